@@ -0,0 +1,27 @@
+package client
+
+import (
+	"github.com/bicycolet/bicycolet/pkg/client"
+	"github.com/pkg/errors"
+)
+
+// Backups represents a way of interacting with the daemon API, which is
+// responsible for backing up the node database.
+type Backups struct {
+	client *Client
+}
+
+// Create starts a backup of the node database, returning a handle to the
+// background operation taking it. Once the operation succeeds, its metadata
+// holds the schema version the backup is named after.
+func (b Backups) Create() (*Operation, error) {
+	var result *Operation
+	if err := b.client.exec("POST", "/1.0/backups", nil, "", func(response *client.Response, meta Metadata) error {
+		var err error
+		result, err = b.client.Operation(response)
+		return err
+	}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"net/http"
+	"path"
 	"time"
 
 	"github.com/bicycolet/bicycolet/pkg/client"
@@ -14,7 +16,9 @@ type Client struct {
 	logger log.Logger
 }
 
-// New creates a Client using the address and certificates.
+// New creates a Client using the address and certificates. An address of the
+// form unix:///path/to/socket connects to a local daemon over its unix socket,
+// in which case no certificates are required.
 func New(address string, options ...Option) (*Client, error) {
 	opts := newOptions()
 	for _, option := range options {
@@ -23,6 +27,11 @@ func New(address string, options ...Option) (*Client, error) {
 
 	client, err := client.New(
 		address,
+		client.WithTLSServerCert(opts.tlsServerCert),
+		client.WithTLSServerFingerprint(opts.tlsServerFingerprint),
+		client.WithTLSClientCert(opts.tlsClientCert),
+		client.WithTLSClientKey(opts.tlsClientKey),
+		client.WithTLSCA(opts.tlsCA),
 		client.WithLogger(opts.logger),
 	)
 	if err != nil {
@@ -41,6 +50,46 @@ func (c *Client) Info() Info {
 	}
 }
 
+// Config returns an API leaf for interacting with the server configuration
+func (c *Client) Config() Config {
+	return Config{
+		client: c,
+	}
+}
+
+// Operations returns an API leaf for interacting with the operations API
+func (c *Client) Operations() Operations {
+	return Operations{
+		client: c,
+	}
+}
+
+// Backups returns an API leaf for backing up the node database
+func (c *Client) Backups() Backups {
+	return Backups{
+		client: c,
+	}
+}
+
+// Operation returns a handle to the background operation processing an
+// asynchronous request, from the response of the daemon to the request.
+func (c *Client) Operation(response *client.Response) (*Operation, error) {
+	if response.Type != client.AsyncResponse {
+		return nil, errors.Errorf("expected an async response, received %q", response.Type)
+	}
+
+	// The metadata of the response is the operation, if it's there at all,
+	// otherwise it's looked up from its URL.
+	if len(response.Metadata) > 0 && string(response.Metadata) != "null" {
+		return newOperation(c, response)
+	}
+	if response.Operation == "" {
+		return nil, errors.Errorf("async response without an operation")
+	}
+	op, err := c.Operations().Get(path.Base(response.Operation))
+	return op, errors.WithStack(err)
+}
+
 func (c *Client) exec(
 	method, path string,
 	body interface{},
@@ -51,7 +100,13 @@ func (c *Client) exec(
 	response, etag, err := c.client.Query(method, path, body, etag)
 	if err != nil {
 		return errors.Wrap(err, "error requesting")
-	} else if response.StatusCode != 200 {
+	}
+
+	// Asynchronous requests return accepted, along with the operation that
+	// is processing the request.
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+	default:
 		return errors.Errorf("invalid status code %d", response.StatusCode)
 	}
 	return fn(response, Metadata{
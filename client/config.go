@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/bicycolet/bicycolet/pkg/api/daemon/root"
+	"github.com/bicycolet/bicycolet/pkg/client"
+	"github.com/pkg/errors"
+)
+
+// Config represents a way of interacting with the daemon API, which is
+// responsible for reading and updating the server configuration.
+type Config struct {
+	client *Client
+}
+
+// Get returns the server configuration, along with the ETag of the
+// configuration, which can be used to guard any following updates.
+func (c Config) Get() (map[string]interface{}, string, error) {
+	var (
+		result map[string]interface{}
+		etag   string
+	)
+	if err := c.client.exec("GET", "/1.0", nil, "", func(response *client.Response, meta Metadata) error {
+		var server root.Server
+		decoder := json.NewDecoder(bytes.NewReader(response.Metadata))
+		if err := decoder.Decode(&server); err != nil {
+			return errors.Wrap(err, "error parsing result")
+		}
+
+		result = make(map[string]interface{}, len(server.Config))
+		for k, v := range server.Config {
+			result[k] = v
+		}
+		etag = meta.ETag
+		return nil
+	}); err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	return result, etag, nil
+}
+
+// Put replaces the server configuration, any keys not in the config are
+// removed. If the etag is not empty, the update is rejected if the server
+// configuration has changed since it was read.
+func (c Config) Put(config map[string]interface{}, etag string) error {
+	return c.update("PUT", config, etag)
+}
+
+// Patch merges the config into the server configuration, any keys with an
+// empty value are removed. If the etag is not empty, the update is rejected
+// if the server configuration has changed since it was read.
+func (c Config) Patch(config map[string]interface{}, etag string) error {
+	return c.update("PATCH", config, etag)
+}
+
+func (c Config) update(method string, config map[string]interface{}, etag string) error {
+	data := root.ServerUpdate{
+		Config: config,
+	}
+	err := c.client.exec(method, "/1.0", data, etag, func(response *client.Response, meta Metadata) error {
+		return nil
+	})
+	return errors.WithStack(err)
+}
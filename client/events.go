@@ -0,0 +1,276 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bicycolet/bicycolet/pkg/client"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EventTypeLogging represents log messages from the daemon
+	EventTypeLogging = "logging"
+
+	// EventTypeOperation represents changes to background operations
+	EventTypeOperation = "operation"
+
+	// EventTypeLifecycle represents changes to the state of the daemon
+	EventTypeLifecycle = "lifecycle"
+)
+
+const (
+	reconnectMinBackoff = 250 * time.Millisecond
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// Event represents a message sent from the daemon events API
+type Event struct {
+	Type      string          `json:"type" yaml:"type"`
+	Timestamp time.Time       `json:"timestamp" yaml:"timestamp"`
+	Metadata  json.RawMessage `json:"metadata" yaml:"metadata"`
+}
+
+// Operation returns the operation of an operation event
+func (e Event) Operation() (OperationResult, error) {
+	if e.Type != EventTypeOperation {
+		return OperationResult{}, errors.Errorf("expected operation event, got %q", e.Type)
+	}
+
+	var op client.Operation
+	if err := json.Unmarshal(e.Metadata, &op); err != nil {
+		return OperationResult{}, errors.Wrap(err, "error parsing operation")
+	}
+	return newOperationResult(op), nil
+}
+
+// Lifecycle returns the lifecycle of a lifecycle event
+func (e Event) Lifecycle() (EventLifecycle, error) {
+	var result EventLifecycle
+	if e.Type != EventTypeLifecycle {
+		return result, errors.Errorf("expected lifecycle event, got %q", e.Type)
+	}
+
+	if err := json.Unmarshal(e.Metadata, &result); err != nil {
+		return result, errors.Wrap(err, "error parsing lifecycle")
+	}
+	return result, nil
+}
+
+// Logging returns the log line of a logging event
+func (e Event) Logging() (EventLogging, error) {
+	var result EventLogging
+	if e.Type != EventTypeLogging {
+		return result, errors.Errorf("expected logging event, got %q", e.Type)
+	}
+
+	if err := json.Unmarshal(e.Metadata, &result); err != nil {
+		return result, errors.Wrap(err, "error parsing logging")
+	}
+	return result, nil
+}
+
+// EventLifecycle contains the result of a lifecycle event
+type EventLifecycle struct {
+	Action  string                 `json:"action" yaml:"action"`
+	Source  string                 `json:"source" yaml:"source"`
+	Context map[string]interface{} `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// EventLogging contains the result of a logging event
+type EventLogging struct {
+	Level   string            `json:"level" yaml:"level"`
+	Message string            `json:"message" yaml:"message"`
+	Context map[string]string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// EventTarget is returned from AddHandler and can be used to remove the
+// handler again.
+type EventTarget struct {
+	types    []string
+	function func(Event)
+}
+
+// EventListener listens to the daemon events API, calling the handlers for
+// every event that is received. If the connection to the daemon is lost, the
+// listener reconnects until it is disconnected.
+type EventListener struct {
+	client *Client
+	path   string
+
+	mutex   sync.Mutex
+	conn    *websocket.Conn
+	targets []*EventTarget
+
+	done chan struct{}
+	once sync.Once
+}
+
+// Events connects to the daemon events API, listening to the given event
+// types. No types will listen to all event types.
+func (c *Client) Events(types ...string) (*EventListener, error) {
+	path := "/events"
+	if len(types) > 0 {
+		path = fmt.Sprintf("%s?type=%s", path, url.QueryEscape(strings.Join(types, ",")))
+	}
+
+	conn, err := c.client.Websocket(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to events")
+	}
+
+	listener := &EventListener{
+		client: c,
+		path:   path,
+		conn:   conn,
+		done:   make(chan struct{}),
+	}
+	go listener.run(conn)
+	return listener, nil
+}
+
+// AddHandler adds a function to be called whenever an event is received. No
+// types will call the function for every event type.
+func (e *EventListener) AddHandler(types []string, function func(Event)) *EventTarget {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	target := &EventTarget{
+		types:    types,
+		function: function,
+	}
+	e.targets = append(e.targets, target)
+	return target
+}
+
+// RemoveHandler removes a function to be called whenever an event is received
+func (e *EventListener) RemoveHandler(target *EventTarget) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for i, entry := range e.targets {
+		if entry == target {
+			e.targets = append(e.targets[:i], e.targets[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("handler not found")
+}
+
+// Disconnect must be used once done listening for events
+func (e *EventListener) Disconnect() {
+	e.once.Do(func() {
+		close(e.done)
+
+		e.mutex.Lock()
+		if e.conn != nil {
+			e.conn.Close()
+		}
+		e.mutex.Unlock()
+	})
+}
+
+// Wait blocks until Disconnect is called
+func (e *EventListener) Wait() {
+	<-e.done
+}
+
+func (e *EventListener) run(conn *websocket.Conn) {
+	for {
+		err := e.read(conn)
+		conn.Close()
+
+		select {
+		case <-e.done:
+			return
+		default:
+		}
+
+		level.Debug(e.client.logger).Log("msg", "lost connection to events", "err", err)
+
+		if conn = e.reconnect(); conn == nil {
+			return
+		}
+	}
+}
+
+func (e *EventListener) read(conn *websocket.Conn) error {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			level.Debug(e.client.logger).Log("msg", "invalid event", "err", err)
+			continue
+		}
+
+		e.mutex.Lock()
+		targets := make([]*EventTarget, len(e.targets))
+		copy(targets, e.targets)
+		e.mutex.Unlock()
+
+		for _, target := range targets {
+			if len(target.types) > 0 && !contains(target.types, event.Type) {
+				continue
+			}
+			target.function(event)
+		}
+	}
+}
+
+// reconnect attempts to connect to the events API until it either succeeds,
+// or the listener is disconnected, in which case nil is returned.
+func (e *EventListener) reconnect() *websocket.Conn {
+	backoff := reconnectMinBackoff
+	for {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-e.done:
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		conn, err := e.client.client.Websocket(e.path)
+		if err != nil {
+			level.Debug(e.client.logger).Log("msg", "failed to reconnect to events", "err", err, "backoff", backoff)
+
+			if backoff *= 2; backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+			continue
+		}
+
+		e.mutex.Lock()
+		select {
+		case <-e.done:
+			e.mutex.Unlock()
+			conn.Close()
+			return nil
+		default:
+		}
+		e.conn = conn
+		e.mutex.Unlock()
+
+		level.Debug(e.client.logger).Log("msg", "reconnected to events")
+		return conn
+	}
+}
+
+func contains(a []string, b string) bool {
+	for _, v := range a {
+		if v == b {
+			return true
+		}
+	}
+	return false
+}
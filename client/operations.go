@@ -0,0 +1,168 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/bicycolet/bicycolet/pkg/client"
+	"github.com/pkg/errors"
+)
+
+// Operations represents a way of interacting with the daemon API, which is
+// responsible for inspecting the background operations of the daemon.
+type Operations struct {
+	client *Client
+}
+
+// List returns all the operations known to the daemon
+func (o Operations) List() ([]OperationResult, error) {
+	var result []OperationResult
+	if err := o.client.exec("GET", "/1.0/operations", nil, "", func(response *client.Response, meta Metadata) error {
+		var ops []client.Operation
+		decoder := json.NewDecoder(bytes.NewReader(response.Metadata))
+		if err := decoder.Decode(&ops); err != nil {
+			return errors.Wrap(err, "error parsing result")
+		}
+
+		result = make([]OperationResult, len(ops))
+		for k, op := range ops {
+			result[k] = newOperationResult(op)
+		}
+		return nil
+	}); err != nil {
+		return result, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+// Get returns a handle to the operation with the given id
+func (o Operations) Get(id string) (*Operation, error) {
+	var result *Operation
+	if err := o.client.exec("GET", operationPath(id, ""), nil, "", func(response *client.Response, meta Metadata) error {
+		var err error
+		result, err = newOperation(o.client, response)
+		return err
+	}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+// Operation represents a handle to a background operation on the daemon.
+type Operation struct {
+	client *Client
+	result OperationResult
+}
+
+func newOperation(c *Client, response *client.Response) (*Operation, error) {
+	var op client.Operation
+	decoder := json.NewDecoder(bytes.NewReader(response.Metadata))
+	if err := decoder.Decode(&op); err != nil {
+		return nil, errors.Wrap(err, "error parsing operation")
+	}
+	return &Operation{
+		client: c,
+		result: newOperationResult(op),
+	}, nil
+}
+
+// ID returns the id of the operation
+func (o *Operation) ID() string {
+	return o.result.ID
+}
+
+// Result returns the last known state of the operation
+func (o *Operation) Result() OperationResult {
+	return o.result
+}
+
+// Metadata returns the last known progress metadata of the operation
+func (o *Operation) Metadata() map[string]interface{} {
+	return o.result.Metadata
+}
+
+// Refresh updates the state of the operation from the daemon
+func (o *Operation) Refresh() error {
+	op, err := o.client.Operations().Get(o.ID())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	o.result = op.result
+	return nil
+}
+
+// Wait for the operation to complete. A negative timeout will wait until
+// the operation is completed. An error is returned if the operation didn't
+// complete in time or if the operation didn't succeed.
+func (o *Operation) Wait(timeout time.Duration) error {
+	var query string
+	if timeout >= 0 {
+		query = fmt.Sprintf("?timeout=%s", timeout)
+	}
+
+	if err := o.client.exec("GET", operationPath(o.ID(), "/wait")+query, nil, "", func(response *client.Response, meta Metadata) error {
+		op, err := newOperation(o.client, response)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		o.result = op.result
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	switch o.result.Status {
+	case "success":
+		return nil
+	case "failure":
+		return errors.Errorf("operation %q failed: %s", o.ID(), o.result.Err)
+	case "cancelled":
+		return errors.Errorf("operation %q was cancelled", o.ID())
+	default:
+		return errors.Errorf("timed out waiting for operation %q", o.ID())
+	}
+}
+
+// Cancel requests that the daemon cancels the operation
+func (o *Operation) Cancel() error {
+	if err := o.client.exec("DELETE", operationPath(o.ID(), ""), nil, "", nopReadFn); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// OperationResult contains the result of querying the daemon operations API
+type OperationResult struct {
+	ID          string                 `json:"id" yaml:"id"`
+	Description string                 `json:"description" yaml:"description"`
+	Status      string                 `json:"status" yaml:"status"`
+	StatusCode  int                    `json:"status_code" yaml:"status_code"`
+	Metadata    map[string]interface{} `json:"metadata" yaml:"metadata"`
+	CreatedAt   time.Time              `json:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" yaml:"updated_at"`
+	Err         string                 `json:"err,omitempty" yaml:"err,omitempty"`
+}
+
+func newOperationResult(op client.Operation) OperationResult {
+	return OperationResult{
+		ID:          op.ID,
+		Description: op.Description,
+		Status:      op.Status,
+		StatusCode:  op.StatusCode,
+		Metadata:    op.Metadata,
+		CreatedAt:   op.CreatedAt,
+		UpdatedAt:   op.UpdatedAt,
+		Err:         op.Err,
+	}
+}
+
+func nopReadFn(*client.Response, Metadata) error {
+	return nil
+}
+
+func operationPath(id, suffix string) string {
+	return fmt.Sprintf("/1.0/operations/%s%s", url.PathEscape(id), suffix)
+}
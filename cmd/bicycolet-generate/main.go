@@ -0,0 +1,68 @@
+// Command bicycolet-generate generates the NodeTx methods for fetching,
+// creating and deleting the entities annotated in a Go source file, along
+// with their tests.
+//
+// It's meant to be run through go generate, for example:
+//
+//	//go:generate go run github.com/bicycolet/bicycolet/cmd/bicycolet-generate -source entities.go -output entities_gen.go -test entities_gen_test.go
+//
+// An entity is a struct whose fields are mapped to the columns of a table
+// with "db" struct tags, and whose doc comment is annotated with a line
+// such as:
+//
+//	generate:entity table=raft_nodes filters=Address
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/bicycolet/bicycolet/internal/db/generate"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	source := flag.String("source", "", "Go source file declaring the entities")
+	output := flag.String("output", "", "Go source file to write the methods to")
+	test := flag.String("test", "", "Go source file to write the tests to, if any")
+	flag.Parse()
+
+	if err := run(*source, *output, *test); err != nil {
+		fmt.Fprintf(os.Stderr, "bicycolet-generate: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(source, output, test string) error {
+	if source == "" || output == "" {
+		return errors.Errorf("both -source and -output are required")
+	}
+
+	src, err := ioutil.ReadFile(source)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	pkg, entities, err := generate.Parse(source, src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	code, err := generate.Source(pkg, entities)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ioutil.WriteFile(output, code, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if test == "" {
+		return nil
+	}
+	code, err = generate.Test(pkg, entities)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(ioutil.WriteFile(test, code, 0644))
+}
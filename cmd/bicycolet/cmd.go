@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/bicycolet/bicycolet/client"
+	"github.com/bicycolet/bicycolet/internal/cert"
+	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
 	"github.com/spoke-d/clui"
@@ -134,9 +139,108 @@ func contains(a []string, b string) bool {
 	return false
 }
 
-func getClient(address string, logger log.Logger) (*client.Client, error) {
+const (
+	// defaultDataDir is the default directory the daemon stores its data in.
+	defaultDataDir = "/var/lib/bicycolet"
+
+	// defaultNetworkAddress is the default address of the daemon API.
+	defaultNetworkAddress = "127.0.0.1:8080"
+)
+
+// databaseFlags defines the flags that are required for opening the node
+// database.
+type databaseFlags struct {
+	dataDir        string
+	connectionInfo database.ConnectionInfo
+}
+
+func (f *databaseFlags) init(flagset *flagset.FlagSet) {
+	flagset.StringVar(&f.dataDir, "data-dir", defaultDataDir, "directory to store the daemon data in")
+	flagset.StringVar(&f.connectionInfo.Driver, "db-driver", database.Postgres, "driver of the database, either postgres or sqlite3 for a local database in the data directory")
+	flagset.StringVar(&f.connectionInfo.Host, "db-host", "localhost", "host of the database server")
+	flagset.IntVar(&f.connectionInfo.Port, "db-port", 5432, "port of the database server")
+	flagset.StringVar(&f.connectionInfo.User, "db-user", "postgres", "user for the database server")
+	flagset.StringVar(&f.connectionInfo.Password, "db-password", "", "password for the database server")
+	flagset.StringVar(&f.connectionInfo.DBName, "db-name", "bicycolet", "name of the database")
+}
+
+// clientFlags defines the flags that are required for connecting to the
+// daemon API.
+type clientFlags struct {
+	address              string
+	tlsClientCert        string
+	tlsClientKey         string
+	tlsCA                string
+	tlsServerCert        string
+	tlsServerFingerprint string
+}
+
+func (f *clientFlags) init(flagset *flagset.FlagSet) {
+	dir := configDir()
+	flagset.StringVar(&f.address, "address", defaultAddress(), "address of the api server, or unix:// followed by the path of its unix socket")
+	flagset.StringVar(&f.tlsClientCert, "tls-client-cert", filepath.Join(dir, "client.crt"), "client certificate, generated if it doesn't exist")
+	flagset.StringVar(&f.tlsClientKey, "tls-client-key", filepath.Join(dir, "client.key"), "client key, generated if it doesn't exist")
+	flagset.StringVar(&f.tlsCA, "tls-ca", "", "CA bundle to verify the api server certificate with")
+	flagset.StringVar(&f.tlsServerCert, "tls-server-cert", "", "certificate of the api server to trust")
+	flagset.StringVar(&f.tlsServerFingerprint, "tls-server-fingerprint", "", "fingerprint of the api server certificate to trust")
+}
+
+func getClient(flags clientFlags, logger log.Logger) (*client.Client, error) {
+	// Local clients don't require any certificates, the unix socket file
+	// permissions take care of the access.
+	if strings.HasPrefix(flags.address, "unix://") {
+		return client.New(
+			flags.address,
+			client.WithLogger(log.WithPrefix(logger, "component", "client")),
+		)
+	}
+
+	if err := cert.FindOrGenCert(flags.tlsClientCert, flags.tlsClientKey, cert.Client); err != nil {
+		return nil, errors.Wrap(err, "error generating client certificate")
+	}
+
+	files := map[string]string{
+		flags.tlsClientCert: "",
+		flags.tlsClientKey:  "",
+		flags.tlsCA:         "",
+		flags.tlsServerCert: "",
+	}
+	for path := range files {
+		if path == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files[path] = string(data)
+	}
+
 	return client.New(
-		address,
+		flags.address,
+		client.WithTLSClientCert(files[flags.tlsClientCert]),
+		client.WithTLSClientKey(files[flags.tlsClientKey]),
+		client.WithTLSCA(files[flags.tlsCA]),
+		client.WithTLSServerCert(files[flags.tlsServerCert]),
+		client.WithTLSServerFingerprint(flags.tlsServerFingerprint),
 		client.WithLogger(log.WithPrefix(logger, "component", "client")),
 	)
 }
+
+// defaultAddress returns the unix socket of a local daemon if it exists,
+// otherwise the default network address.
+func defaultAddress() string {
+	path := filepath.Join(defaultDataDir, "unix.socket")
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Sprintf("unix://%s", path)
+	}
+	return defaultNetworkAddress
+}
+
+// configDir returns the directory that holds the client configuration.
+func configDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "bicycolet")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "bicycolet")
+}
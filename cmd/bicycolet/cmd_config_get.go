@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/bicycolet/bicycolet/internal/exec"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type configGetCmd struct {
+	baseCmd
+	clientFlags
+}
+
+// NewConfigGetCmd creates a Command with sane defaults
+func NewConfigGetCmd(ui clui.UI) clui.Command {
+	c := &configGetCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("config get", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *configGetCmd) init() {
+	c.baseCmd.init()
+	c.clientFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *configGetCmd) Help() string {
+	return `
+Usage:
+  config get [flags] <key>
+Description:
+  Get the value of a key of the server configuration.
+  An empty value is output if the key isn't set.
+Example:
+  bicycolet config get operations.retention
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *configGetCmd) Synopsis() string {
+	return "Get a value of the server configuration."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *configGetCmd) Run() clui.ExitCode {
+	// Logging.
+	var logger log.Logger
+	{
+		logLevel := level.AllowInfo()
+		if c.debug {
+			logLevel = level.AllowAll()
+		}
+		logger = NewLogCluiFormatter(c.UI())
+		logger = log.With(logger,
+			"ts", log.DefaultTimestampUTC,
+			"uid", uuid.NewRandom().String(),
+		)
+		logger = level.NewFilter(logger, logLevel)
+	}
+
+	args := c.flagset.Args()
+	if len(args) != 1 {
+		return exit(c.ui, "expected exactly one argument: <key>")
+	}
+	key := args[0]
+
+	client, err := getClient(c.clientFlags, logger)
+	if err != nil {
+		return exit(c.ui, errors.WithStack(err).Error())
+	}
+
+	g := exec.NewGroup()
+	exec.Block(g)
+	{
+		g.Add(func() error {
+			config, _, err := client.Config().Get()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			var value string
+			if v, ok := config[key]; ok {
+				value = fmt.Sprintf("%v", v)
+			}
+			c.ui.Output(value)
+			return nil
+		}, func(err error) {
+			// ignore
+		})
+	}
+	exec.Interrupt(g)
+	if err := g.Run(); err != nil {
+		return exit(c.ui, err.Error())
+	}
+
+	return clui.ExitCode{}
+}
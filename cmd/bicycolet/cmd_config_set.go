@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/exec"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type configSetCmd struct {
+	baseCmd
+	clientFlags
+}
+
+// NewConfigSetCmd creates a Command with sane defaults
+func NewConfigSetCmd(ui clui.UI) clui.Command {
+	c := &configSetCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("config set", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *configSetCmd) init() {
+	c.baseCmd.init()
+	c.clientFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *configSetCmd) Help() string {
+	return `
+Usage:
+  config set [flags] <key> <value>
+Description:
+  Set the value of a key of the server configuration.
+  The update is rejected if the configuration is changed
+  by someone else at the same time.
+Example:
+  bicycolet config set operations.retention 1h
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *configSetCmd) Synopsis() string {
+	return "Set a value of the server configuration."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *configSetCmd) Run() clui.ExitCode {
+	// Logging.
+	var logger log.Logger
+	{
+		logLevel := level.AllowInfo()
+		if c.debug {
+			logLevel = level.AllowAll()
+		}
+		logger = NewLogCluiFormatter(c.UI())
+		logger = log.With(logger,
+			"ts", log.DefaultTimestampUTC,
+			"uid", uuid.NewRandom().String(),
+		)
+		logger = level.NewFilter(logger, logLevel)
+	}
+
+	args := c.flagset.Args()
+	if len(args) < 2 {
+		return exit(c.ui, "expected arguments: <key> <value>")
+	}
+	key, value := args[0], strings.Join(args[1:], " ")
+
+	client, err := getClient(c.clientFlags, logger)
+	if err != nil {
+		return exit(c.ui, errors.WithStack(err).Error())
+	}
+
+	g := exec.NewGroup()
+	exec.Block(g)
+	{
+		g.Add(func() error {
+			config, etag, err := client.Config().Get()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			// Config already matches the value, no-op.
+			if v, ok := config[key]; ok && v == value {
+				return nil
+			}
+
+			if err := client.Config().Patch(map[string]interface{}{
+				key: value,
+			}, etag); err != nil {
+				return errors.WithStack(err)
+			}
+
+			c.ui.Output(fmt.Sprintf("Set %q to %q", key, value))
+			return nil
+		}, func(err error) {
+			// ignore
+		})
+	}
+	exec.Interrupt(g)
+	if err := g.Run(); err != nil {
+		return exit(c.ui, err.Error())
+	}
+
+	return clui.ExitCode{}
+}
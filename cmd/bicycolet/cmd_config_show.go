@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/bicycolet/bicycolet/internal/exec"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type configShowCmd struct {
+	baseCmd
+	clientFlags
+}
+
+// NewConfigShowCmd creates a Command with sane defaults
+func NewConfigShowCmd(ui clui.UI) clui.Command {
+	c := &configShowCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("config show", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *configShowCmd) init() {
+	c.baseCmd.init()
+	c.clientFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *configShowCmd) Help() string {
+	return `
+Usage:
+  config show [flags]
+Description:
+  Show the server configuration as JSON, YAML or Tabular.
+Example:
+  bicycolet config show
+  bicycolet config show --format=json
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *configShowCmd) Synopsis() string {
+	return "Show the server configuration."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *configShowCmd) Run() clui.ExitCode {
+	// Logging.
+	var logger log.Logger
+	{
+		logLevel := level.AllowInfo()
+		if c.debug {
+			logLevel = level.AllowAll()
+		}
+		logger = NewLogCluiFormatter(c.UI())
+		logger = log.With(logger,
+			"ts", log.DefaultTimestampUTC,
+			"uid", uuid.NewRandom().String(),
+		)
+		logger = level.NewFilter(logger, logLevel)
+	}
+
+	client, err := getClient(c.clientFlags, logger)
+	if err != nil {
+		return exit(c.ui, errors.WithStack(err).Error())
+	}
+
+	g := exec.NewGroup()
+	exec.Block(g)
+	{
+		g.Add(func() error {
+			config, _, err := client.Config().Get()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return c.Output(config)
+		}, func(err error) {
+			// ignore
+		})
+	}
+	exec.Interrupt(g)
+	if err := g.Run(); err != nil {
+		return exit(c.ui, err.Error())
+	}
+
+	return clui.ExitCode{}
+}
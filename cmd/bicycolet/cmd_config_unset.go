@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/bicycolet/bicycolet/internal/exec"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type configUnsetCmd struct {
+	baseCmd
+	clientFlags
+}
+
+// NewConfigUnsetCmd creates a Command with sane defaults
+func NewConfigUnsetCmd(ui clui.UI) clui.Command {
+	c := &configUnsetCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("config unset", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *configUnsetCmd) init() {
+	c.baseCmd.init()
+	c.clientFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *configUnsetCmd) Help() string {
+	return `
+Usage:
+  config unset [flags] <key>
+Description:
+  Remove a key from the server configuration.
+Example:
+  bicycolet config unset core.debug_address
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *configUnsetCmd) Synopsis() string {
+	return "Unset a value of the server configuration."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *configUnsetCmd) Run() clui.ExitCode {
+	// Logging.
+	var logger log.Logger
+	{
+		logLevel := level.AllowInfo()
+		if c.debug {
+			logLevel = level.AllowAll()
+		}
+		logger = NewLogCluiFormatter(c.UI())
+		logger = log.With(logger,
+			"ts", log.DefaultTimestampUTC,
+			"uid", uuid.NewRandom().String(),
+		)
+		logger = level.NewFilter(logger, logLevel)
+	}
+
+	args := c.flagset.Args()
+	if len(args) != 1 {
+		return exit(c.ui, "expected exactly one argument: <key>")
+	}
+	key := args[0]
+
+	client, err := getClient(c.clientFlags, logger)
+	if err != nil {
+		return exit(c.ui, errors.WithStack(err).Error())
+	}
+
+	g := exec.NewGroup()
+	exec.Block(g)
+	{
+		g.Add(func() error {
+			config, etag, err := client.Config().Get()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			// Config doesn't have the key, no-op.
+			if _, ok := config[key]; !ok {
+				return nil
+			}
+
+			if err := client.Config().Patch(map[string]interface{}{
+				key: "",
+			}, etag); err != nil {
+				return errors.WithStack(err)
+			}
+
+			c.ui.Output(fmt.Sprintf("Unset %q", key))
+			return nil
+		}, func(err error) {
+			// ignore
+		})
+	}
+	exec.Interrupt(g)
+	if err := g.Run(); err != nil {
+		return exit(c.ui, err.Error())
+	}
+
+	return clui.ExitCode{}
+}
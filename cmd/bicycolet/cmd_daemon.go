@@ -0,0 +1,229 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/events"
+	"github.com/bicycolet/bicycolet/internal/exec"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/bicycolet/bicycolet/pkg/api/daemon/backups"
+	eventsapi "github.com/bicycolet/bicycolet/pkg/api/daemon/events"
+	"github.com/bicycolet/bicycolet/pkg/api/daemon/operations"
+	"github.com/bicycolet/bicycolet/pkg/api/daemon/root"
+	"github.com/bicycolet/bicycolet/pkg/daemon"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/expvar"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type daemonCmd struct {
+	baseCmd
+	databaseFlags
+	serverVersion         string
+	networkAddress        string
+	slowQueryThreshold    time.Duration
+	acceptSchemaChecksums bool
+}
+
+// NewDaemonCmd creates a Command with sane defaults
+func NewDaemonCmd(ui clui.UI, serverVersion string) clui.Command {
+	c := &daemonCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("daemon", flag.ExitOnError),
+		},
+		serverVersion: serverVersion,
+	}
+	c.init()
+	return c
+}
+
+func (c *daemonCmd) init() {
+	c.baseCmd.init()
+	c.flagset.StringVar(&c.networkAddress, "network-address", defaultNetworkAddress, "address to bind the api server to")
+	c.flagset.DurationVar(&c.slowQueryThreshold, "db-slow-query-threshold", 500*time.Millisecond, "log database statements taking longer than this, zero disables the log")
+	c.flagset.BoolVar(&c.acceptSchemaChecksums, "db-accept-schema-checksums", false, "accept the schema updates that changed since they were applied to the database")
+	c.databaseFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *daemonCmd) Help() string {
+	return `
+Usage:
+  daemon [flags]
+Description:
+  Run the bicycolet daemon, serving the REST API.
+  The daemon opens the node database, ensures that the
+  schema is up to date and then serves the API until it
+  receives an interrupt or terminate signal. A backup of
+  the database is taken before its schema is updated, see
+  "bicycolet db restore".
+
+  The checksum of every schema update is recorded along
+  with it, and the daemon refuses to start if an update
+  has changed since it was applied. Once the change is
+  known to be harmless, start the daemon once with the
+  db-accept-schema-checksums flag to record the new
+  checksums.
+
+  The API is served over TLS, using the server.crt and
+  server.key within the data directory, which are
+  generated on first start. Only clients with trusted
+  certificates are accepted; a client certificate is
+  trusted if it's in the trusted directory within the
+  data directory, or if it's signed by the server.ca.
+
+  Local clients can use the unix.socket within the data
+  directory instead, access to which is controlled by the
+  socket file permissions.
+
+  The database metrics are published on /debug/vars of the
+  debug endpoint, when the core.debug_address is set. The
+  query durations have a histogram per statement.
+Example:
+  bicycolet daemon
+  bicycolet daemon --network-address=0.0.0.0:8080
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *daemonCmd) Synopsis() string {
+	return "Run the daemon."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *daemonCmd) Run() clui.ExitCode {
+	// Logging.
+	var (
+		logger      log.Logger
+		broadcaster *events.Broadcaster
+	)
+	{
+		logLevel := level.AllowInfo()
+		if c.debug {
+			logLevel = level.AllowAll()
+		}
+		logger = NewLogCluiFormatter(c.UI())
+
+		// Every log line is also sent to any logging event listeners. The
+		// broadcaster itself can't log to them, so it gets the plain logger.
+		broadcaster = events.New(
+			events.WithLogger(level.NewFilter(log.WithPrefix(logger, "component", "events"), logLevel)),
+		)
+		logger = events.NewLogger(logger, broadcaster)
+		logger = log.With(logger,
+			"ts", log.DefaultTimestampUTC,
+			"uid", uuid.NewRandom().String(),
+		)
+		logger = level.NewFilter(logger, logLevel)
+	}
+
+	var fileSystem fsys.FileSystem
+	{
+		config, err := fsys.Build(
+			fsys.With("local"),
+		)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fs, err := fsys.New(config)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fileSystem = fs
+	}
+
+	// Database metrics, which are published as expvars. Expvar histograms
+	// ignore their labels, so every statement gets its own, named after it.
+	queryDuration := database.NewStatementHistogram(func(query string) metrics.Histogram {
+		name := "db_query_duration_seconds"
+		if query != "" {
+			name = fmt.Sprintf("%s{query=%q}", name, query)
+		}
+		return expvar.NewHistogram(name, 50)
+	})
+	databaseMetrics := database.Metrics{
+		QueryDuration: queryDuration,
+		TxDuration:    expvar.NewHistogram("db_tx_duration_seconds", 50),
+		Commits:       expvar.NewCounter("db_commits_total"),
+		Rollbacks:     expvar.NewCounter("db_rollbacks_total"),
+		OpenTxs:       expvar.NewGauge("db_open_txs"),
+	}
+
+	apiServices := []api.Service{
+		root.NewAPI(
+			root.WithLogger(log.WithPrefix(logger, "api", "root")),
+		),
+		eventsapi.NewAPI(
+			eventsapi.WithLogger(log.WithPrefix(logger, "api", "events")),
+		),
+		operations.NewAPI(
+			operations.WithLogger(log.WithPrefix(logger, "api", "operations")),
+		),
+		operations.NewIdentityAPI(
+			operations.WithLogger(log.WithPrefix(logger, "api", "operations")),
+		),
+		operations.NewWaitAPI(
+			operations.WithLogger(log.WithPrefix(logger, "api", "operations")),
+		),
+		backups.NewAPI(
+			backups.WithLogger(log.WithPrefix(logger, "api", "backups")),
+		),
+	}
+
+	d := daemon.New(
+		c.serverVersion,
+		c.networkAddress,
+		c.dataDir,
+		c.connectionInfo,
+		apiServices,
+		daemon.WithFileSystem(fileSystem),
+		daemon.WithEvents(broadcaster),
+		daemon.WithLogger(log.WithPrefix(logger, "component", "daemon")),
+		daemon.WithDatabaseMetrics(databaseMetrics),
+		daemon.WithSlowQueryThreshold(c.slowQueryThreshold),
+		daemon.WithAcceptSchemaChecksums(c.acceptSchemaChecksums),
+	)
+
+	var stopErr error
+	g := exec.NewGroup()
+	{
+		g.Add(func() error {
+			if err := d.Init(); err != nil {
+				d.Stop()
+				return errors.WithStack(err)
+			}
+			<-d.ShutdownChan()
+			stopErr = d.Stop()
+			return stopErr
+		}, func(err error) {
+			d.Kill()
+		})
+	}
+	exec.Interrupt(g)
+	if err := g.Run(); err != nil && !exec.ErrInterrupt(err) {
+		return exit(c.ui, err.Error())
+	}
+	if stopErr != nil {
+		return exit(c.ui, stopErr.Error())
+	}
+
+	return clui.ExitCode{}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbCmd struct {
+	baseCmd
+}
+
+// NewDBCmd creates a Command with sane defaults
+func NewDBCmd(ui clui.UI) clui.Command {
+	c := &dbCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db", flag.ExitOnError),
+		},
+	}
+	return c
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbCmd) Help() string {
+	return `
+Usage:
+  db [flags]
+Description:
+  Manage the node database.
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbCmd) Synopsis() string {
+	return "Manage the node database."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbCmd) Run() clui.ExitCode {
+	return clui.ExitCode{
+		ShowHelp: true,
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbCheckCmd struct {
+	baseCmd
+	databaseFlags
+}
+
+// NewDBCheckCmd creates a Command with sane defaults
+func NewDBCheckCmd(ui clui.UI) clui.Command {
+	c := &dbCheckCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db check", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *dbCheckCmd) init() {
+	c.databaseFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbCheckCmd) Help() string {
+	return `
+Usage:
+  db check [flags]
+Description:
+  Check the node database schema for drift.
+
+  The tables and indexes of the node database, along
+  with their columns and constraints, are compared with
+  the ones expected from the schema updates applied to
+  it. Any object that's missing, extra or changed, for
+  example by a manual hot-fix, is reported and the
+  command fails.
+
+  The database is left unchanged.
+Example:
+  bicycolet db check
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbCheckCmd) Synopsis() string {
+	return "Check the node database schema for drift."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbCheckCmd) Run() clui.ExitCode {
+	var fileSystem fsys.FileSystem
+	{
+		config, err := fsys.Build(
+			fsys.With("local"),
+		)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fs, err := fsys.New(config)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fileSystem = fs
+	}
+
+	n := node.New(fileSystem)
+	if err := n.Open(filepath.Join(c.dataDir, "database"), c.connectionInfo); err != nil {
+		return exit(c.ui, errors.Wrap(err, "error opening database").Error())
+	}
+	defer n.Close()
+
+	drifts, err := n.VerifySchema()
+	if err != nil {
+		return exit(c.ui, err.Error())
+	}
+	if len(drifts) == 0 {
+		c.ui.Output("Database schema matches the applied updates")
+		return clui.ExitCode{}
+	}
+	for _, drift := range drifts {
+		c.ui.Output(drift.String())
+	}
+	return exit(c.ui, fmt.Sprintf("database schema has drifted from the applied updates (%d differences)", len(drifts)))
+}
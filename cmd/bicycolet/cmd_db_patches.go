@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbPatchesCmd struct {
+	baseCmd
+	databaseFlags
+}
+
+// NewDBPatchesCmd creates a Command with sane defaults
+func NewDBPatchesCmd(ui clui.UI) clui.Command {
+	c := &dbPatchesCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db patches", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *dbPatchesCmd) init() {
+	c.databaseFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbPatchesCmd) Help() string {
+	return `
+Usage:
+  db patches [flags]
+Description:
+  List the data patches of the node database.
+
+  Data patches fix up the data of the node database, once
+  its schema is up to date. Each patch is run once, either
+  while the database is opened or, for the long running
+  ones, in background once the daemon is up. The patches
+  are listed in the order they're run, along with when
+  they were.
+Example:
+  bicycolet db patches
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbPatchesCmd) Synopsis() string {
+	return "List the node database patches."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbPatchesCmd) Run() clui.ExitCode {
+	var fileSystem fsys.FileSystem
+	{
+		config, err := fsys.Build(
+			fsys.With("local"),
+		)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fs, err := fsys.New(config)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fileSystem = fs
+	}
+
+	n := node.New(fileSystem)
+	if err := n.Open(filepath.Join(c.dataDir, "database"), c.connectionInfo); err != nil {
+		return exit(c.ui, errors.Wrap(err, "error opening database").Error())
+	}
+	defer n.Close()
+
+	statuses, err := n.Patches(context.Background())
+	if err != nil {
+		return exit(c.ui, err.Error())
+	}
+	if len(statuses) == 0 {
+		c.ui.Output("No patches")
+		return clui.ExitCode{}
+	}
+	for _, status := range statuses {
+		line := status.Name
+		if status.Background {
+			line += " (background)"
+		}
+		if status.AppliedAt.IsZero() {
+			line += ": pending"
+		} else {
+			line += ": applied at " + status.AppliedAt.Format(time.RFC3339)
+		}
+		c.ui.Output(line)
+	}
+	return clui.ExitCode{}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbRestoreCmd struct {
+	baseCmd
+	databaseFlags
+}
+
+// NewDBRestoreCmd creates a Command with sane defaults
+func NewDBRestoreCmd(ui clui.UI) clui.Command {
+	c := &dbRestoreCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db restore", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *dbRestoreCmd) init() {
+	c.databaseFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbRestoreCmd) Help() string {
+	return `
+Usage:
+  db restore [flags] [<version>]
+Description:
+  Restore the node database from a backup.
+
+  The daemon takes a backup of the node database before
+  updating its schema, or when one is requested through
+  the backups API, named after the schema version it was
+  taken at. Without a version the available backups are
+  listed, otherwise the database is replaced with the
+  backup of that version.
+
+  The daemon must not be running while restoring.
+Example:
+  bicycolet db restore
+  bicycolet db restore 1
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbRestoreCmd) Synopsis() string {
+	return "Restore the node database from a backup."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbRestoreCmd) Run() clui.ExitCode {
+	args := c.flagset.Args()
+	if len(args) > 1 {
+		return exit(c.ui, "expected at most one argument: [<version>]")
+	}
+
+	var fileSystem fsys.FileSystem
+	{
+		config, err := fsys.Build(
+			fsys.With("local"),
+		)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fs, err := fsys.New(config)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fileSystem = fs
+	}
+
+	n := node.New(fileSystem)
+	if err := n.Open(filepath.Join(c.dataDir, "database"), c.connectionInfo); err != nil {
+		return exit(c.ui, errors.Wrap(err, "error opening database").Error())
+	}
+	defer n.Close()
+
+	if len(args) == 0 {
+		versions, err := n.Backups()
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		for _, version := range versions {
+			c.ui.Output(strconv.Itoa(version))
+		}
+		return clui.ExitCode{}
+	}
+
+	version, err := strconv.Atoi(args[0])
+	if err != nil {
+		return exit(c.ui, fmt.Sprintf("invalid version %q", args[0]))
+	}
+
+	// Replacing the database underneath a running daemon would leave it in
+	// an unknown state.
+	if conn, err := net.Dial("unix", filepath.Join(c.dataDir, "unix.socket")); err == nil {
+		conn.Close()
+		return exit(c.ui, "daemon is running, stop it before restoring the database")
+	}
+
+	if err := n.Restore(context.Background(), version); err != nil {
+		return exit(c.ui, err.Error())
+	}
+	c.ui.Output(fmt.Sprintf("Restored database from the backup of version %d", version))
+	return clui.ExitCode{}
+}
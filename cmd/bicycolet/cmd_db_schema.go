@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbSchemaCmd struct {
+	baseCmd
+}
+
+// NewDBSchemaCmd creates a Command with sane defaults
+func NewDBSchemaCmd(ui clui.UI) clui.Command {
+	c := &dbSchemaCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db schema", flag.ExitOnError),
+		},
+	}
+	return c
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbSchemaCmd) Help() string {
+	return `
+Usage:
+  db schema [flags]
+Description:
+  Manage the schema of the node database.
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbSchemaCmd) Synopsis() string {
+	return "Manage the node database schema."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbSchemaCmd) Run() clui.ExitCode {
+	return clui.ExitCode{
+		ShowHelp: true,
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbSchemaDumpCmd struct {
+	baseCmd
+	check bool
+	write string
+}
+
+// NewDBSchemaDumpCmd creates a Command with sane defaults
+func NewDBSchemaDumpCmd(ui clui.UI) clui.Command {
+	c := &dbSchemaDumpCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db schema dump", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *dbSchemaDumpCmd) init() {
+	c.flagset.BoolVar(&c.check, "check", false, "fail if the fresh schema doesn't match the schema updates")
+	c.flagset.StringVar(&c.write, "write", "", "rewrite the given schema.go with the dump")
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbSchemaDumpCmd) Help() string {
+	return `
+Usage:
+  db schema dump [flags]
+Description:
+  Apply all the schema updates of the node database to
+  a scratch database and dump the resulting schema.
+
+  The dump is what the fresh schema, which is used to
+  create the node database from scratch, is expected to
+  be. The check flag verifies they match, the write flag
+  rewrites the schema.go that holds the fresh schema.
+Example:
+  bicycolet db schema dump
+  bicycolet db schema dump --check
+  bicycolet db schema dump --write=internal/db/node/schema.go
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbSchemaDumpCmd) Synopsis() string {
+	return "Dump the node database schema."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbSchemaDumpCmd) Run() clui.ExitCode {
+	fileSystem := fsys.NewVirtualFileSystem()
+
+	if c.check {
+		if err := node.CheckFreshSchema(fileSystem); err != nil {
+			return exit(c.ui, err.Error())
+		}
+		c.ui.Output("Fresh schema matches the schema updates")
+		return clui.ExitCode{}
+	}
+
+	dump, err := node.DumpSchema(fileSystem)
+	if err != nil {
+		return exit(c.ui, err.Error())
+	}
+
+	if c.write == "" {
+		c.ui.Output(dump)
+		return clui.ExitCode{}
+	}
+
+	source, err := node.SchemaDotGo(dump)
+	if err != nil {
+		return exit(c.ui, err.Error())
+	}
+	if err := ioutil.WriteFile(c.write, source, 0644); err != nil {
+		return exit(c.ui, errors.Wrap(err, "error writing schema").Error())
+	}
+	c.ui.Output("Wrote " + c.write)
+	return clui.ExitCode{}
+}
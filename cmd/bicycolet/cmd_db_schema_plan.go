@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/bicycolet/bicycolet/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbSchemaPlanCmd struct {
+	baseCmd
+	databaseFlags
+}
+
+// NewDBSchemaPlanCmd creates a Command with sane defaults
+func NewDBSchemaPlanCmd(ui clui.UI) clui.Command {
+	c := &dbSchemaPlanCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db schema plan", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *dbSchemaPlanCmd) init() {
+	c.databaseFlags.init(c.flagset)
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbSchemaPlanCmd) Help() string {
+	return `
+Usage:
+  db schema plan [flags]
+Description:
+  Show what updating the schema of the node database
+  would do, without changing it.
+
+  The pending schema updates are applied within a
+  transaction that is rolled back, listing the
+  statements each of them executes, along with the
+  extra queries of patch.global.sql and patch.local.sql
+  if any. No backup is taken.
+Example:
+  bicycolet db schema plan
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbSchemaPlanCmd) Synopsis() string {
+	return "Show the pending node database schema updates."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbSchemaPlanCmd) Run() clui.ExitCode {
+	var fileSystem fsys.FileSystem
+	{
+		config, err := fsys.Build(
+			fsys.With("local"),
+		)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fs, err := fsys.New(config)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fileSystem = fs
+	}
+
+	n := node.New(fileSystem)
+	if err := n.Open(filepath.Join(c.dataDir, "database"), c.connectionInfo); err != nil {
+		return exit(c.ui, errors.Wrap(err, "error opening database").Error())
+	}
+	defer n.Close()
+
+	n.AppVersion(version.Version)
+	plan, err := n.PlanSchema()
+	if err != nil {
+		return exit(c.ui, err.Error())
+	}
+	if len(plan.Steps) == 0 {
+		c.ui.Output(fmt.Sprintf("Database schema is up to date at version %d", plan.Current))
+		return clui.ExitCode{}
+	}
+
+	c.ui.Output(fmt.Sprintf("Database schema is at version %d", plan.Current))
+	for _, step := range plan.Steps {
+		switch {
+		case step.Path != "":
+			c.ui.Output(fmt.Sprintf("Run the queries of %s:", step.Path))
+		case step.Fresh:
+			c.ui.Output(fmt.Sprintf("Create the schema at version %d:", step.Version))
+		default:
+			c.ui.Output(fmt.Sprintf("Update to version %d:", step.Version))
+		}
+		for _, statement := range step.Statements {
+			c.ui.Output("    " + strings.Replace(statement, "\n", "\n    ", -1))
+		}
+	}
+	return clui.ExitCode{}
+}
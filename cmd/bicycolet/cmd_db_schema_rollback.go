@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"path/filepath"
+
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/bicycolet/bicycolet/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbSchemaRollbackCmd struct {
+	baseCmd
+	databaseFlags
+	to int
+}
+
+// NewDBSchemaRollbackCmd creates a Command with sane defaults
+func NewDBSchemaRollbackCmd(ui clui.UI) clui.Command {
+	c := &dbSchemaRollbackCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db schema rollback", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *dbSchemaRollbackCmd) init() {
+	c.databaseFlags.init(c.flagset)
+	c.flagset.IntVar(&c.to, "to", -1, "schema version to roll the node database back to")
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbSchemaRollbackCmd) Help() string {
+	return `
+Usage:
+  db schema rollback [flags]
+Description:
+  Roll the schema of the node database back to a
+  previous version, undoing the schema updates applied
+  after it.
+
+  This allows running the release that expects that
+  version again. It fails without changing anything if
+  any of the updates can't be undone. A backup of the
+  database is taken first, which can be brought back
+  with db restore.
+
+  The daemon must not be running while rolling back.
+Example:
+  bicycolet db schema rollback --to=1
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbSchemaRollbackCmd) Synopsis() string {
+	return "Roll back the node database schema."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbSchemaRollbackCmd) Run() clui.ExitCode {
+	if c.to < 0 {
+		return exit(c.ui, "expected a schema version to roll back to: --to=<version>")
+	}
+
+	// Changing the schema underneath a running daemon would leave it in an
+	// unknown state.
+	if conn, err := net.Dial("unix", filepath.Join(c.dataDir, "unix.socket")); err == nil {
+		conn.Close()
+		return exit(c.ui, "daemon is running, stop it before rolling back the database")
+	}
+
+	var fileSystem fsys.FileSystem
+	{
+		config, err := fsys.Build(
+			fsys.With("local"),
+		)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fs, err := fsys.New(config)
+		if err != nil {
+			return exit(c.ui, err.Error())
+		}
+		fileSystem = fs
+	}
+
+	n := node.New(fileSystem)
+	if err := n.Open(filepath.Join(c.dataDir, "database"), c.connectionInfo); err != nil {
+		return exit(c.ui, errors.Wrap(err, "error opening database").Error())
+	}
+	defer n.Close()
+
+	n.AppVersion(version.Version)
+	current, err := n.RollbackSchema(c.to)
+	if err != nil {
+		return exit(c.ui, err.Error())
+	}
+	if current == c.to {
+		c.ui.Output(fmt.Sprintf("Database schema is already at version %d", c.to))
+		return clui.ExitCode{}
+	}
+	c.ui.Output(fmt.Sprintf("Rolled back database schema from version %d to %d", current, c.to))
+	return clui.ExitCode{}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/bicycolet/bicycolet/internal/db"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/spoke-d/clui"
+	"github.com/spoke-d/clui/flagset"
+)
+
+type dbStatementsCmd struct {
+	baseCmd
+	check bool
+}
+
+// NewDBStatementsCmd creates a Command with sane defaults
+func NewDBStatementsCmd(ui clui.UI) clui.Command {
+	c := &dbStatementsCmd{
+		baseCmd: baseCmd{
+			ui:      ui,
+			flagset: flagset.NewFlagSet("db statements", flag.ExitOnError),
+		},
+	}
+	c.init()
+	return c
+}
+
+func (c *dbStatementsCmd) init() {
+	c.flagset.BoolVar(&c.check, "check", false, "fail if any statement doesn't match the current schema")
+}
+
+// Help should return a long-form help text that includes the command-line
+// usage. A brief few sentences explaining the function of the command, and
+// the complete list of flags the command accepts.
+func (c *dbStatementsCmd) Help() string {
+	return `
+Usage:
+  db statements [flags]
+Description:
+  List the statements that are prepared against the node
+  database when it's opened, along with their names.
+
+  The check flag prepares all of them against a scratch
+  database with the current schema, reporting the ones
+  that don't match it.
+Example:
+  bicycolet db statements
+  bicycolet db statements --check
+`
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+// This should be short (50 characters of less ideally).
+func (c *dbStatementsCmd) Synopsis() string {
+	return "List the prepared database statements."
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+//
+// There are a handful of special exit codes that can return documented
+// behavioral changes.
+func (c *dbStatementsCmd) Run() clui.ExitCode {
+	if c.check {
+		if err := db.CheckStmts(fsys.NewVirtualFileSystem()); err != nil {
+			return exit(c.ui, err.Error())
+		}
+		c.ui.Output("All statements match the current schema")
+		return clui.ExitCode{}
+	}
+
+	for _, stmt := range db.Stmts() {
+		c.ui.Output(fmt.Sprintf("%s: %s", stmt.Name, stmt.SQL))
+	}
+	return clui.ExitCode{}
+}
@@ -15,7 +15,7 @@ import (
 
 type versionCmd struct {
 	baseCmd
-	address       string
+	clientFlags
 	clientVersion string
 }
 
@@ -34,7 +34,7 @@ func NewVersionCmd(ui clui.UI, clientVersion string) clui.Command {
 
 func (c *versionCmd) init() {
 	c.baseCmd.init()
-	c.flagset.StringVar(&c.address, "address", "127.0.0.1:8080", "address of the api server")
+	c.clientFlags.init(c.flagset)
 }
 
 // Help should return a long-form help text that includes the command-line
@@ -51,6 +51,8 @@ Description:
 Example:
   bicycolet version
   bicycolet version --format=json
+  bicycolet version --tls-server-fingerprint=<fingerprint>
+  bicycolet version --address=unix:///var/lib/bicycolet/unix.socket
 `
 }
 
@@ -82,7 +84,7 @@ func (c *versionCmd) Run() clui.ExitCode {
 		logger = level.NewFilter(logger, logLevel)
 	}
 
-	client, err := getClient(c.address, logger)
+	client, err := getClient(c.clientFlags, logger)
 	if err != nil {
 		return exit(c.ui, errors.WithStack(err).Error())
 	}
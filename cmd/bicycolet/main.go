@@ -31,6 +31,21 @@ func main() {
 		UI: ui,
 	})
 
+	cli.AddCommand("config", NewConfigCmd(ui))
+	cli.AddCommand("config get", NewConfigGetCmd(ui))
+	cli.AddCommand("config set", NewConfigSetCmd(ui))
+	cli.AddCommand("config show", NewConfigShowCmd(ui))
+	cli.AddCommand("config unset", NewConfigUnsetCmd(ui))
+	cli.AddCommand("daemon", NewDaemonCmd(ui, version.Version))
+	cli.AddCommand("db", NewDBCmd(ui))
+	cli.AddCommand("db check", NewDBCheckCmd(ui))
+	cli.AddCommand("db patches", NewDBPatchesCmd(ui))
+	cli.AddCommand("db restore", NewDBRestoreCmd(ui))
+	cli.AddCommand("db schema", NewDBSchemaCmd(ui))
+	cli.AddCommand("db schema dump", NewDBSchemaDumpCmd(ui))
+	cli.AddCommand("db schema plan", NewDBSchemaPlanCmd(ui))
+	cli.AddCommand("db schema rollback", NewDBSchemaRollbackCmd(ui))
+	cli.AddCommand("db statements", NewDBStatementsCmd(ui))
 	cli.AddCommand("version", NewVersionCmd(ui, version.Version))
 
 	exitCode, err := cli.Run(os.Args[1:])
@@ -3,6 +3,7 @@ module github.com/bicycolet/bicycolet
 go 1.12
 
 require (
+	github.com/VividCortex/gohistogram v1.0.0 // indirect
 	github.com/flosch/pongo2 v0.0.0-20190707114632-bbf5a6c351f4 // indirect
 	github.com/go-kit/kit v0.9.0
 	github.com/go-logfmt/logfmt v0.4.0
@@ -10,6 +11,7 @@ require (
 	github.com/golang/mock v1.3.1
 	github.com/google/go-cmp v0.3.0 // indirect
 	github.com/google/uuid v1.1.1 // indirect
+	github.com/gorilla/mux v1.7.3
 	github.com/gorilla/websocket v1.4.0
 	github.com/kr/pty v1.1.8 // indirect
 	github.com/lib/pq v1.2.0
@@ -0,0 +1,117 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kind defines the kind of certificate to generate from scratch in
+// KeyPairAndCA when it's not there.
+//
+// The two possible kinds are client and server, and they differ in the
+// ext-key-usage bitmaps. See GenerateMemCert for more details.
+type Kind int
+
+// Possible kinds of certificates.
+const (
+	Client Kind = iota
+	Server
+)
+
+// Info captures TLS certificate information about a certain public/private
+// keypair and an optional CA certificate.
+type Info struct {
+	keypair tls.Certificate
+	ca      *x509.Certificate
+}
+
+// NewInfo creates a new cert.Info with sane defaults.
+func NewInfo(keypair tls.Certificate, ca *x509.Certificate) *Info {
+	return &Info{
+		keypair: keypair,
+		ca:      ca,
+	}
+}
+
+// KeyPair returns the public/private key pair.
+func (c *Info) KeyPair() tls.Certificate {
+	return c.keypair
+}
+
+// CA returns the CA certificate.
+func (c *Info) CA() *x509.Certificate {
+	return c.ca
+}
+
+// PublicKey is a convenience to encode the underlying public key to ASCII.
+func (c *Info) PublicKey() []byte {
+	data := c.keypair.Certificate[0]
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: data})
+}
+
+// PrivateKey is a convenience to encode the underlying private key.
+func (c *Info) PrivateKey() []byte {
+	switch key := c.keypair.PrivateKey.(type) {
+	case *ecdsa.PrivateKey:
+		data, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: data})
+	case *rsa.PrivateKey:
+		data := x509.MarshalPKCS1PrivateKey(key)
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: data})
+	default:
+		return nil
+	}
+}
+
+// Fingerprint returns the fingerprint of the public key.
+func (c *Info) Fingerprint() string {
+	return fmt.Sprintf("%x", sha256.Sum256(c.keypair.Certificate[0]))
+}
+
+// Fingerprint returns the fingerprint of the certificate
+func Fingerprint(cert *x509.Certificate) string {
+	return fmt.Sprintf("%x", sha256.Sum256(cert.Raw))
+}
+
+// FingerprintStr returns the fingerprint of the PEM encoded certificate
+func FingerprintStr(c string) (string, error) {
+	cert, err := ParseCert([]byte(c))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return Fingerprint(cert), nil
+}
+
+// NormalizeFingerprint returns the fingerprint in the form returned by
+// Fingerprint, allowing fingerprints to be written in upper case and with
+// colon separators.
+func NormalizeFingerprint(fingerprint string) (string, error) {
+	normalized := strings.ToLower(strings.Replace(fingerprint, ":", "", -1))
+	if b, err := hex.DecodeString(normalized); err != nil || len(b) != sha256.Size {
+		return "", errors.Errorf("invalid fingerprint %q", fingerprint)
+	}
+	return normalized, nil
+}
+
+// ParseCert parses the first PEM encoded certificate.
+func ParseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("invalid certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	return cert, errors.WithStack(err)
+}
@@ -0,0 +1,61 @@
+package cert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/cert"
+)
+
+func TestNormalizeFingerprint(t *testing.T) {
+	fingerprint := strings.Repeat("ab", 32)
+
+	for input, expected := range map[string]string{
+		fingerprint:                      fingerprint,
+		strings.ToUpper(fingerprint):     fingerprint,
+		strings.Repeat("AB:", 31) + "AB": fingerprint,
+	} {
+		t.Run(input, func(t *testing.T) {
+			actual, err := cert.NormalizeFingerprint(input)
+			if err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+			if expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestNormalizeFingerprintWithInvalidFingerprint(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"abc",
+		strings.Repeat("zz", 32),
+	} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := cert.NormalizeFingerprint(input); err == nil {
+				t.Errorf("expected err not to be nil")
+			}
+		})
+	}
+}
+
+func TestFingerprintStr(t *testing.T) {
+	certKey, err := cert.GenerateMemCert(cert.Client)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	c, err := cert.ParseCert(certKey.Cert)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	fingerprint, err := cert.FingerprintStr(string(certKey.Cert))
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := cert.Fingerprint(c), fingerprint; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
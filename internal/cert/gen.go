@@ -0,0 +1,106 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultCertValidPeriod = 10 * 365 * 24 * time.Hour
+
+// CertKey represents a tuple of Certificates and Keys as a pair.
+type CertKey struct {
+	Cert, Key []byte
+}
+
+// GenerateMemCert creates client or server certificate and key pair,
+// returning them as byte arrays in memory.
+func GenerateMemCert(kind Kind) (CertKey, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		return CertKey{}, errors.Wrap(err, "failed to generate key")
+	}
+
+	validFrom := time.Now()
+	validTo := validFrom.Add(defaultCertValidPeriod)
+
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return CertKey{}, errors.Wrap(err, "failed to generate serial number")
+	}
+
+	username := "UNKNOWN"
+	if userEntry, err := user.Current(); err == nil && userEntry.Username != "" {
+		username = userEntry.Username
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "UNKNOWN"
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"bicycolet"},
+			CommonName:   fmt.Sprintf("%s@%s", username, hostname),
+		},
+		NotBefore: validFrom,
+		NotAfter:  validTo,
+
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	switch kind {
+	case Client:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{
+			x509.ExtKeyUsageClientAuth,
+		}
+	case Server:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth,
+		}
+		template.DNSNames = []string{hostname, "localhost"}
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	}
+
+	derBytes, err := x509.CreateCertificate(
+		rand.Reader,
+		&template,
+		&template,
+		&privateKey.PublicKey,
+		privateKey,
+	)
+	if err != nil {
+		return CertKey{}, errors.Wrap(err, "failed to create certificate")
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return CertKey{}, errors.Wrap(err, "failed to encode key")
+	}
+
+	return CertKey{
+		Cert: pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: derBytes,
+		}),
+		Key: pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: keyBytes,
+		}),
+	}, nil
+}
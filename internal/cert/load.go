@@ -0,0 +1,181 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+// KeyPairAndCA returns a CertInfo object with a reference to the key pair and
+// (optionally) CA certificate located in the given directory and having the
+// given name prefix
+//
+// The naming conversion for the various files is:
+//
+// <prefix>.crt -> public key
+// <prefix>.key -> private key
+// <prefix>.ca -> CA certificate
+//
+// If no public/private key files are found, a new key pair will be generated
+// and saved on disk.
+//
+// If a CA certificate is found, it will be returned as well as second return
+// value (otherwise it will be nil).
+func KeyPairAndCA(dir, prefix string, kind Kind, options ...Option) (*Info, error) {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	certFilename := filepath.Join(dir, prefix+".crt")
+	keyFilename := filepath.Join(dir, prefix+".key")
+
+	// Ensure that the certificate exists, or create a new one if it does
+	// not.
+	if err := findOrGenCert(opts.fileSystem, certFilename, keyFilename, kind); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	certPEM, err := readFile(opts.fileSystem, certFilename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	keyPEM, err := readFile(opts.fileSystem, keyFilename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	keypair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key pair")
+	}
+
+	// If available, load the CA data as well.
+	caFilename := filepath.Join(dir, prefix+".ca")
+
+	var ca *x509.Certificate
+	if opts.fileSystem.Exists(caFilename) {
+		if ca, err = ReadCert(caFilename, options...); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return NewInfo(keypair, ca), nil
+}
+
+// ReadCert will read a certificate file and correctly parse it
+func ReadCert(path string, options ...Option) (*x509.Certificate, error) {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	data, err := readFile(opts.fileSystem, path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cert, err := ParseCert(data)
+	return cert, errors.Wrapf(err, "invalid certificate file %q", path)
+}
+
+// ReadCerts reads all the certificate files (*.crt) within a directory. A
+// missing directory is the same as an empty one.
+func ReadCerts(dir string, options ...Option) ([]*x509.Certificate, error) {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	var paths []string
+	if err := opts.fileSystem.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == dir && os.IsNotExist(errors.Cause(err)) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".crt" {
+			paths = append(paths, path)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	certs := make([]*x509.Certificate, len(paths))
+	for k, path := range paths {
+		cert, err := ReadCert(path, options...)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		certs[k] = cert
+	}
+	return certs, nil
+}
+
+// FindOrGenCert will generate a certificate and key if either of them are
+// missing.
+func FindOrGenCert(cert, key string, kind Kind, options ...Option) error {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+	return findOrGenCert(opts.fileSystem, cert, key, kind)
+}
+
+func findOrGenCert(fileSystem fsys.FileSystem, cert, key string, kind Kind) error {
+	if fileSystem.Exists(cert) && fileSystem.Exists(key) {
+		return nil
+	}
+
+	certKey, err := GenerateMemCert(kind)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := fileSystem.MkdirAll(filepath.Dir(cert), 0750); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := fileSystem.MkdirAll(filepath.Dir(key), 0750); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := writeFile(fileSystem, cert, certKey.Cert, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := writeFile(fileSystem, key, certKey.Key, 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func readFile(fileSystem fsys.FileSystem, path string) ([]byte, error) {
+	file, err := fileSystem.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %q", path)
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	return data, errors.Wrapf(err, "failed to read %q", path)
+}
+
+func writeFile(fileSystem fsys.FileSystem, path string, data []byte, perm os.FileMode) error {
+	file, err := fileSystem.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %q for writing", path)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write to %q", path)
+	}
+	if err := file.Sync(); err != nil {
+		return errors.Wrapf(err, "failed to flush write to %q", path)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cert_test
+
+import (
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/cert"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+)
+
+func TestKeyPairAndCA(t *testing.T) {
+	fileSystem := fsys.NewVirtualFileSystem()
+
+	info, err := cert.KeyPairAndCA("/var/lib/bicycolet", "server", cert.Server, cert.WithFileSystem(fileSystem))
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if !fileSystem.Exists("/var/lib/bicycolet/server.crt") {
+		t.Errorf("expected certificate to be written")
+	}
+	if !fileSystem.Exists("/var/lib/bicycolet/server.key") {
+		t.Errorf("expected key to be written")
+	}
+	if info.CA() != nil {
+		t.Errorf("expected no CA")
+	}
+
+	// Loading the key pair again, should return the same key pair.
+	other, err := cert.KeyPairAndCA("/var/lib/bicycolet", "server", cert.Server, cert.WithFileSystem(fileSystem))
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := info.Fingerprint(), other.Fingerprint(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+	if expected, actual := string(info.PrivateKey()), string(other.PrivateKey()); expected != actual {
+		t.Errorf("expected private keys to match")
+	}
+}
+
+func TestReadCerts(t *testing.T) {
+	fileSystem := fsys.NewVirtualFileSystem()
+
+	if err := cert.FindOrGenCert("/trusted/a.crt", "/keys/a.key", cert.Client, cert.WithFileSystem(fileSystem)); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if err := cert.FindOrGenCert("/trusted/b.crt", "/keys/b.key", cert.Client, cert.WithFileSystem(fileSystem)); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	certs, err := cert.ReadCerts("/trusted", cert.WithFileSystem(fileSystem))
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 2, len(certs); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestReadCertsWithMissingDir(t *testing.T) {
+	certs, err := cert.ReadCerts("/missing", cert.WithFileSystem(fsys.NewLocalFileSystem(false)))
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 0, len(certs); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
@@ -0,0 +1,27 @@
+package cert
+
+import (
+	"github.com/bicycolet/bicycolet/internal/fsys"
+)
+
+// Option to be passed to the cert functions to customize the resulting
+// instance.
+type Option func(*options)
+
+type options struct {
+	fileSystem fsys.FileSystem
+}
+
+// WithFileSystem sets the fileSystem on the option
+func WithFileSystem(fileSystem fsys.FileSystem) Option {
+	return func(options *options) {
+		options.fileSystem = fileSystem
+	}
+}
+
+// Create a options instance with default values.
+func newOptions() *options {
+	return &options{
+		fileSystem: fsys.NewLocalFileSystem(false),
+	}
+}
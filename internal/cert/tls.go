@@ -0,0 +1,157 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// InitTLSConfig returns a tls.Config populated with default encryption
+// parameters. This is used as baseline config for both client and server
+// certificates used by bicycolet.
+func InitTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+		PreferServerCipherSuites: true,
+	}
+}
+
+// ServerTLSConfig returns a new server-side tls.Config generated from the
+// given certificate info. Client certificates are requested, but it's up to
+// the caller to check that they're trusted, unless a CA is present, in which
+// case any given client certificate must be signed by the CA.
+func ServerTLSConfig(info *Info) *tls.Config {
+	config := InitTLSConfig()
+	config.ClientAuth = tls.RequestClientCert
+	config.Certificates = []tls.Certificate{info.KeyPair()}
+
+	if ca := info.CA(); ca != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(ca)
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config
+}
+
+// ClientTLSConfig returns a client-side tls.Config from PEM encoded
+// certificates and keys held in memory.
+//
+// The server is verified against the CA bundle, or the system CAs if the
+// bundle is empty. If the server certificate is given, it's also trusted as
+// a CA, which allows pinning of self-signed certificates. If the server
+// fingerprint is given, the server certificate must match the fingerprint
+// and when it's the only means of verification, it's used instead of the
+// normal certificate chain verification.
+func ClientTLSConfig(
+	clientCert, clientKey, ca, serverCert, serverFingerprint string,
+	insecureSkipVerify bool,
+) (*tls.Config, error) {
+	config := InitTLSConfig()
+	config.InsecureSkipVerify = insecureSkipVerify
+
+	// Client authentication
+	if clientCert != "" || clientKey != "" {
+		keypair, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid client certificate")
+		}
+		config.Certificates = []tls.Certificate{keypair}
+	}
+
+	if ca != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(ca)) {
+			return nil, errors.New("invalid CA bundle")
+		}
+		config.RootCAs = pool
+	}
+
+	if serverCert != "" {
+		cert, err := ParseCert([]byte(serverCert))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid server certificate")
+		}
+
+		pool := config.RootCAs
+		if pool == nil {
+			if pool, err = x509.SystemCertPool(); err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+		}
+
+		// Make it a valid RootCA
+		cert.IsCA = true
+		cert.KeyUsage = x509.KeyUsageCertSign
+		pool.AddCert(cert)
+		config.RootCAs = pool
+	}
+
+	if serverFingerprint != "" {
+		fingerprint, err := NormalizeFingerprint(serverFingerprint)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if ca == "" && serverCert == "" {
+			config.InsecureSkipVerify = true
+		}
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("no server certificate")
+			}
+			if actual := fmt.Sprintf("%x", sha256.Sum256(rawCerts[0])); actual != fingerprint {
+				return errors.Errorf("server certificate fingerprint %q does not match %q", actual, fingerprint)
+			}
+			return nil
+		}
+	}
+
+	return config, nil
+}
+
+// CheckTrustState checks whether the given client certificate is trusted
+// (i.e. it has a valid time span and it belongs to the given list of trusted
+// certificates).
+func CheckTrustState(cert *x509.Certificate, trustedCerts []*x509.Certificate) bool {
+	// Extra validity check (should have been caught by TLS stack)
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return false
+	}
+
+	for _, v := range trustedCerts {
+		if bytes.Equal(cert.Raw, v.Raw) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTrustedRequest checks whether the request was made with a trusted
+// client certificate. Certificates that were verified against the CA by the
+// TLS stack are trusted, otherwise the certificate has to be one of the
+// trusted certificates.
+func CheckTrustedRequest(r *http.Request, trustedCerts []*x509.Certificate) bool {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return false
+	}
+	if len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+	return CheckTrustState(r.TLS.PeerCertificates[0], trustedCerts)
+}
@@ -0,0 +1,125 @@
+package cert_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/cert"
+)
+
+func TestClientTLSConfigWithFingerprint(t *testing.T) {
+	server, info := newTLSServer(t, nil)
+	defer server.Close()
+
+	clientCert := generateCert(t, cert.Client)
+
+	config, err := cert.ClientTLSConfig(
+		string(clientCert.PublicKey()),
+		string(clientCert.PrivateKey()),
+		"", "",
+		info.Fingerprint(),
+		false,
+	)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	resp, err := get(server.URL, config)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := http.StatusForbidden, resp.StatusCode; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestClientTLSConfigWithFingerprintMismatch(t *testing.T) {
+	server, _ := newTLSServer(t, nil)
+	defer server.Close()
+
+	other := generateCert(t, cert.Server)
+
+	config, err := cert.ClientTLSConfig("", "", "", "", other.Fingerprint(), false)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	if _, err := get(server.URL, config); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestClientTLSConfigWithServerCert(t *testing.T) {
+	clientCert := generateCert(t, cert.Client)
+	trusted, err := x509.ParseCertificate(clientCert.KeyPair().Certificate[0])
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	server, info := newTLSServer(t, []*x509.Certificate{trusted})
+	defer server.Close()
+
+	config, err := cert.ClientTLSConfig(
+		string(clientCert.PublicKey()),
+		string(clientCert.PrivateKey()),
+		"",
+		string(info.PublicKey()),
+		"",
+		false,
+	)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	resp, err := get(server.URL, config)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := http.StatusOK, resp.StatusCode; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func newTLSServer(t *testing.T, trusted []*x509.Certificate) (*httptest.Server, *cert.Info) {
+	info := generateCert(t, cert.Server)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cert.CheckTrustedRequest(r, trusted) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = cert.ServerTLSConfig(info)
+	server.StartTLS()
+	return server, info
+}
+
+func generateCert(t *testing.T, kind cert.Kind) *cert.Info {
+	certKey, err := cert.GenerateMemCert(kind)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	keypair, err := tls.X509KeyPair(certKey.Cert, certKey.Key)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	return cert.NewInfo(keypair, nil)
+}
+
+func get(url string, config *tls.Config) (*http.Response, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: config,
+		},
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
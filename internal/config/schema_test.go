@@ -0,0 +1,154 @@
+package config_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/config"
+	"github.com/pkg/errors"
+)
+
+func TestSchemaWithDefaults(t *testing.T) {
+	schema := config.Schema{
+		"foo": {},
+		"bar": {Default: "x"},
+	}
+	values := map[string]interface{}{"foo": "", "bar": "x"}
+	if expected, actual := values, schema.Defaults(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSchemaWithKeys(t *testing.T) {
+	schema := config.Schema{
+		"foo": {},
+		"bar": {Default: "x"},
+	}
+	keys := []string{"bar", "foo"}
+	if expected, actual := keys, schema.Keys(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSchemaWithWatch(t *testing.T) {
+	schema := config.Schema{
+		"foo": {},
+	}
+	if err := schema.Watch("bar", func(string) error { return nil }); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+
+	var called string
+	if err := schema.Watch("foo", func(value string) error {
+		called = value
+		return nil
+	}); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if err := schema.Trigger(map[string]string{"foo": "bar"}); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := "bar", called; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestSchemaWithTrigger(t *testing.T) {
+	var called []string
+	record := func(name string) func(string) error {
+		return func(value string) error {
+			called = append(called, fmt.Sprintf("%s=%s", name, value))
+			return nil
+		}
+	}
+
+	schema := config.Schema{
+		"foo": {OnChange: record("foo")},
+		"bar": {Default: "x", OnChange: record("bar")},
+		"egg": {},
+	}
+	if err := schema.Trigger(map[string]string{
+		"foo": "hello",
+		"bar": "",
+		"egg": "ignored",
+	}); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	// Callbacks are called in key name order, with unset values reverting to
+	// their defaults.
+	want := []string{"bar=x", "foo=hello"}
+	if expected, actual := want, called; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSchemaWithTriggerError(t *testing.T) {
+	schema := config.Schema{
+		"foo": {OnChange: func(string) error { return errors.Errorf("boom") }},
+	}
+	if err := schema.Trigger(map[string]string{"foo": "bar"}); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+	if err := schema.Trigger(map[string]string{"unknown": "bar"}); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+// Exercise valid values.
+func TestKeyWithValidate(t *testing.T) {
+	for k, c := range []struct {
+		node  config.Key
+		value string
+	}{
+		{node: config.Key{}, value: "hello"},
+		{node: config.Key{Type: config.Bool}, value: "yes"},
+		{node: config.Key{Type: config.Bool}, value: "0"},
+		{node: config.Key{Type: config.Int64}, value: "666"},
+		{node: config.Key{Type: config.Bool}, value: ""},
+		{node: config.Key{Type: config.Duration}, value: "5m"},
+		{node: config.Key{Type: config.Address}, value: "127.0.0.1"},
+		{node: config.Key{Type: config.Address}, value: "[::1]:9000"},
+		{node: config.Key{Validator: isNotEmptyString, Default: "foo"}, value: ""},
+	} {
+		t.Run(fmt.Sprintf("validate %d", k), func(t *testing.T) {
+			if err := config.Validate(c.node, c.value); err != nil {
+				t.Errorf("expected err to be nil: got %v", err)
+			}
+		})
+	}
+}
+
+// Validator that returns an error if the value is not the empty string.
+func isNotEmptyString(value string) error {
+	if value == "" {
+		return errors.Errorf("empty value not valid")
+	}
+	return nil
+}
+
+// Exercise all possible validation errors.
+func TestKeyWithValidateError(t *testing.T) {
+	for _, c := range []struct {
+		node    config.Key
+		value   string
+		message string
+	}{
+		{node: config.Key{Type: config.Int64}, value: "1.2", message: "invalid integer"},
+		{node: config.Key{Type: config.Bool}, value: "yyy", message: "invalid boolean"},
+		{node: config.Key{Type: config.Duration}, value: "5", message: "invalid duration"},
+		{node: config.Key{Type: config.Address}, value: "[::1", message: "invalid address"},
+		{node: config.Key{Validator: func(string) error { return errors.Errorf("ugh") }}, value: "", message: "ugh"},
+	} {
+		t.Run(c.message, func(t *testing.T) {
+			err := config.Validate(c.node, c.value)
+			if err == nil {
+				t.Fatalf("expected err to not be nil")
+			}
+			if expected, actual := c.message, err.Error(); expected != actual {
+				t.Errorf("expected: %q, actual: %q", expected, actual)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package database
 
 import "fmt"
 
+// ConnectionInfo describes how to connect to a database.
 type ConnectionInfo struct {
+	Driver   string
 	Host     string
 	Port     int
 	User     string
@@ -11,10 +13,29 @@ type ConnectionInfo struct {
 	Memory   bool
 }
 
+// DriverName returns the name of the database/sql driver to connect with,
+// which defaults to postgres.
+func (c ConnectionInfo) DriverName() string {
+	if c.Driver == "" {
+		return Postgres
+	}
+	return c.Driver
+}
+
+// Dialect returns the dialect of SQL spoken by the database.
+func (c ConnectionInfo) Dialect() (Dialect, error) {
+	return NewDialect(c.DriverName())
+}
+
+// String returns the data source name of the database, in the format
+// expected by the driver.
 func (c ConnectionInfo) String() string {
 	if c.Memory {
 		return ":memory:"
 	}
+	if c.DriverName() == SQLite {
+		return c.DBName + "?" + sqliteParams
+	}
 	return fmt.Sprintf("host=%s port=%d user=%s "+
 		"password=%s dbname=%s sslmode=disable",
 		c.Host, c.Port, c.User, c.Password, c.DBName)
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 )
 
@@ -10,8 +11,33 @@ type DB interface {
 	// the driver.
 	Begin() (Tx, error)
 
+	// BeginTx starts a transaction.
+	//
+	// The provided context is used until the transaction is committed or
+	// rolled back. If the context is canceled, the transaction will be rolled
+	// back and Commit will return an error.
+	//
+	// The provided TxOptions is optional and may be nil if defaults should be
+	// used.
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+
+	// Ping verifies a connection to the database is still alive,
+	// establishing a connection if necessary.
 	Ping() error
 
+	// PingContext verifies a connection to the database is still alive,
+	// establishing a connection if necessary, aborting once the context is
+	// done.
+	PingContext(ctx context.Context) error
+
+	// PrepareContext creates a prepared statement for later queries or
+	// executions. The statement is prepared again on any other connection it
+	// ends up being executed on.
+	PrepareContext(ctx context.Context, query string) (Stmt, error)
+
+	// Dialect returns the dialect of SQL spoken by the database.
+	Dialect() Dialect
+
 	// Close closes the database, releasing any open resources.
 	//
 	// It is rare to Close a DB, as the DB handle is meant to be
@@ -25,10 +51,26 @@ type Tx interface {
 	// Query executes a query that returns rows, typically a SELECT.
 	Query(query string, args ...interface{}) (Rows, error)
 
+	// QueryContext executes a query that returns rows, typically a SELECT,
+	// aborting once the context is done.
+	QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error)
+
 	// Exec executes a query that doesn't return rows.
 	// For example: an INSERT and UPDATE.
 	Exec(query string, args ...interface{}) (sql.Result, error)
 
+	// ExecContext executes a query that doesn't return rows, aborting once
+	// the context is done.
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+
+	// StmtContext returns a transaction-specific prepared statement from an
+	// existing statement, prepared against the database the transaction
+	// belongs to.
+	StmtContext(ctx context.Context, stmt Stmt) Stmt
+
+	// Dialect returns the dialect of SQL spoken by the database.
+	Dialect() Dialect
+
 	// Commit commits the transaction.
 	Commit() error
 
@@ -36,6 +78,21 @@ type Tx interface {
 	Rollback() error
 }
 
+// Stmt is a prepared statement, which is safe for concurrent use by multiple
+// goroutines.
+type Stmt interface {
+	// QueryContext executes the prepared statement with the given arguments,
+	// returning the rows it yields.
+	QueryContext(ctx context.Context, args ...interface{}) (Rows, error)
+
+	// ExecContext executes the prepared statement with the given arguments,
+	// returning a summary of its effects.
+	ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error)
+
+	// Close closes the statement.
+	Close() error
+}
+
 // Rows is the result of a query. Its cursor starts before the first row
 // of the result set. Use Next to advance through the rows:
 type Rows interface {
@@ -124,6 +181,13 @@ type Rows interface {
 // ColumnType contains the name and type of a column.
 type ColumnType interface {
 
+	// Name returns the name or alias of the column.
+	Name() string
+
+	// Nullable reports whether the column may be null.
+	// If a driver does not support this property ok will be false.
+	Nullable() (nullable, ok bool)
+
 	// DatabaseTypeName returns the database system name of the column type. If an empty
 	// string is returned the driver type name is not supported.
 	// Consult your driver documentation for a list of driver data types. Length specifiers
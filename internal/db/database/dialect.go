@@ -0,0 +1,147 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The names of the supported database drivers, each of which speaks its own
+// dialect of SQL.
+const (
+	SQLite   = "sqlite3"
+	Postgres = "postgres"
+)
+
+// Dialect captures the differences between the SQL understood by the
+// supported databases, so that the same queries can be run against any of
+// them.
+type Dialect interface {
+	// DriverName returns the name of the database/sql driver that speaks the
+	// dialect.
+	DriverName() string
+
+	// Rebind rewrites the "?" placeholders of the given query into the ones
+	// understood by the dialect.
+	Rebind(query string) string
+
+	// Quote returns the given identifier quoted, so that it's never mistaken
+	// for a keyword.
+	Quote(identifier string) string
+
+	// Upsert returns a statement that inserts the given values into the
+	// columns of the table, replacing any existing row that conflicts on the
+	// key columns. The values are the parenthesized placeholders of each row.
+	// The table and columns are quoted by the dialect.
+	Upsert(table string, columns, keys []string, values string) string
+
+	// Returning returns the clause to append to an insert statement, for it
+	// to yield the given column of the inserted row, which is quoted by the
+	// dialect. An empty string is returned if the dialect reports it as the
+	// last insert id instead.
+	Returning(column string) string
+
+	// StmtTableNames returns a query yielding the names of all the tables in
+	// the database, in the order they were created.
+	StmtTableNames() string
+
+	// StmtTableExists returns a query yielding the number of tables named
+	// after its only argument.
+	StmtTableExists() string
+
+	// StmtTables returns a query yielding the name and the statement that
+	// creates each table in the database, in the order they were created.
+	StmtTables() string
+
+	// StmtIndexes returns a query yielding the name and the statement that
+	// creates each index in the database, sorted by name. Indexes backing a
+	// constraint are part of the statement creating their table instead.
+	StmtIndexes() string
+
+	// StmtDeferForeignKeys returns a statement deferring the foreign key
+	// checks until the transaction is committed.
+	StmtDeferForeignKeys() string
+
+	// StmtResetSequence returns a statement that moves the sequence backing
+	// the auto-incrementing column of the table past its values, or an empty
+	// string if the dialect keeps track of that itself. The table and column
+	// are quoted by the dialect.
+	StmtResetSequence(table, column string) string
+
+	// Now returns an expression that evaluates to the current time, for
+	// storing in a DateTime column.
+	Now() string
+
+	// DateTime returns the type of a column storing a point in time.
+	DateTime() string
+
+	// Blob returns the type of a column storing binary data.
+	Blob() string
+
+	// BlobLiteral returns the literal of the given binary data, for storing
+	// in a Blob column.
+	BlobLiteral(data []byte) string
+
+	// AutoIncrement returns the definition of an auto-incrementing integer
+	// primary key column.
+	AutoIncrement() string
+}
+
+// NewDialect returns the dialect spoken by the given driver.
+func NewDialect(driverName string) (Dialect, error) {
+	switch driverName {
+	case SQLite:
+		return sqliteDialect{}, nil
+	case Postgres:
+		return postgresDialect{}, nil
+	default:
+		return nil, errors.Errorf("unsupported database driver %q", driverName)
+	}
+}
+
+// Return a statement inserting the given values into the columns of the
+// table, which updates the row conflicting on the key columns instead, the
+// way both dialects understand.
+func upsertOnConflict(table string, columns, keys []string, values string) string {
+	var updates []string
+	for _, column := range columns {
+		if !contains(keys, column) {
+			quoted := quoteIdentifier(column)
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", quoted, quoted))
+		}
+	}
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT (%s) %s",
+		quoteIdentifier(table), strings.Join(quoteIdentifiers(columns), ", "), values,
+		strings.Join(quoteIdentifiers(keys), ", "), action)
+}
+
+// QuoteIdentifier quotes the given identifier the same way the Quote method
+// of every dialect does, for statements written before knowing the dialect
+// they're run against.
+func QuoteIdentifier(identifier string) string {
+	return quoteIdentifier(identifier)
+}
+
+// Quote the given identifier the standard SQL way, which both dialects
+// understand, doubling any embedded quote.
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
+
+func quoteIdentifiers(identifiers []string) []string {
+	quoted := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		quoted[i] = quoteIdentifier(identifier)
+	}
+	return quoted
+}
+
+// Quote the given string literal, doubling any embedded quote.
+func quoteString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
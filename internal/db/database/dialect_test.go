@@ -0,0 +1,219 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+)
+
+func TestNewDialect(t *testing.T) {
+	for _, driverName := range []string{database.SQLite, database.Postgres} {
+		t.Run(driverName, func(t *testing.T) {
+			dialect, err := database.NewDialect(driverName)
+			if err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+			if expected, actual := driverName, dialect.DriverName(); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestNewDialectWithUnknownDriver(t *testing.T) {
+	_, err := database.NewDialect("mysql")
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		driver string
+		query  string
+		result string
+	}{
+		{
+			name:   "sqlite",
+			driver: database.SQLite,
+			query:  "SELECT * FROM test WHERE id = ? AND name = ?",
+			result: "SELECT * FROM test WHERE id = ? AND name = ?",
+		},
+		{
+			name:   "postgres",
+			driver: database.Postgres,
+			query:  "SELECT * FROM test WHERE id = ? AND name = ?",
+			result: "SELECT * FROM test WHERE id = $1 AND name = $2",
+		},
+		{
+			name:   "postgres with quotes",
+			driver: database.Postgres,
+			query:  `SELECT "a?" FROM test WHERE id = ? AND name = 'what?'`,
+			result: `SELECT "a?" FROM test WHERE id = $1 AND name = 'what?'`,
+		},
+		{
+			name:   "postgres with comments",
+			driver: database.Postgres,
+			query:  "SELECT 1 -- why?\nFROM test /* where? */ WHERE id = ?",
+			result: "SELECT 1 -- why?\nFROM test /* where? */ WHERE id = $1",
+		},
+		{
+			name:   "postgres with dollar-quoted strings",
+			driver: database.Postgres,
+			query:  "SELECT $$what?$$, $tag$who?$tag$ FROM test WHERE id = ?",
+			result: "SELECT $$what?$$, $tag$who?$tag$ FROM test WHERE id = $1",
+		},
+		{
+			name:   "postgres with escape strings",
+			driver: database.Postgres,
+			query:  `SELECT E'it\'s?', type FROM test WHERE name = ?`,
+			result: `SELECT E'it\'s?', type FROM test WHERE name = $1`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dialect, err := database.NewDialect(test.driver)
+			if err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+			if expected, actual := test.result, dialect.Rebind(test.query); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		driver  string
+		columns []string
+		result  string
+	}{
+		{
+			name:    "sqlite",
+			driver:  database.SQLite,
+			columns: []string{"id", "name"},
+			result:  `INSERT INTO "test" ("id", "name") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
+		},
+		{
+			name:    "postgres",
+			driver:  database.Postgres,
+			columns: []string{"id", "name"},
+			result:  `INSERT INTO "test" ("id", "name") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
+		},
+		{
+			name:    "postgres with only keys",
+			driver:  database.Postgres,
+			columns: []string{"id"},
+			result:  `INSERT INTO "test" ("id") VALUES (?) ON CONFLICT ("id") DO NOTHING`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dialect, err := database.NewDialect(test.driver)
+			if err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+			stmt := dialect.Upsert("test", test.columns, []string{"id"}, query.Params(len(test.columns)))
+			if expected, actual := test.result, stmt; expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpsertWithConflict(t *testing.T) {
+	db, err := sql.Open(database.SQLite, ":memory:?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{
+		"CREATE TABLE test (id INTEGER PRIMARY KEY, name TEXT, colour TEXT DEFAULT 'none')",
+		"CREATE TABLE parts (id INTEGER PRIMARY KEY, test_id INTEGER REFERENCES test (id) ON DELETE CASCADE)",
+		"INSERT INTO test (id, name, colour) VALUES (1, 'foo', 'red')",
+		"INSERT INTO parts (id, test_id) VALUES (1, 1)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+	}
+
+	dialect, err := database.NewDialect(database.SQLite)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	stmt := dialect.Upsert("test", []string{"id", "name"}, []string{"id"}, query.Params(2))
+	if _, err := db.Exec(stmt, 1, "bar"); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	// The conflicting row is updated in place, rather than replaced.
+	var name, colour string
+	if err := db.QueryRow("SELECT name, colour FROM test WHERE id = 1").Scan(&name, &colour); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := "bar", name; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+	if expected, actual := "red", colour; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+	var parts int
+	if err := db.QueryRow("SELECT COUNT(*) FROM parts").Scan(&parts); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, parts; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestStmtResetSequence(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		driver string
+		result string
+	}{
+		{
+			name:   "sqlite",
+			driver: database.SQLite,
+			result: "",
+		},
+		{
+			name:   "postgres",
+			driver: database.Postgres,
+			result: `SELECT setval(pg_get_serial_sequence('"Order"', 'id'), COALESCE(MAX("id"), 0) + 1, false) FROM "Order"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dialect, err := database.NewDialect(test.driver)
+			if err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+			if expected, actual := test.result, dialect.StmtResetSequence("Order", "id"); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, driverName := range []string{database.SQLite, database.Postgres} {
+		t.Run(driverName, func(t *testing.T) {
+			dialect, err := database.NewDialect(driverName)
+			if err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+			if expected, actual := `"key"`, dialect.Quote("key"); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+			if expected, actual := `"a""b"`, dialect.Quote(`a"b`); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bicycolet/bicycolet/internal/resilience/clock"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+)
+
+// Metrics holds the instruments the shims record the activity of the
+// database with.
+type Metrics struct {
+	// QueryDuration observes how many seconds every statement takes, up to
+	// the closing of the rows it yields. It's labelled with the normalized
+	// SQL of the statement, under "query".
+	QueryDuration metrics.Histogram
+
+	// TxDuration observes how many seconds every transaction stays open.
+	TxDuration metrics.Histogram
+
+	// Commits counts the committed transactions.
+	Commits metrics.Counter
+
+	// Rollbacks counts the rolled back transactions, including the ones that
+	// failed to commit.
+	Rollbacks metrics.Counter
+
+	// OpenTxs gauges how many transactions are open.
+	OpenTxs metrics.Gauge
+}
+
+// NewDiscardMetrics creates Metrics that record nothing.
+func NewDiscardMetrics() Metrics {
+	return Metrics{
+		QueryDuration: discard.NewHistogram(),
+		TxDuration:    discard.NewHistogram(),
+		Commits:       discard.NewCounter(),
+		Rollbacks:     discard.NewCounter(),
+		OpenTxs:       discard.NewGauge(),
+	}
+}
+
+// StatementHistogram is a histogram keeping a series per statement, as
+// labelled by the shims under "query", for backends whose histograms ignore
+// their labels, such as expvar. The series are created on demand.
+type StatementHistogram struct {
+	newFn  func(query string) metrics.Histogram
+	mutex  sync.Mutex
+	series map[string]metrics.Histogram
+}
+
+// NewStatementHistogram creates a StatementHistogram, whose series are
+// created by the given function. Observations without a statement go to the
+// series of the empty statement.
+func NewStatementHistogram(newFn func(query string) metrics.Histogram) *StatementHistogram {
+	return &StatementHistogram{
+		newFn:  newFn,
+		series: make(map[string]metrics.Histogram),
+	}
+}
+
+// With returns the series of the statement given under "query".
+func (h *StatementHistogram) With(labelValues ...string) metrics.Histogram {
+	var query string
+	for i := 0; i+1 < len(labelValues); i += 2 {
+		if labelValues[i] == "query" {
+			query = labelValues[i+1]
+		}
+	}
+	return h.Series(query)
+}
+
+// Observe records the value in the series of the empty statement.
+func (h *StatementHistogram) Observe(value float64) {
+	h.Series("").Observe(value)
+}
+
+// Series returns the series of the given statement, creating it if needed.
+func (h *StatementHistogram) Series(query string) metrics.Histogram {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	series, ok := h.series[query]
+	if !ok {
+		series = h.newFn(query)
+		h.series[query] = series
+	}
+	return series
+}
+
+// Statements returns the statements that have a series, sorted.
+func (h *StatementHistogram) Statements() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	statements := make([]string, 0, len(h.series))
+	for query := range h.series {
+		statements = append(statements, query)
+	}
+	sort.Strings(statements)
+	return statements
+}
+
+// recorder records the activity of the database on behalf of the shims.
+type recorder struct {
+	metrics            Metrics
+	logger             log.Logger
+	slowQueryThreshold time.Duration
+	clock              clock.Clock
+}
+
+// Return a function to call once the given statement is done, which
+// records how long it took from now on.
+func (r *recorder) query(query string, args int) func() {
+	start := r.clock.Now()
+	return func() {
+		elapsed := r.clock.Now().Sub(start)
+		normalized := NormalizeSQL(query)
+		r.metrics.QueryDuration.With("query", normalized).Observe(elapsed.Seconds())
+		if r.slowQueryThreshold > 0 && elapsed >= r.slowQueryThreshold {
+			level.Warn(r.logger).Log("msg", "slow query", "query", normalized, "args", args, "duration", elapsed)
+		}
+	}
+}
+
+// Record that a transaction began, returning a function to call once it
+// ends, telling whether it was committed.
+func (r *recorder) tx() func(committed bool) {
+	start := r.clock.Now()
+	r.metrics.OpenTxs.Add(1)
+	return func(committed bool) {
+		r.metrics.OpenTxs.Add(-1)
+		r.metrics.TxDuration.Observe(r.clock.Now().Sub(start).Seconds())
+		if committed {
+			r.metrics.Commits.Add(1)
+		} else {
+			r.metrics.Rollbacks.Add(1)
+		}
+	}
+}
+
+var (
+	placeholdersRegexp = regexp.MustCompile(`\?(?:, \?)+`)
+	tuplesRegexp       = regexp.MustCompile(`\((\?(?:, \.\.\.)?)\)(?:, \(\?(?:, \.\.\.)?\))+`)
+)
+
+// NormalizeSQL returns the given statement with its literals replaced by
+// placeholders, its whitespace collapsed and its lists of placeholders
+// shortened, so that statements that only differ by their values normalize
+// to the same string.
+func NormalizeSQL(query string) string {
+	var (
+		buf   strings.Builder
+		runes = []rune(query)
+		space bool
+	)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == ',':
+			space = false
+			buf.WriteString(", ")
+			for i+1 < len(runes) && unicode.IsSpace(runes[i+1]) {
+				i++
+			}
+			continue
+		}
+		if space && buf.Len() > 0 && !strings.HasSuffix(buf.String(), " ") && r != ')' {
+			buf.WriteRune(' ')
+		}
+		space = false
+
+		switch {
+		case r == '\'':
+			// String literals end with a quote that isn't doubled.
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			buf.WriteRune('?')
+		case r == '"':
+			// Quoted identifiers are kept as is.
+			buf.WriteRune(r)
+			for i++; i < len(runes); i++ {
+				buf.WriteRune(runes[i])
+				if runes[i] == '"' {
+					break
+				}
+			}
+		case r == '$' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			for i+1 < len(runes) && unicode.IsDigit(runes[i+1]) {
+				i++
+			}
+			buf.WriteRune('?')
+		case unicode.IsDigit(r) && (i == 0 || !isIdentifierRune(runes[i-1])):
+			for i+1 < len(runes) && (unicode.IsDigit(runes[i+1]) || runes[i+1] == '.') {
+				i++
+			}
+			buf.WriteRune('?')
+		case r == '(':
+			buf.WriteRune(r)
+			for i+1 < len(runes) && unicode.IsSpace(runes[i+1]) {
+				i++
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+
+	normalized := strings.TrimSpace(buf.String())
+	normalized = placeholdersRegexp.ReplaceAllString(normalized, "?, ...")
+	normalized = tuplesRegexp.ReplaceAllString(normalized, "($1), ...")
+	return normalized
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
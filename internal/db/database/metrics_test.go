@@ -0,0 +1,313 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/generic"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	for _, test := range []struct {
+		query  string
+		result string
+	}{
+		{
+			query:  "SELECT id FROM test WHERE name = 'it''s' AND id > 10",
+			result: "SELECT id FROM test WHERE name = ? AND id > ?",
+		},
+		{
+			query:  "\n  SELECT id,name\n\tFROM test2  WHERE id = $1\n",
+			result: "SELECT id, name FROM test2 WHERE id = ?",
+		},
+		{
+			query:  `DELETE FROM "config" WHERE "key" IN (?, ?, ?)`,
+			result: `DELETE FROM "config" WHERE "key" IN (?, ...)`,
+		},
+		{
+			query:  `INSERT INTO "config" ("key", "value") VALUES (?, ?), (?, ?),(?, ?)`,
+			result: `INSERT INTO "config" ("key", "value") VALUES (?, ...), ...`,
+		},
+		{
+			query:  "INSERT INTO test (name) VALUES ( ? ), (?)",
+			result: "INSERT INTO test (name) VALUES (?), ...",
+		},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			if expected, actual := test.result, database.NormalizeSQL(test.query); expected != actual {
+				t.Errorf("expected: %q, actual: %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestShimMetrics(t *testing.T) {
+	m, observations := newMetrics()
+	var buf bytes.Buffer
+	db, fake := openShimDB(t,
+		database.WithMetrics(m),
+		database.WithLogger(log.NewLogfmtLogger(&buf)),
+		database.WithSlowQueryThreshold(2*time.Second),
+	)
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := float64(1), m.OpenTxs.(*generic.Gauge).Value(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if _, err := tx.Exec("CREATE TABLE test (id INTEGER, name TEXT)"); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	rows, err := tx.QueryContext(context.Background(), "SELECT id FROM test WHERE name = ?", "foo")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	// The clock moves on while the rows are being read, so the query is slow.
+	for rows.Next() {
+	}
+	fake.Now()
+	if err := rows.Close(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	expected := []observation{
+		{labels: []string{"query", "CREATE TABLE test (id INTEGER, name TEXT)"}, value: 1},
+		{labels: []string{"query", "SELECT id FROM test WHERE name = ?"}, value: 2},
+		{labels: nil, value: 6},
+	}
+	if actual := *observations; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := float64(1), m.Commits.(*generic.Counter).Value(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := float64(0), m.OpenTxs.(*generic.Gauge).Value(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	logged := buf.String()
+	if expected := `msg="slow query" query="SELECT id FROM test WHERE name = ?" args=1 duration=2s`; !strings.Contains(logged, expected) {
+		t.Errorf("expected log to contain %q, actual: %q", expected, logged)
+	}
+	if strings.Contains(logged, "CREATE TABLE") {
+		t.Errorf("expected fast queries not to be logged, actual: %q", logged)
+	}
+}
+
+func TestShimMetricsWithRollback(t *testing.T) {
+	m, _ := newMetrics()
+	db, _ := openShimDB(t, database.WithMetrics(m))
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if err := tx.Rollback(); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+
+	if expected, actual := float64(1), m.Rollbacks.(*generic.Counter).Value(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := float64(0), m.Commits.(*generic.Counter).Value(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := float64(0), m.OpenTxs.(*generic.Gauge).Value(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestShimMetricsWithCancelledContext(t *testing.T) {
+	m, _ := newMetrics()
+	db, _ := openShimDB(t, database.WithMetrics(m))
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	cancel()
+
+	// The transaction is rolled back in the background once its context is
+	// done, after which it can't be rolled back anymore.
+	for {
+		if _, err := tx.Exec("SELECT 1"); err == sql.ErrTxDone {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := tx.Rollback(); err != sql.ErrTxDone {
+		t.Errorf("expected: %v, actual: %v", sql.ErrTxDone, err)
+	}
+
+	if expected, actual := float64(1), m.Rollbacks.(*generic.Counter).Value(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := float64(0), m.OpenTxs.(*generic.Gauge).Value(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestShimMetricsWithPreparedStatement(t *testing.T) {
+	m, observations := newMetrics()
+	db, _ := openShimDB(t, database.WithMetrics(m))
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer stmt.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.StmtContext(context.Background(), stmt).QueryContext(context.Background())
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	rows.Close()
+
+	expected := []observation{
+		{labels: []string{"query", "SELECT ?"}, value: 1},
+	}
+	if actual := *observations; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestShimMetricsWithStatementHistogram(t *testing.T) {
+	series := make(map[string]*[]observation)
+	queryDuration := database.NewStatementHistogram(func(query string) metrics.Histogram {
+		observations := new([]observation)
+		series[query] = observations
+		return histogram{observations: observations}
+	})
+
+	m, _ := newMetrics()
+	m.QueryDuration = queryDuration
+	db, _ := openShimDB(t, database.WithMetrics(m))
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{"SELECT 1", "SELECT 2 + 3", "SELECT 4"} {
+		rows, err := tx.QueryContext(context.Background(), query)
+		if err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+		rows.Close()
+	}
+	if _, err := tx.Exec("CREATE TABLE test (id INTEGER)"); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	expected := []string{"CREATE TABLE test (id INTEGER)", "SELECT ?", "SELECT ? + ?"}
+	if actual := queryDuration.Statements(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	for query, count := range map[string]int{
+		"CREATE TABLE test (id INTEGER)": 1,
+		"SELECT ?":                       2,
+		"SELECT ? + ?":                   1,
+	} {
+		if expected, actual := count, len(*series[query]); expected != actual {
+			t.Errorf("expected %q to have %d observations, actual: %d", query, expected, actual)
+		}
+	}
+}
+
+// Open a scratch SQLite database, whose shim uses a clock that moves on by a
+// second every time it's read.
+func openShimDB(t *testing.T, options ...database.Option) (database.DB, *fakeClock) {
+	raw, err := sql.Open(database.SQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	raw.SetMaxOpenConns(1)
+
+	dialect, err := database.NewDialect(database.SQLite)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	fake := &fakeClock{now: time.Unix(0, 0)}
+	options = append(options, database.WithClock(fake))
+	return database.NewShimDB(raw, dialect, options...), fake
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func (c *fakeClock) UTC() time.Time {
+	return c.Now().UTC()
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type observation struct {
+	labels []string
+	value  float64
+}
+
+// histogram records every observation along with its labels.
+type histogram struct {
+	labels       []string
+	observations *[]observation
+}
+
+func (h histogram) With(labelValues ...string) metrics.Histogram {
+	return histogram{
+		labels:       append(append([]string{}, h.labels...), labelValues...),
+		observations: h.observations,
+	}
+}
+
+func (h histogram) Observe(value float64) {
+	*h.observations = append(*h.observations, observation{labels: h.labels, value: value})
+}
+
+// Return metrics whose durations are all recorded together, in order.
+func newMetrics() (database.Metrics, *[]observation) {
+	observations := new([]observation)
+	return database.Metrics{
+		QueryDuration: histogram{observations: observations},
+		TxDuration:    histogram{observations: observations},
+		Commits:       generic.NewCounter("commits"),
+		Rollbacks:     generic.NewCounter("rollbacks"),
+		OpenTxs:       generic.NewGauge("open_txs"),
+	}, observations
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/resilience/clock"
+	"github.com/go-kit/kit/log"
+)
+
+// Option to be passed to NewShimDB to customize the resulting instance.
+type Option func(*options)
+
+type options struct {
+	metrics            Metrics
+	logger             log.Logger
+	slowQueryThreshold time.Duration
+	clock              clock.Clock
+}
+
+// WithMetrics sets the metrics on the option
+func WithMetrics(metrics Metrics) Option {
+	return func(options *options) {
+		options.metrics = metrics
+	}
+}
+
+// WithLogger sets the logger on the option
+func WithLogger(logger log.Logger) Option {
+	return func(options *options) {
+		options.logger = logger
+	}
+}
+
+// WithSlowQueryThreshold sets how long a statement can take before it's
+// logged as a slow query. Zero disables the slow query log.
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(options *options) {
+		options.slowQueryThreshold = threshold
+	}
+}
+
+// WithClock sets the clock on the option
+func WithClock(clock clock.Clock) Option {
+	return func(options *options) {
+		options.clock = clock
+	}
+}
+
+// Create a options instance with default values.
+func newOptions() *options {
+	return &options{
+		metrics: NewDiscardMetrics(),
+		logger:  log.NewNopLogger(),
+		clock:   clock.New(),
+	}
+}
@@ -1,6 +1,224 @@
 package database
 
-// DriverName to be used for the database.
-func DriverName() string {
-	return "postgres"
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	// Register the postgres driver with database/sql.
+	_ "github.com/lib/pq"
+)
+
+type postgresDialect struct{}
+
+func (postgresDialect) DriverName() string {
+	return Postgres
+}
+
+// Rebind numbers the placeholders, skipping over any quoted literals and
+// identifiers, escape strings, dollar-quoted strings and comments.
+func (postgresDialect) Rebind(query string) string {
+	var (
+		buf   strings.Builder
+		runes = []rune(query)
+		n     int
+	)
+	for i := 0; i < len(runes); i++ {
+		end := i + 1
+		switch r := runes[i]; {
+		case r == '?':
+			n++
+			buf.WriteString("$" + strconv.Itoa(n))
+			continue
+		case r == '\'' || r == '"':
+			// A doubled quote closes the quote and opens it again.
+			end = skipPast(runes, i+1, string(r))
+		case (r == 'E' || r == 'e') && nextRune(runes, i) == '\'' && (i == 0 || !isIdentifierRune(runes[i-1])):
+			end = skipEscapeString(runes, i+2)
+		case r == '-' && nextRune(runes, i) == '-':
+			end = skipPast(runes, i+2, "\n")
+		case r == '/' && nextRune(runes, i) == '*':
+			end = skipPast(runes, i+2, "*/")
+		case r == '$':
+			if tag := dollarTag(runes, i); tag != "" {
+				end = skipPast(runes, i+len(tag), tag)
+			}
+		}
+		buf.WriteString(string(runes[i:end]))
+		i = end - 1
+	}
+	return buf.String()
+}
+
+func (postgresDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier)
+}
+
+func (postgresDialect) Upsert(table string, columns, keys []string, values string) string {
+	return upsertOnConflict(table, columns, keys, values)
+}
+
+func (postgresDialect) Returning(column string) string {
+	return fmt.Sprintf(" RETURNING %s", quoteIdentifier(column))
+}
+
+func (postgresDialect) StmtTableNames() string {
+	return `
+SELECT c.relname::text FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'r' AND n.nspname = current_schema()
+ORDER BY c.oid
+`
+}
+
+func (postgresDialect) StmtTableExists() string {
+	return `
+SELECT COUNT(c.relname) FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'r' AND n.nspname = current_schema() AND c.relname = ?
+`
+}
+
+// Postgres doesn't keep the statements that created the tables around, so
+// they're rebuilt from the catalog. Columns backed by a sequence are turned
+// back into serial ones, so that the sequence is recreated along with them.
+func (postgresDialect) StmtTables() string {
+	return `
+SELECT c.relname::text, 'CREATE TABLE ' || quote_ident(c.relname) || E' (\n' || array_to_string(ARRAY(
+	SELECT E'\t' || quote_ident(a.attname) || ' ' ||
+		CASE WHEN pg_get_expr(d.adbin, d.adrelid) LIKE 'nextval(%' THEN
+			CASE WHEN a.atttypid = 'bigint'::regtype THEN 'BIGSERIAL' ELSE 'SERIAL' END
+		ELSE
+			format_type(a.atttypid, a.atttypmod) || COALESCE(' DEFAULT ' || pg_get_expr(d.adbin, d.adrelid), '')
+		END ||
+		CASE WHEN a.attnotnull THEN ' NOT NULL' ELSE '' END
+	FROM pg_attribute a
+	LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+	WHERE a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+	ORDER BY a.attnum
+) || ARRAY(
+	SELECT E'\t' || pg_get_constraintdef(con.oid)
+	FROM pg_constraint con
+	WHERE con.conrelid = c.oid
+	ORDER BY con.conname
+), E',\n') || E'\n)'
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'r' AND n.nspname = current_schema()
+ORDER BY c.oid
+`
+}
+
+// The statements creating the indexes are stripped of the schema their table
+// is qualified with, so that they don't depend on it.
+func (postgresDialect) StmtIndexes() string {
+	return `
+SELECT c.relname::text, replace(pg_get_indexdef(c.oid), ' ON ' || quote_ident(n.nspname) || '.', ' ON ')
+FROM pg_index i
+JOIN pg_class c ON c.oid = i.indexrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema()
+AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = i.indexrelid)
+ORDER BY c.relname
+`
+}
+
+func (postgresDialect) StmtDeferForeignKeys() string {
+	return `
+SET CONSTRAINTS ALL DEFERRED
+`
+}
+
+// The table given to pg_get_serial_sequence is parsed as an identifier, so
+// it's quoted twice over, unlike the column, which is taken as is.
+func (postgresDialect) StmtResetSequence(table, column string) string {
+	return fmt.Sprintf(
+		"SELECT setval(pg_get_serial_sequence(%s, %s), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+		quoteString(quoteIdentifier(table)), quoteString(column), quoteIdentifier(column), quoteIdentifier(table))
+}
+
+func (postgresDialect) Now() string {
+	return "now()"
+}
+
+func (postgresDialect) DateTime() string {
+	return "TIMESTAMP WITH TIME ZONE"
+}
+
+func (postgresDialect) Blob() string {
+	return "BYTEA"
+}
+
+// The hex format of bytea, rather than the escape one, reads the same
+// whatever the bytes are.
+func (postgresDialect) BlobLiteral(data []byte) string {
+	return `'\x` + hex.EncodeToString(data) + "'"
+}
+
+func (postgresDialect) AutoIncrement() string {
+	return "SERIAL PRIMARY KEY"
+}
+
+func contains(a []string, b string) bool {
+	for _, v := range a {
+		if v == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the tag opening a dollar-quoted string at the given index, such as
+// $$ or $body$, or an empty string if there's none.
+func dollarTag(runes []rune, i int) string {
+	for j := i + 1; j < len(runes); j++ {
+		switch r := runes[j]; {
+		case r == '$':
+			return string(runes[i : j+1])
+		case unicode.IsDigit(r) && j == i+1:
+			return ""
+		case !isIdentifierRune(r):
+			return ""
+		}
+	}
+	return ""
+}
+
+// Return the index right after the first occurrence of the given pattern in
+// the runes, looking from the given index onwards, or their length if there's
+// none.
+func skipPast(runes []rune, from int, pattern string) int {
+	n := len([]rune(pattern))
+	for i := from; i+n <= len(runes); i++ {
+		if string(runes[i:i+n]) == pattern {
+			return i + n
+		}
+	}
+	return len(runes)
+}
+
+// Return the index right after the end of the escape string whose contents
+// start at the given index, in which backslashes escape the next character.
+func skipEscapeString(runes []rune, from int) int {
+	for i := from; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '\'':
+			if nextRune(runes, i) != '\'' {
+				return i + 1
+			}
+			i++
+		}
+	}
+	return len(runes)
+}
+
+func nextRune(runes []rune, i int) rune {
+	if i+1 < len(runes) {
+		return runes[i+1]
+	}
+	return 0
 }
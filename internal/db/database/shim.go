@@ -1,7 +1,9 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"sync"
 
 	"github.com/pkg/errors"
 )
@@ -11,87 +13,209 @@ import (
 // structure.
 // Additionally we can also insert better errors and mertics when we need to
 // so.
-func NewShimDB(db *sql.DB) DB {
+//
+// The queries are rewritten for the given dialect before they're executed,
+// so that the same queries can be run against any of the dialects.
+//
+// Every statement and transaction is recorded with the metrics given as an
+// option, and the statements taking longer than the slow query threshold are
+// logged.
+func NewShimDB(db *sql.DB, dialect Dialect, options ...Option) DB {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
 	return &databaseShim{
-		db: db,
+		db:      db,
+		dialect: dialect,
+		recorder: &recorder{
+			metrics:            opts.metrics,
+			logger:             opts.logger,
+			slowQueryThreshold: opts.slowQueryThreshold,
+			clock:              opts.clock,
+		},
 	}
 }
 
 // The following will shim the database to enable better logging and metrics
 // at the query sites.
 
-// ShimDB takes a db and err and returns a database shim
-// See NewShimDB
-func ShimDB(db *sql.DB, err error) (DB, error) {
+// shimTx takes a tx and err and returns a Tx shim, which records the
+// transaction until it's committed or rolled back.
+func shimTx(tx *sql.Tx, dialect Dialect, recorder *recorder, err error) (Tx, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return &databaseShim{
-		db: db,
+	return &txShim{
+		tx:       tx,
+		dialect:  dialect,
+		recorder: recorder,
+		done:     recorder.tx(),
 	}, nil
 }
 
-// ShimTx takes a db and err and returns a Tx shim
-func ShimTx(tx *sql.Tx, err error) (Tx, error) {
+func shimStmt(stmt *sql.Stmt, query string, recorder *recorder, err error) (Stmt, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return &txShim{
-		tx: tx,
+	return &stmtShim{
+		stmt:     stmt,
+		query:    query,
+		recorder: recorder,
 	}, nil
 }
 
-func shimRows(rows *sql.Rows, err error) (Rows, error) {
+// shimRows takes rows and err and returns a Rows shim, calling done once the
+// rows are closed, or straight away if there's an error.
+func shimRows(rows *sql.Rows, done func(), err error) (Rows, error) {
 	if err != nil {
+		done()
 		return nil, errors.WithStack(err)
 	}
 	return &rowsShim{
 		rows: rows,
+		done: done,
 	}, nil
 }
 
 type databaseShim struct {
-	db *sql.DB
+	db       *sql.DB
+	dialect  Dialect
+	recorder *recorder
 }
 
 func (w *databaseShim) Begin() (Tx, error) {
-	return ShimTx(w.db.Begin())
+	tx, err := w.db.Begin()
+	return shimTx(tx, w.dialect, w.recorder, err)
+}
+
+func (w *databaseShim) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := w.db.BeginTx(ctx, opts)
+	return shimTx(tx, w.dialect, w.recorder, err)
 }
 
 func (w *databaseShim) Ping() error {
 	return w.db.Ping()
 }
 
+func (w *databaseShim) PingContext(ctx context.Context) error {
+	return w.db.PingContext(ctx)
+}
+
+func (w *databaseShim) PrepareContext(ctx context.Context, query string) (Stmt, error) {
+	stmt, err := w.db.PrepareContext(ctx, w.dialect.Rebind(query))
+	return shimStmt(stmt, query, w.recorder, err)
+}
+
 func (w *databaseShim) Close() error {
 	return w.db.Close()
 }
 
+func (w *databaseShim) Dialect() Dialect {
+	return w.dialect
+}
+
 func (w *databaseShim) Raw() *sql.DB {
 	return w.db
 }
 
 type txShim struct {
-	tx *sql.Tx
+	tx       *sql.Tx
+	dialect  Dialect
+	recorder *recorder
+	once     sync.Once
+	done     func(committed bool)
 }
 
 func (w *txShim) Query(query string, args ...interface{}) (Rows, error) {
-	return shimRows(w.tx.Query(query, args...))
+	done := w.recorder.query(query, len(args))
+	rows, err := w.tx.Query(w.dialect.Rebind(query), args...)
+	return shimRows(rows, done, err)
+}
+
+func (w *txShim) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	done := w.recorder.query(query, len(args))
+	rows, err := w.tx.QueryContext(ctx, w.dialect.Rebind(query), args...)
+	return shimRows(rows, done, err)
 }
 
 func (w *txShim) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return w.tx.Exec(query, args...)
+	defer w.recorder.query(query, len(args))()
+	return w.tx.Exec(w.dialect.Rebind(query), args...)
+}
+
+func (w *txShim) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer w.recorder.query(query, len(args))()
+	return w.tx.ExecContext(ctx, w.dialect.Rebind(query), args...)
 }
 
+// StmtContext binds statements prepared by the shim to the transaction, any
+// other statement is returned as is.
+func (w *txShim) StmtContext(ctx context.Context, stmt Stmt) Stmt {
+	shim, ok := stmt.(*stmtShim)
+	if !ok {
+		return stmt
+	}
+	return &stmtShim{
+		stmt:     w.tx.StmtContext(ctx, shim.stmt),
+		query:    shim.query,
+		recorder: w.recorder,
+	}
+}
+
+func (w *txShim) Dialect() Dialect {
+	return w.dialect
+}
+
+// Commit commits the transaction, which ends it even if it fails, in which
+// case it's recorded as rolled back.
 func (w *txShim) Commit() error {
-	return w.tx.Commit()
+	err := w.tx.Commit()
+	w.end(err == nil)
+	return err
 }
 
+// Rollback rolls the transaction back. It's recorded as rolled back even if
+// it was already ended, which is the case when its context is done.
 func (w *txShim) Rollback() error {
-	return w.tx.Rollback()
+	err := w.tx.Rollback()
+	w.end(false)
+	return err
+}
+
+// Record the end of the transaction, only the first time it's called.
+func (w *txShim) end(committed bool) {
+	w.once.Do(func() {
+		w.done(committed)
+	})
+}
+
+type stmtShim struct {
+	stmt     *sql.Stmt
+	query    string
+	recorder *recorder
+}
+
+func (w *stmtShim) QueryContext(ctx context.Context, args ...interface{}) (Rows, error) {
+	done := w.recorder.query(w.query, len(args))
+	rows, err := w.stmt.QueryContext(ctx, args...)
+	return shimRows(rows, done, err)
+}
+
+func (w *stmtShim) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	defer w.recorder.query(w.query, len(args))()
+	return w.stmt.ExecContext(ctx, args...)
+}
+
+func (w *stmtShim) Close() error {
+	return w.stmt.Close()
 }
 
 type rowsShim struct {
 	rows *sql.Rows
+	once sync.Once
+	done func()
 }
 
 func (w *rowsShim) Columns() ([]string, error) {
@@ -122,6 +246,9 @@ func (w *rowsShim) Err() error {
 	return w.rows.Err()
 }
 
+// Close closes the rows, which completes the statement that yielded them.
 func (w *rowsShim) Close() error {
-	return w.rows.Close()
+	err := w.rows.Close()
+	w.once.Do(w.done)
+	return err
 }
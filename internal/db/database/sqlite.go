@@ -0,0 +1,99 @@
+package database
+
+import (
+	"encoding/hex"
+
+	// Register the sqlite driver with database/sql.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteParams are the parameters of the connections to a SQLite database.
+// The write-ahead log lets readers carry on while a transaction is writing,
+// and waiting on a busy database, rather than failing straight away, lets
+// concurrent transactions queue up. Transactions take the write lock when
+// they begin instead of on their first write, which would fail if another
+// transaction got the lock in the meantime.
+const sqliteParams = "_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on&_txlock=immediate"
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) DriverName() string {
+	return SQLite
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier)
+}
+
+// Replacing the conflicting row would delete it first, resetting the columns
+// that aren't upserted and cascading to the rows referencing it, so it's
+// updated in place instead.
+func (sqliteDialect) Upsert(table string, columns, keys []string, values string) string {
+	return upsertOnConflict(table, columns, keys, values)
+}
+
+func (sqliteDialect) Returning(column string) string {
+	return ""
+}
+
+func (sqliteDialect) StmtTableNames() string {
+	return `
+SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY rowid
+`
+}
+
+func (sqliteDialect) StmtTableExists() string {
+	return `
+SELECT COUNT(name) FROM sqlite_master WHERE type = 'table' AND name = ?
+`
+}
+
+func (sqliteDialect) StmtTables() string {
+	return `
+SELECT name, sql FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY rowid
+`
+}
+
+// Indexes backing a constraint are created automatically, without any
+// statement.
+func (sqliteDialect) StmtIndexes() string {
+	return `
+SELECT name, sql FROM sqlite_master WHERE type = 'index' AND sql IS NOT NULL ORDER BY name
+`
+}
+
+func (sqliteDialect) StmtDeferForeignKeys() string {
+	return `
+PRAGMA defer_foreign_keys = ON
+`
+}
+
+// Inserting a row with an explicit id already moves the sequence of an
+// AUTOINCREMENT column past it.
+func (sqliteDialect) StmtResetSequence(table, column string) string {
+	return ""
+}
+
+func (sqliteDialect) Now() string {
+	return `strftime("%s")`
+}
+
+func (sqliteDialect) DateTime() string {
+	return "DATETIME"
+}
+
+func (sqliteDialect) Blob() string {
+	return "BLOB"
+}
+
+func (sqliteDialect) BlobLiteral(data []byte) string {
+	return "X'" + hex.EncodeToString(data) + "'"
+}
+
+func (sqliteDialect) AutoIncrement() string {
+	return "INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL"
+}
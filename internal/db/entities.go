@@ -0,0 +1,20 @@
+package db
+
+//go:generate go run github.com/bicycolet/bicycolet/cmd/bicycolet-generate -source entities.go -output entities_gen.go -test entities_gen_test.go
+
+// Config is a key of the node-level config.
+//
+// generate:entity table=config plural=Config filters=Key
+type Config struct {
+	ID    int64  `db:"id,pk"`
+	Key   string `db:"key"`
+	Value string `db:"value"`
+}
+
+// RaftNode is a node taking part in the raft cluster.
+//
+// generate:entity table=raft_nodes filters=Address
+type RaftNode struct {
+	ID      int64  `db:"id,pk"`
+	Address string `db:"address"`
+}
@@ -0,0 +1,113 @@
+// Code generated by "bicycolet-generate". DO NOT EDIT.
+
+package db
+
+import (
+	"github.com/pkg/errors"
+)
+
+var stmtConfigObjectsByKey = RegisterStmt("configObjectsByKey", `SELECT "id", "key", "value" FROM "config" WHERE "key" = ? ORDER BY "id"`)
+var stmtConfigObjects = RegisterStmt("configObjects", `SELECT "id", "key", "value" FROM "config" ORDER BY "id"`)
+
+// ConfigFilter holds the fields config can be filtered by, the zero
+// ones are ignored.
+type ConfigFilter struct {
+	Key string
+}
+
+// GetConfig returns the config matching the given filter.
+func (n *NodeTx) GetConfig(filter ConfigFilter) ([]Config, error) {
+	var (
+		code int
+		args []interface{}
+	)
+	switch {
+	case filter.Key != "":
+		code = stmtConfigObjectsByKey
+		args = []interface{}{filter.Key}
+	default:
+		code = stmtConfigObjects
+	}
+
+	objects := make([]Config, 0)
+	dest := func(i int) []interface{} {
+		objects = append(objects, Config{})
+		return []interface{}{&objects[i].ID, &objects[i].Key, &objects[i].Value}
+	}
+	stmt, err := n.stmt(code)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch config")
+	}
+	if err := n.query.SelectObjectsStmt(n.ctx, stmt, dest, args...); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch config")
+	}
+	return objects, nil
+}
+
+// CreateConfig adds a new config, returning its id.
+func (n *NodeTx) CreateConfig(object Config) (int64, error) {
+	columns := []string{"key", "value"}
+	values := []interface{}{object.Key, object.Value}
+	id, err := n.query.UpsertObject(n.ctx, n.tx, "config", columns, values)
+	return id, errors.Wrap(err, "failed to create config")
+}
+
+// DeleteConfig removes the config with the given id, returning
+// whether it existed.
+func (n *NodeTx) DeleteConfig(id int64) (bool, error) {
+	deleted, err := n.query.DeleteObject(n.ctx, n.tx, "config", id)
+	return deleted, errors.Wrap(err, "failed to delete config")
+}
+
+var stmtRaftNodeObjectsByAddress = RegisterStmt("raftNodeObjectsByAddress", `SELECT "id", "address" FROM "raft_nodes" WHERE "address" = ? ORDER BY "id"`)
+var stmtRaftNodeObjects = RegisterStmt("raftNodeObjects", `SELECT "id", "address" FROM "raft_nodes" ORDER BY "id"`)
+
+// RaftNodeFilter holds the fields raft nodes can be filtered by, the zero
+// ones are ignored.
+type RaftNodeFilter struct {
+	Address string
+}
+
+// GetRaftNodes returns the raft nodes matching the given filter.
+func (n *NodeTx) GetRaftNodes(filter RaftNodeFilter) ([]RaftNode, error) {
+	var (
+		code int
+		args []interface{}
+	)
+	switch {
+	case filter.Address != "":
+		code = stmtRaftNodeObjectsByAddress
+		args = []interface{}{filter.Address}
+	default:
+		code = stmtRaftNodeObjects
+	}
+
+	objects := make([]RaftNode, 0)
+	dest := func(i int) []interface{} {
+		objects = append(objects, RaftNode{})
+		return []interface{}{&objects[i].ID, &objects[i].Address}
+	}
+	stmt, err := n.stmt(code)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch raft nodes")
+	}
+	if err := n.query.SelectObjectsStmt(n.ctx, stmt, dest, args...); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch raft nodes")
+	}
+	return objects, nil
+}
+
+// CreateRaftNode adds a new raft node, returning its id.
+func (n *NodeTx) CreateRaftNode(object RaftNode) (int64, error) {
+	columns := []string{"address"}
+	values := []interface{}{object.Address}
+	id, err := n.query.UpsertObject(n.ctx, n.tx, "raft_nodes", columns, values)
+	return id, errors.Wrap(err, "failed to create raft node")
+}
+
+// DeleteRaftNode removes the raft node with the given id, returning
+// whether it existed.
+func (n *NodeTx) DeleteRaftNode(id int64) (bool, error) {
+	deleted, err := n.query.DeleteObject(n.ctx, n.tx, "raft_nodes", id)
+	return deleted, errors.Wrap(err, "failed to delete raft node")
+}
@@ -0,0 +1,18 @@
+package db
+
+import (
+	"context"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+)
+
+// NewNodeWithMocks creates a Node with the given dependencies for testing.
+func NewNodeWithMocks(node QueryNode, transaction Transaction, query Query) *Node {
+	return &Node{
+		node:        node,
+		transaction: transaction,
+		builder: func(ctx context.Context, tx database.Tx) *NodeTx {
+			return NewNodeTxWithQuery(ctx, tx, query)
+		},
+	}
+}
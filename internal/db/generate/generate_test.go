@@ -0,0 +1,127 @@
+package generate_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/generate"
+)
+
+const source = `package db
+
+// Car is a car.
+//
+// generate:entity table=cars filters=Brand,Year
+type Car struct {
+	ID    int64  ` + "`db:\"id,pk\"`" + `
+	Brand string ` + "`db:\"brand\"`" + `
+	Year  int    ` + "`db:\"year\"`" + `
+	Notes string
+}
+
+type ignored struct {
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+func TestParse(t *testing.T) {
+	pkg, entities, err := generate.Parse("cars.go", []byte(source))
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := "db", pkg; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+
+	brand := generate.Field{Name: "Brand", Type: "string", Column: "brand"}
+	year := generate.Field{Name: "Year", Type: "int", Column: "year"}
+	expected := []generate.Entity{
+		{
+			Name:   "Car",
+			Plural: "Cars",
+			Table:  "cars",
+			Fields: []generate.Field{
+				{Name: "ID", Type: "int64", Column: "id", PK: true},
+				brand,
+				year,
+			},
+			Filters: []generate.Field{brand, year},
+		},
+	}
+	if actual := entities; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestParseWithoutTable(t *testing.T) {
+	src := strings.Replace(source, "table=cars ", "", 1)
+	if _, _, err := generate.Parse("cars.go", []byte(src)); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestParseWithUnknownFilter(t *testing.T) {
+	src := strings.Replace(source, "filters=Brand,Year", "filters=Colour", 1)
+	if _, _, err := generate.Parse("cars.go", []byte(src)); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestSource(t *testing.T) {
+	pkg, entities, err := generate.Parse("cars.go", []byte(source))
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	code, err := generate.Source(pkg, entities)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	for _, want := range []string{
+		"var stmtCarObjectsByBrandAndYear = RegisterStmt(\"carObjectsByBrandAndYear\", `SELECT \"id\", \"brand\", \"year\" FROM \"cars\" WHERE \"brand\" = ? AND \"year\" = ? ORDER BY \"id\"`)",
+		"var stmtCarObjectsByBrand = RegisterStmt(\"carObjectsByBrand\", `SELECT \"id\", \"brand\", \"year\" FROM \"cars\" WHERE \"brand\" = ? ORDER BY \"id\"`)",
+		"var stmtCarObjectsByYear = RegisterStmt(\"carObjectsByYear\", `SELECT \"id\", \"brand\", \"year\" FROM \"cars\" WHERE \"year\" = ? ORDER BY \"id\"`)",
+		"var stmtCarObjects = RegisterStmt(\"carObjects\", `SELECT \"id\", \"brand\", \"year\" FROM \"cars\" ORDER BY \"id\"`)",
+		"case filter.Brand != \"\" && filter.Year != 0:\n",
+		"func (n *NodeTx) GetCars(filter CarFilter) ([]Car, error) {\n",
+		"func (n *NodeTx) CreateCar(object Car) (int64, error) {\n",
+		"func (n *NodeTx) DeleteCar(id int64) (bool, error) {\n",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("expected source to contain %q", want)
+		}
+	}
+}
+
+// The generated code of the db package must be regenerated whenever its
+// entities change.
+func TestGeneratedCodeIsUpToDate(t *testing.T) {
+	src, err := ioutil.ReadFile("../entities.go")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	pkg, entities, err := generate.Parse("entities.go", src)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	for file, render := range map[string]func(string, []generate.Entity) ([]byte, error){
+		"../entities_gen.go":      generate.Source,
+		"../entities_gen_test.go": generate.Test,
+	} {
+		expected, err := render(pkg, entities)
+		if err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+		actual, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("%s is out of date, regenerate it using \"go generate\"", file)
+		}
+	}
+}
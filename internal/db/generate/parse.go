@@ -0,0 +1,218 @@
+package generate
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// annotation marks the doc comment of a struct as the one of an entity, it's
+// followed by space separated key=value pairs:
+//
+//	table    the table holding the entities, which is required.
+//	plural   the plural of the struct name, defaults to adding an "s".
+//	filters  comma separated names of the fields to filter entities by.
+const annotation = "generate:entity"
+
+// Entity describes a struct mapped to the rows of a table.
+type Entity struct {
+	// Name of the struct.
+	Name string
+
+	// Plural of the name of the struct.
+	Plural string
+
+	// Table holding the entities.
+	Table string
+
+	// Fields mapped to the columns of the table, in order.
+	Fields []Field
+
+	// Filters are the fields entities can be filtered by.
+	Filters []Field
+}
+
+// PK returns the primary key field of the entity.
+func (e Entity) PK() Field {
+	for _, field := range e.Fields {
+		if field.PK {
+			return field
+		}
+	}
+	return Field{}
+}
+
+// Columns returns the fields that are set when creating an entity, which is
+// all of them but the primary key.
+func (e Entity) Columns() []Field {
+	var fields []Field
+	for _, field := range e.Fields {
+		if !field.PK {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Field describes a struct field mapped to a column.
+type Field struct {
+	// Name of the field.
+	Name string
+
+	// Type of the field.
+	Type string
+
+	// Column the field is mapped to.
+	Column string
+
+	// PK is true if the column is the primary key of the table.
+	PK bool
+}
+
+// Parse returns the package name and the annotated entities of the given Go
+// source.
+func Parse(filename string, src []byte) (string, []Entity, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.ParseComments)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to parse source")
+	}
+
+	var entities []Entity
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			doc := typeSpec.Doc
+			if doc == nil {
+				doc = gen.Doc
+			}
+			params, ok := parseAnnotation(doc)
+			if !ok {
+				continue
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				return "", nil, errors.Errorf("entity %s is not a struct", typeSpec.Name.Name)
+			}
+			entity, err := parseEntity(typeSpec.Name.Name, params, structType)
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "entity %s", typeSpec.Name.Name)
+			}
+			entities = append(entities, entity)
+		}
+	}
+	return file.Name.Name, entities, nil
+}
+
+// Return the key=value pairs of the entity annotation of the given comment,
+// if it has one.
+func parseAnnotation(doc *ast.CommentGroup) (map[string]string, bool) {
+	if doc == nil {
+		return nil, false
+	}
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != annotation {
+			continue
+		}
+		params := make(map[string]string)
+		for _, field := range fields[1:] {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) == 2 {
+				params[parts[0]] = parts[1]
+			} else {
+				params[parts[0]] = ""
+			}
+		}
+		return params, true
+	}
+	return nil, false
+}
+
+func parseEntity(name string, params map[string]string, structType *ast.StructType) (Entity, error) {
+	entity := Entity{
+		Name:   name,
+		Plural: name + "s",
+		Table:  params["table"],
+	}
+	if entity.Table == "" {
+		return entity, errors.Errorf("no table")
+	}
+	if plural := params["plural"]; plural != "" {
+		entity.Plural = plural
+	}
+
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil || len(field.Names) != 1 {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return entity, errors.WithStack(err)
+		}
+		value, ok := reflect.StructTag(tag).Lookup("db")
+		if !ok || value == "-" {
+			continue
+		}
+		ident, ok := field.Type.(*ast.Ident)
+		if !ok {
+			return entity, errors.Errorf("field %s has unsupported type", field.Names[0].Name)
+		}
+
+		parts := strings.Split(value, ",")
+		f := Field{
+			Name:   field.Names[0].Name,
+			Type:   ident.Name,
+			Column: parts[0],
+		}
+		for _, option := range parts[1:] {
+			if option == "pk" {
+				f.PK = true
+			}
+		}
+		entity.Fields = append(entity.Fields, f)
+	}
+	if entity.PK().Column != "id" {
+		return entity, errors.Errorf("no primary key field mapped to the id column")
+	}
+
+	if filters := params["filters"]; filters != "" {
+		for _, name := range strings.Split(filters, ",") {
+			var found bool
+			for _, field := range entity.Fields {
+				if field.Name == name {
+					entity.Filters = append(entity.Filters, field)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return entity, errors.Errorf("no field named after filter %q", name)
+			}
+		}
+	}
+	return entity, nil
+}
+
+// Return the words of the given camel cased name, in lower case.
+func words(name string) string {
+	var buf strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			buf.WriteRune(' ')
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
@@ -0,0 +1,419 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/pkg/errors"
+)
+
+// Source renders the NodeTx methods of the given entities as the source of a
+// Go file in the given package.
+func Source(pkg string, entities []Entity) ([]byte, error) {
+	return render(sourceTemplate, pkg, entities)
+}
+
+// Test renders the tests of the NodeTx methods of the given entities as the
+// source of a Go file in the external test package of the given package.
+func Test(pkg string, entities []Entity) ([]byte, error) {
+	return render(testTemplate, pkg, entities)
+}
+
+func render(tmpl *template.Template, pkg string, entities []Entity) ([]byte, error) {
+	var views []entityView
+	for _, entity := range entities {
+		views = append(views, newEntityView(entity))
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Package  string
+		Entities []entityView
+	}{
+		Package:  pkg,
+		Entities: views,
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to render source")
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to format source")
+	}
+	return formatted, nil
+}
+
+// entityView is what the templates render an entity from.
+type entityView struct {
+	Entity
+	Singular string
+	Many     string
+	Dest     string
+	Criteria []criteriaView
+	Create   criteriaView
+}
+
+// criteriaView is what the templates render a statement selecting entities
+// from.
+type criteriaView struct {
+	Fields []Field
+	Stmt   string
+	Name   string
+	SQL    string
+}
+
+// Condition returns the Go expression checking that all the fields of the
+// criteria are set on the filter.
+func (c criteriaView) Condition() string {
+	var conditions []string
+	for _, field := range c.Fields {
+		conditions = append(conditions, fmt.Sprintf("filter.%s != %s", field.Name, zero(field.Type)))
+	}
+	return strings.Join(conditions, " && ")
+}
+
+// Args returns the Go expressions of the arguments of the statement.
+func (c criteriaView) Args(prefix string) string {
+	var args []string
+	for _, field := range c.Fields {
+		args = append(args, prefix+field.Name)
+	}
+	return strings.Join(args, ", ")
+}
+
+// Samples returns Go expressions of sample values for the fields of the
+// criteria.
+func (c criteriaView) Samples() string {
+	var samples []string
+	for _, field := range c.Fields {
+		samples = append(samples, sample(field.Type))
+	}
+	return strings.Join(samples, ", ")
+}
+
+// Filter returns a Go expression of a filter with sample values for the
+// fields of the criteria.
+func (c criteriaView) Filter() string {
+	var fields []string
+	for _, field := range c.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", field.Name, sample(field.Type)))
+	}
+	return strings.Join(fields, ", ")
+}
+
+// Columns returns the Go expression of the columns of the criteria.
+func (c criteriaView) Columns() string {
+	var columns []string
+	for _, field := range c.Fields {
+		columns = append(columns, strconv.Quote(field.Column))
+	}
+	return strings.Join(columns, ", ")
+}
+
+// Suffix returns the suffix of the names of the tests of the criteria.
+func (c criteriaView) Suffix() string {
+	var names []string
+	for _, field := range c.Fields {
+		names = append(names, field.Name)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "By" + strings.Join(names, "And")
+}
+
+func newEntityView(entity Entity) entityView {
+	view := entityView{
+		Entity:   entity,
+		Singular: words(entity.Name),
+		Many:     words(entity.Plural),
+		Create: criteriaView{
+			Fields: entity.Columns(),
+		},
+	}
+
+	// The identifiers are quoted, since some of them are keywords of some
+	// dialects.
+	var columns, dest []string
+	for _, field := range entity.Fields {
+		columns = append(columns, database.QuoteIdentifier(field.Column))
+		dest = append(dest, fmt.Sprintf("&objects[i].%s", field.Name))
+	}
+	view.Dest = strings.Join(dest, ", ")
+
+	// Every combination of filters gets a statement of its own, the ones with
+	// the most filters come first so that they're matched first.
+	for _, fields := range combinations(entity.Filters) {
+		c := criteriaView{
+			Fields: fields,
+			Stmt:   fmt.Sprintf("stmt%sObjects", entity.Name),
+		}
+		sql := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), database.QuoteIdentifier(entity.Table))
+		if len(fields) > 0 {
+			var where []string
+			for _, field := range fields {
+				where = append(where, fmt.Sprintf("%s = ?", database.QuoteIdentifier(field.Column)))
+			}
+			sql += " WHERE " + strings.Join(where, " AND ")
+			c.Stmt += c.Suffix()
+		}
+		c.Name = lowerFirst(strings.TrimPrefix(c.Stmt, "stmt"))
+		c.SQL = sql + fmt.Sprintf(" ORDER BY %s", database.QuoteIdentifier(entity.PK().Column))
+		view.Criteria = append(view.Criteria, c)
+	}
+	return view
+}
+
+// Return all the combinations of the given fields, from the largest to the
+// empty one.
+func combinations(fields []Field) [][]Field {
+	var result [][]Field
+	for size := len(fields); size >= 0; size-- {
+		result = append(result, combine(fields, size)...)
+	}
+	return result
+}
+
+func combine(fields []Field, size int) [][]Field {
+	if size == 0 {
+		return [][]Field{nil}
+	}
+	var result [][]Field
+	for i := range fields {
+		for _, rest := range combine(fields[i+1:], size-1) {
+			result = append(result, append([]Field{fields[i]}, rest...))
+		}
+	}
+	return result
+}
+
+// Return the given name with its first letter in lower case.
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// Return the Go expression of the zero value of the given type.
+func zero(typ string) string {
+	switch typ {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	default:
+		return "0"
+	}
+}
+
+// Return the Go expression of a sample value of the given type.
+func sample(typ string) string {
+	switch typ {
+	case "string":
+		return `"a"`
+	case "bool":
+		return "true"
+	default:
+		return fmt.Sprintf("%s(1)", typ)
+	}
+}
+
+var funcs = template.FuncMap{
+	"quote": strconv.Quote,
+	"sql":   quoteSQL,
+}
+
+// Return the Go string literal of the given statement, which is a raw one
+// unless the statement can't be written as such, for the quoted identifiers
+// to read as they are.
+func quoteSQL(sql string) string {
+	if strconv.CanBackquote(sql) {
+		return "`" + sql + "`"
+	}
+	return strconv.Quote(sql)
+}
+
+var sourceTemplate = template.Must(template.New("source").Funcs(funcs).Parse(`// Code generated by "bicycolet-generate". DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"github.com/pkg/errors"
+)
+{{ range $entity := .Entities }}
+{{- range .Criteria }}
+var {{ .Stmt }} = RegisterStmt({{ quote .Name }}, {{ sql .SQL }})
+{{- end }}
+
+// {{ .Name }}Filter holds the fields {{ .Many }} can be filtered by, the zero
+// ones are ignored.
+type {{ .Name }}Filter struct {
+{{- range .Filters }}
+	{{ .Name }} {{ .Type }}
+{{- end }}
+}
+
+// Get{{ .Plural }} returns the {{ .Many }} matching the given filter.
+func (n *NodeTx) Get{{ .Plural }}(filter {{ .Name }}Filter) ([]{{ .Name }}, error) {
+	var (
+		code int
+		args []interface{}
+	)
+	switch {
+{{- range .Criteria }}
+{{- if .Fields }}
+	case {{ .Condition }}:
+		code = {{ .Stmt }}
+		args = []interface{}{ {{- .Args "filter." -}} }
+{{- else }}
+	default:
+		code = {{ .Stmt }}
+{{- end }}
+{{- end }}
+	}
+
+	objects := make([]{{ .Name }}, 0)
+	dest := func(i int) []interface{} {
+		objects = append(objects, {{ .Name }}{})
+		return []interface{}{ {{- .Dest -}} }
+	}
+	stmt, err := n.stmt(code)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch {{ .Many }}")
+	}
+	if err := n.query.SelectObjectsStmt(n.ctx, stmt, dest, args...); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch {{ .Many }}")
+	}
+	return objects, nil
+}
+
+// Create{{ .Name }} adds a new {{ .Singular }}, returning its id.
+func (n *NodeTx) Create{{ .Name }}(object {{ .Name }}) (int64, error) {
+	columns := []string{ {{- .Create.Columns -}} }
+	values := []interface{}{ {{- .Create.Args "object." -}} }
+	id, err := n.query.UpsertObject(n.ctx, n.tx, {{ quote .Table }}, columns, values)
+	return id, errors.Wrap(err, "failed to create {{ .Singular }}")
+}
+
+// Delete{{ .Name }} removes the {{ .Singular }} with the given id, returning
+// whether it existed.
+func (n *NodeTx) Delete{{ .Name }}(id int64) (bool, error) {
+	deleted, err := n.query.DeleteObject(n.ctx, n.tx, {{ quote .Table }}, id)
+	return deleted, errors.Wrap(err, "failed to delete {{ .Singular }}")
+}
+{{ end }}`))
+
+var testTemplate = template.Must(template.New("test").Funcs(funcs).Parse(`// Code generated by "bicycolet-generate". DO NOT EDIT.
+
+package {{ .Package }}_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db"
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/mocks"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/golang/mock/gomock"
+)
+
+// Run the given function in a transaction against mocks, once the given query
+// expectations are set. The expectations are given the mocked prepared
+// statements, indexed by their SQL.
+func runGenerated(t *testing.T, expect func(*mocks.MockQuery, *mocks.MockTx, map[string]*mocks.MockStmt) *gomock.Call, f func(*db.NodeTx) error) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+	mockDB := mocks.NewMockDB(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+
+	stmts := make(map[string]*mocks.MockStmt)
+	gomock.InOrder(
+		mockNode.EXPECT().Open("/path/to/a/dir", database.ConnectionInfo{}).Return(nil),
+		mockNode.EXPECT().EnsureSchema(gomock.Any()).Return(1, nil),
+		mockNode.EXPECT().EnsurePatches(gomock.Any(), false).Return(nil, nil),
+		mockNode.EXPECT().DB().Return(mockDB),
+		mockDB.EXPECT().PrepareContext(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, query string) (database.Stmt, error) {
+			stmt := mocks.NewMockStmt(ctrl)
+			mockTx.EXPECT().StmtContext(gomock.Any(), stmt).Return(stmt).AnyTimes()
+			stmts[query] = stmt
+			return stmt, nil
+		}).Times(len(db.Stmts())),
+	)
+
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	if err := node.Open("/path/to/a/dir", database.ConnectionInfo{}, nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	gomock.InOrder(
+		mockNode.EXPECT().DB().Return(mockDB),
+		mockTransaction.EXPECT().Transaction(gomock.Any(), mockDB, gomock.Any()).DoAndReturn(func(ctx context.Context, db database.DB, fn func(database.Tx) error) error {
+			return fn(mockTx)
+		}),
+		expect(mockQuery, mockTx, stmts),
+	)
+
+	if err := node.Transaction(context.Background(), f); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
+{{ range $entity := .Entities }}
+{{- range .Criteria }}
+
+func TestGet{{ $entity.Plural }}{{ .Suffix }}(t *testing.T) {
+	expect := func(mockQuery *mocks.MockQuery, mockTx *mocks.MockTx, stmts map[string]*mocks.MockStmt) *gomock.Call {
+		return mockQuery.EXPECT().SelectObjectsStmt(gomock.Any(), stmts[{{ sql .SQL }}], gomock.Any(){{ if .Fields }}, {{ .Samples }}{{ end }}).DoAndReturn(func(ctx context.Context, stmt database.Stmt, dest query.Dest, args ...interface{}) error {
+			dest(0)
+			return nil
+		})
+	}
+	runGenerated(t, expect, func(tx *db.NodeTx) error {
+		objects, err := tx.Get{{ $entity.Plural }}(db.{{ $entity.Name }}Filter{ {{- .Filter -}} })
+		if expected, actual := 1, len(objects); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		return err
+	})
+}
+{{- end }}
+
+func TestCreate{{ .Name }}(t *testing.T) {
+	expect := func(mockQuery *mocks.MockQuery, mockTx *mocks.MockTx, stmts map[string]*mocks.MockStmt) *gomock.Call {
+		columns := []string{ {{- .Create.Columns -}} }
+		values := []interface{}{ {{- .Create.Samples -}} }
+		return mockQuery.EXPECT().UpsertObject(gomock.Any(), mockTx, {{ quote .Table }}, columns, values).Return(int64(1), nil)
+	}
+	runGenerated(t, expect, func(tx *db.NodeTx) error {
+		id, err := tx.Create{{ .Name }}(db.{{ .Name }}{ {{- .Create.Filter -}} })
+		if expected, actual := int64(1), id; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		return err
+	})
+}
+
+func TestDelete{{ .Name }}(t *testing.T) {
+	expect := func(mockQuery *mocks.MockQuery, mockTx *mocks.MockTx, stmts map[string]*mocks.MockStmt) *gomock.Call {
+		return mockQuery.EXPECT().DeleteObject(gomock.Any(), mockTx, {{ quote .Table }}, int64(1)).Return(true, nil)
+	}
+	runGenerated(t, expect, func(tx *db.NodeTx) error {
+		deleted, err := tx.Delete{{ .Name }}(1)
+		if !deleted {
+			t.Errorf("expected deleted to be true")
+		}
+		return err
+	})
+}
+{{- end }}
+`))
@@ -0,0 +1,180 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bicycolet/bicycolet/internal/db (interfaces: QueryNode,Transaction)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	database "github.com/bicycolet/bicycolet/internal/db/database"
+	node "github.com/bicycolet/bicycolet/internal/db/node"
+	schema "github.com/bicycolet/bicycolet/internal/db/schema"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockQueryNode is a mock of QueryNode interface
+type MockQueryNode struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueryNodeMockRecorder
+}
+
+// MockQueryNodeMockRecorder is the mock recorder for MockQueryNode
+type MockQueryNodeMockRecorder struct {
+	mock *MockQueryNode
+}
+
+// NewMockQueryNode creates a new mock instance
+func NewMockQueryNode(ctrl *gomock.Controller) *MockQueryNode {
+	mock := &MockQueryNode{ctrl: ctrl}
+	mock.recorder = &MockQueryNodeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockQueryNode) EXPECT() *MockQueryNodeMockRecorder {
+	return m.recorder
+}
+
+// AcceptChecksums mocks base method
+func (m *MockQueryNode) AcceptChecksums(arg0 bool) {
+	m.ctrl.Call(m, "AcceptChecksums", arg0)
+}
+
+// AcceptChecksums indicates an expected call of AcceptChecksums
+func (mr *MockQueryNodeMockRecorder) AcceptChecksums(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptChecksums", reflect.TypeOf((*MockQueryNode)(nil).AcceptChecksums), arg0)
+}
+
+// AppVersion mocks base method
+func (m *MockQueryNode) AppVersion(arg0 string) {
+	m.ctrl.Call(m, "AppVersion", arg0)
+}
+
+// AppVersion indicates an expected call of AppVersion
+func (mr *MockQueryNodeMockRecorder) AppVersion(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppVersion", reflect.TypeOf((*MockQueryNode)(nil).AppVersion), arg0)
+}
+
+// Backup mocks base method
+func (m *MockQueryNode) Backup(arg0 context.Context) (int, error) {
+	ret := m.ctrl.Call(m, "Backup", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backup indicates an expected call of Backup
+func (mr *MockQueryNodeMockRecorder) Backup(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backup", reflect.TypeOf((*MockQueryNode)(nil).Backup), arg0)
+}
+
+// Close mocks base method
+func (m *MockQueryNode) Close() error {
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close
+func (mr *MockQueryNodeMockRecorder) Close() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockQueryNode)(nil).Close))
+}
+
+// DB mocks base method
+func (m *MockQueryNode) DB() database.DB {
+	ret := m.ctrl.Call(m, "DB")
+	ret0, _ := ret[0].(database.DB)
+	return ret0
+}
+
+// DB indicates an expected call of DB
+func (mr *MockQueryNodeMockRecorder) DB() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DB", reflect.TypeOf((*MockQueryNode)(nil).DB))
+}
+
+// EnsurePatches mocks base method
+func (m *MockQueryNode) EnsurePatches(arg0 context.Context, arg1 bool) ([]string, error) {
+	ret := m.ctrl.Call(m, "EnsurePatches", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnsurePatches indicates an expected call of EnsurePatches
+func (mr *MockQueryNodeMockRecorder) EnsurePatches(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsurePatches", reflect.TypeOf((*MockQueryNode)(nil).EnsurePatches), arg0, arg1)
+}
+
+// EnsureSchema mocks base method
+func (m *MockQueryNode) EnsureSchema(arg0 schema.Hook) (int, error) {
+	ret := m.ctrl.Call(m, "EnsureSchema", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnsureSchema indicates an expected call of EnsureSchema
+func (mr *MockQueryNodeMockRecorder) EnsureSchema(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureSchema", reflect.TypeOf((*MockQueryNode)(nil).EnsureSchema), arg0)
+}
+
+// Open mocks base method
+func (m *MockQueryNode) Open(arg0 string, arg1 database.ConnectionInfo) error {
+	ret := m.ctrl.Call(m, "Open", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Open indicates an expected call of Open
+func (mr *MockQueryNodeMockRecorder) Open(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockQueryNode)(nil).Open), arg0, arg1)
+}
+
+// Patches mocks base method
+func (m *MockQueryNode) Patches(arg0 context.Context) ([]node.PatchStatus, error) {
+	ret := m.ctrl.Call(m, "Patches", arg0)
+	ret0, _ := ret[0].([]node.PatchStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patches indicates an expected call of Patches
+func (mr *MockQueryNodeMockRecorder) Patches(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patches", reflect.TypeOf((*MockQueryNode)(nil).Patches), arg0)
+}
+
+// MockTransaction is a mock of Transaction interface
+type MockTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionMockRecorder
+}
+
+// MockTransactionMockRecorder is the mock recorder for MockTransaction
+type MockTransactionMockRecorder struct {
+	mock *MockTransaction
+}
+
+// NewMockTransaction creates a new mock instance
+func NewMockTransaction(ctrl *gomock.Controller) *MockTransaction {
+	mock := &MockTransaction{ctrl: ctrl}
+	mock.recorder = &MockTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTransaction) EXPECT() *MockTransactionMockRecorder {
+	return m.recorder
+}
+
+// Transaction mocks base method
+func (m *MockTransaction) Transaction(arg0 context.Context, arg1 database.DB, arg2 func(database.Tx) error) error {
+	ret := m.ctrl.Call(m, "Transaction", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction
+func (mr *MockTransactionMockRecorder) Transaction(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockTransaction)(nil).Transaction), arg0, arg1, arg2)
+}
@@ -0,0 +1,162 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/bicycolet/bicycolet/internal/db (interfaces: Query)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	database "github.com/bicycolet/bicycolet/internal/db/database"
+	query "github.com/bicycolet/bicycolet/internal/db/query"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockQuery is a mock of Query interface
+type MockQuery struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueryMockRecorder
+}
+
+// MockQueryMockRecorder is the mock recorder for MockQuery
+type MockQueryMockRecorder struct {
+	mock *MockQuery
+}
+
+// NewMockQuery creates a new mock instance
+func NewMockQuery(ctrl *gomock.Controller) *MockQuery {
+	mock := &MockQuery{ctrl: ctrl}
+	mock.recorder = &MockQueryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockQuery) EXPECT() *MockQueryMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method
+func (m *MockQuery) Count(arg0 context.Context, arg1 database.Tx, arg2 string, arg3 ...query.Condition) (int, error) {
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Count", varargs...)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count
+func (mr *MockQueryMockRecorder) Count(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockQuery)(nil).Count), varargs...)
+}
+
+// DeleteObject mocks base method
+func (m *MockQuery) DeleteObject(arg0 context.Context, arg1 database.Tx, arg2 string, arg3 int64) (bool, error) {
+	ret := m.ctrl.Call(m, "DeleteObject", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteObject indicates an expected call of DeleteObject
+func (mr *MockQueryMockRecorder) DeleteObject(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObject", reflect.TypeOf((*MockQuery)(nil).DeleteObject), arg0, arg1, arg2, arg3)
+}
+
+// SelectConfig mocks base method
+func (m *MockQuery) SelectConfig(arg0 context.Context, arg1 database.Tx, arg2 string, arg3 ...query.Condition) (map[string]string, error) {
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SelectConfig", varargs...)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectConfig indicates an expected call of SelectConfig
+func (mr *MockQueryMockRecorder) SelectConfig(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectConfig", reflect.TypeOf((*MockQuery)(nil).SelectConfig), varargs...)
+}
+
+// SelectObjects mocks base method
+func (m *MockQuery) SelectObjects(arg0 context.Context, arg1 database.Tx, arg2 query.Dest, arg3 string, arg4 ...interface{}) error {
+	varargs := []interface{}{arg0, arg1, arg2, arg3}
+	for _, a := range arg4 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SelectObjects", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SelectObjects indicates an expected call of SelectObjects
+func (mr *MockQueryMockRecorder) SelectObjects(arg0, arg1, arg2, arg3 interface{}, arg4 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1, arg2, arg3}, arg4...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectObjects", reflect.TypeOf((*MockQuery)(nil).SelectObjects), varargs...)
+}
+
+// SelectObjectsStmt mocks base method
+func (m *MockQuery) SelectObjectsStmt(arg0 context.Context, arg1 database.Stmt, arg2 query.Dest, arg3 ...interface{}) error {
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SelectObjectsStmt", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SelectObjectsStmt indicates an expected call of SelectObjectsStmt
+func (mr *MockQueryMockRecorder) SelectObjectsStmt(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectObjectsStmt", reflect.TypeOf((*MockQuery)(nil).SelectObjectsStmt), varargs...)
+}
+
+// SelectStrings mocks base method
+func (m *MockQuery) SelectStrings(arg0 context.Context, arg1 database.Tx, arg2 string, arg3 ...interface{}) ([]string, error) {
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SelectStrings", varargs...)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectStrings indicates an expected call of SelectStrings
+func (mr *MockQueryMockRecorder) SelectStrings(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectStrings", reflect.TypeOf((*MockQuery)(nil).SelectStrings), varargs...)
+}
+
+// UpdateConfig mocks base method
+func (m *MockQuery) UpdateConfig(arg0 context.Context, arg1 database.Tx, arg2 string, arg3 map[string]string) error {
+	ret := m.ctrl.Call(m, "UpdateConfig", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateConfig indicates an expected call of UpdateConfig
+func (mr *MockQueryMockRecorder) UpdateConfig(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfig", reflect.TypeOf((*MockQuery)(nil).UpdateConfig), arg0, arg1, arg2, arg3)
+}
+
+// UpsertObject mocks base method
+func (m *MockQuery) UpsertObject(arg0 context.Context, arg1 database.Tx, arg2 string, arg3 []string, arg4 []interface{}) (int64, error) {
+	ret := m.ctrl.Call(m, "UpsertObject", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertObject indicates an expected call of UpsertObject
+func (mr *MockQueryMockRecorder) UpsertObject(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertObject", reflect.TypeOf((*MockQuery)(nil).UpsertObject), arg0, arg1, arg2, arg3, arg4)
+}
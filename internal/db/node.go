@@ -1,8 +1,13 @@
 package db
 
 import (
+	"context"
+
 	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/node"
 	"github.com/bicycolet/bicycolet/internal/db/schema"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
 )
 
 // NodeTransactioner represents a way to run transaction on the node
@@ -12,14 +17,16 @@ type NodeTransactioner interface {
 	// node-level database interactions invoked by the given function. If the
 	// function returns no error, all database changes are committed to the
 	// node-level database, otherwise they are rolled back.
-	Transaction(f func(*NodeTx) error) error
+	//
+	// The queries of the transaction are aborted once the context is done.
+	Transaction(ctx context.Context, f func(*NodeTx) error) error
 }
 
 // QueryNode represents a local node in a cluster
 type QueryNode interface {
 
 	// Open the node-local database object.
-	Open(string) error
+	Open(string, database.ConnectionInfo) error
 
 	// EnsureSchema applies all relevant schema updates to the node-local
 	// database.
@@ -28,11 +35,39 @@ type QueryNode interface {
 	// with any error occurred.
 	EnsureSchema(hookFn schema.Hook) (int, error)
 
+	// AppVersion sets the version of the application, which is recorded along
+	// with the schema updates it applies.
+	AppVersion(string)
+
+	// AcceptChecksums instructs EnsureSchema to accept the schema updates that
+	// have changed since they were applied, instead of failing.
+	AcceptChecksums(bool)
+
+	// EnsurePatches runs the patches of the node-local database that weren't
+	// run yet, either the ones to run in background or the other ones.
+	//
+	// Return the names of the patches that were run, along with any error
+	// occurred.
+	EnsurePatches(ctx context.Context, background bool) ([]string, error)
+
+	// Patches returns the patches of the node-local database in the order
+	// they're run, along with when they were.
+	Patches(ctx context.Context) ([]node.PatchStatus, error)
+
+	// Backup dumps the node-local database into a new backup, named after the
+	// schema version it's at.
+	//
+	// Return the version of the backup, along with any error occurred.
+	Backup(ctx context.Context) (int, error)
+
 	// DB return the current database source.
 	DB() database.DB
+
+	// Close the node-local database object.
+	Close() error
 }
 
-type nodeTxBuilder func(database.Tx) *NodeTx
+type nodeTxBuilder func(context.Context, database.Tx) *NodeTx
 
 // Node mediates access to the data stored in the node-local SQLite database.
 type Node struct {
@@ -40,19 +75,125 @@ type Node struct {
 	node        QueryNode
 	dir         string // Reference to the directory where the database file lives.
 	builder     nodeTxBuilder
+	stmts       map[int]database.Stmt // Registered statements, prepared against the database.
+}
+
+// NewNode creates a new Node object, whose database is customized by the
+// given options once it's opened.
+func NewNode(fileSystem fsys.FileSystem, options ...database.Option) *Node {
+	return &Node{
+		node:        node.New(fileSystem, options...),
+		transaction: transactionShim{},
+		builder:     NewNodeTx,
+	}
+}
+
+// AppVersion sets the version of the application, which is recorded along
+// with the schema updates applied when opening the database.
+func (n *Node) AppVersion(version string) {
+	n.node.AppVersion(version)
+}
+
+// AcceptChecksums instructs Open to accept the schema updates that have
+// changed since they were applied, instead of failing with
+// schema.ErrChecksumMismatch.
+func (n *Node) AcceptChecksums(accept bool) {
+	n.node.AcceptChecksums(accept)
+}
+
+// Open the node-local database, ensure that the schema is up to date, run
+// the pending patches that aren't meant to run in background and prepare all
+// the registered statements against it.
+//
+// The fresh hook parameter is used by the daemon to perform any additional
+// work when a brand new database is created.
+func (n *Node) Open(dir string, connectionInfo database.ConnectionInfo, fresh func(*Node) error) error {
+	if err := n.node.Open(dir, connectionInfo); err != nil {
+		return errors.WithStack(err)
+	}
+
+	initial, err := n.node.EnsureSchema(nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := n.node.EnsurePatches(context.Background(), false); err != nil {
+		return errors.WithStack(err)
+	}
+
+	stmts, err := PrepareStmts(context.Background(), n.node.DB())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	n.stmts = stmts
+
+	n.dir = dir
+
+	if initial == 0 && fresh != nil {
+		if err := fresh(n); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// EnsureBackgroundPatches runs the pending patches of the node-local database
+// that are meant to run in background, once the database is opened. It stops
+// short once the given context is done.
+//
+// Return the names of the patches that were run, along with any error
+// occurred.
+func (n *Node) EnsureBackgroundPatches(ctx context.Context) ([]string, error) {
+	applied, err := n.node.EnsurePatches(ctx, true)
+	return applied, errors.WithStack(err)
+}
+
+// Patches returns the patches of the node-local database in the order they're
+// run, along with when they were.
+func (n *Node) Patches(ctx context.Context) ([]node.PatchStatus, error) {
+	statuses, err := n.node.Patches(ctx)
+	return statuses, errors.WithStack(err)
+}
+
+// Backup dumps the node-local database into a new backup, named after the
+// schema version it's at, which can be restored once the daemon is stopped.
+//
+// Return the version of the backup, along with any error occurred.
+func (n *Node) Backup(ctx context.Context) (int, error) {
+	version, err := n.node.Backup(ctx)
+	return version, errors.WithStack(err)
+}
+
+// Dir returns the directory of the underlying database file.
+func (n *Node) Dir() string {
+	return n.dir
 }
 
 // Transaction creates a new NodeTx object and transactionally executes the
 // node-level database interactions invoked by the given function. If the
 // function returns no error, all database changes are committed to the
 // node-level database, otherwise they are rolled back.
-func (n *Node) Transaction(f func(*NodeTx) error) error {
-	return n.transaction.Transaction(n.node.DB(), func(tx database.Tx) error {
-		return f(n.builder(tx))
+//
+// The queries of the transaction are aborted once the context is done.
+func (n *Node) Transaction(ctx context.Context, f func(*NodeTx) error) error {
+	return n.transaction.Transaction(ctx, n.node.DB(), func(tx database.Tx) error {
+		nodeTx := n.builder(ctx, tx)
+		nodeTx.stmts = n.stmts
+		return f(nodeTx)
 	})
 }
 
-// Close the database facade.
+// Close the database facade, along with the prepared statements.
 func (n *Node) Close() error {
-	return n.node.DB().Close()
+	err := closeStmts(n.stmts)
+	n.stmts = nil
+	if closeErr := n.node.Close(); err == nil {
+		err = closeErr
+	}
+	return errors.WithStack(err)
+}
+
+// DB returns the low level database handle to the node-local database.
+func (n *Node) DB() database.DB {
+	return n.node.DB()
 }
@@ -0,0 +1,161 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/db/schema"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+// BackupRetention is the number of database backups that are kept, once the
+// updates following a new backup are applied the oldest ones past this number
+// are removed.
+const BackupRetention = 3
+
+const backupPrefix = "backup."
+
+// BackupPath returns the path of the database backup taken before updating
+// the schema from the given version.
+func BackupPath(dir string, version int) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%d", backupPrefix, version))
+}
+
+// Backups returns the schema versions of the database backups found in the
+// node database directory, in increasing order.
+func (n *Node) Backups() ([]int, error) {
+	return backups(n.fileSystem, n.databasePath)
+}
+
+// Backup dumps the node database into a new backup, named after the schema
+// version it's at, and removes the backups that are past the retention.
+//
+// Return the version of the backup, along with any error occurred.
+func (n *Node) Backup(ctx context.Context) (int, error) {
+	var version int
+	err := query.Transaction(ctx, n.database, func(tx database.Tx) error {
+		versions, err := query.SelectIntegers(ctx, tx, schema.StmtSelectSchemaVersions)
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch schema version")
+		}
+		if len(versions) == 0 {
+			return errors.Errorf("database has no schema")
+		}
+		version = versions[len(versions)-1]
+		return backup(n.fileSystem, n.databasePath, version, tx)
+	})
+	if err != nil {
+		return -1, errors.Wrap(err, "failed to backup database")
+	}
+
+	err = pruneBackups(n.fileSystem, n.databasePath)
+	return version, errors.WithStack(err)
+}
+
+// Restore replaces the contents of the node database with the backup taken
+// before updating the schema from the given version.
+func (n *Node) Restore(ctx context.Context, version int) error {
+	path := BackupPath(n.databasePath, version)
+	if !n.fileSystem.Exists(path) {
+		return errors.Errorf("no backup found for version %d", version)
+	}
+
+	file, err := n.fileSystem.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open backup")
+	}
+	defer file.Close()
+
+	dump, err := ioutil.ReadAll(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to read backup")
+	}
+
+	err = query.Transaction(ctx, n.database, func(tx database.Tx) error {
+		return query.Restore(ctx, tx, string(dump))
+	})
+	return errors.Wrapf(err, "failed to restore backup %q", path)
+}
+
+// Dump the database into a new backup file for the given version.
+func backup(fileSystem fsys.FileSystem, dir string, version int, tx database.Tx) error {
+	dump, err := query.Dump(context.Background(), tx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	file, err := fileSystem.Create(BackupPath(dir, version))
+	if err != nil {
+		return errors.Wrap(err, "failed to create backup file")
+	}
+	if _, err := file.Write([]byte(dump)); err != nil {
+		file.Close()
+		return errors.Wrap(err, "failed to write backup file")
+	}
+	err = file.Close()
+	return errors.Wrap(err, "failed to close backup file")
+}
+
+// Remove the backups in the given directory that are past the retention,
+// oldest first. It's only done once the updates following a new backup are
+// committed, so that the backups are left alone if they fail.
+func pruneBackups(fileSystem fsys.FileSystem, dir string) error {
+	versions, err := backups(fileSystem, dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for len(versions) > BackupRetention {
+		if err := fileSystem.Remove(BackupPath(dir, versions[0])); err != nil {
+			return errors.Wrap(err, "failed to remove old backup")
+		}
+		versions = versions[1:]
+	}
+	return nil
+}
+
+// Return the versions of all the backups in the given directory, in
+// increasing order.
+func backups(fileSystem fsys.FileSystem, dir string) ([]int, error) {
+	dir = filepath.Clean(dir)
+
+	var versions []int
+	err := fileSystem.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Dir(path) != dir {
+			return nil
+		}
+
+		name := filepath.Base(path)
+		if !strings.HasPrefix(name, backupPrefix) {
+			return nil
+		}
+		version, err := strconv.Atoi(strings.TrimPrefix(name, backupPrefix))
+		if err != nil {
+			return nil
+		}
+		versions = append(versions, version)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list backups")
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
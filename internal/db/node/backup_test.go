@@ -0,0 +1,233 @@
+package node_test
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+func TestHookTakesBackup(t *testing.T) {
+	fs := fsys.NewVirtualFileSystem()
+	db := newScratchDB(t)
+	defer db.Close()
+
+	ctx := &node.Context{}
+	for _, version := range []int{1, 2} {
+		err := query.Transaction(context.Background(), db, func(tx database.Tx) error {
+			return node.Hook(ctx, fs, nil, "/path/to/a/dir", version, tx)
+		})
+		if err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+	}
+
+	// Only the first update of a run is backed up.
+	if expected, actual := true, fs.Exists("/path/to/a/dir/backup.1"); expected != actual {
+		t.Errorf("expected: %t, actual: %t", expected, actual)
+	}
+	if expected, actual := false, fs.Exists("/path/to/a/dir/backup.2"); expected != actual {
+		t.Errorf("expected: %t, actual: %t", expected, actual)
+	}
+
+	file, err := fs.Open("/path/to/a/dir/backup.1")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer file.Close()
+	dump, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := `INSERT INTO "test" VALUES(1, 'foo');`, string(dump); !strings.Contains(actual, expected) {
+		t.Errorf("expected: %q to contain %q", actual, expected)
+	}
+}
+
+// Old backups are only removed once the updates are applied, so that they're
+// left alone if the updates fail.
+func TestEnsureSchemaRemovesOldBackups(t *testing.T) {
+	fs := fsys.NewVirtualFileSystem()
+	n := node.New(fs)
+	if err := n.Open("/path/to/a/dir", database.ConnectionInfo{
+		Driver: database.SQLite,
+		Memory: true,
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer n.Close()
+
+	if _, err := n.EnsureSchema(nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if _, err := n.RollbackSchema(1); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	for _, version := range []int{0, 3} {
+		writeFile(t, fs, node.BackupPath("/path/to/a/dir", version), "")
+	}
+
+	_, err := n.EnsureSchema(func(int, database.Tx) error {
+		return errors.New("boom")
+	})
+	if err == nil {
+		t.Fatalf("expected err not to be nil")
+	}
+	backups, err := n.Backups()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []int{0, 1, 2, 3}, backups; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	if _, err := n.EnsureSchema(nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	backups, err = n.Backups()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []int{1, 2, 3}, backups; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	fs := fsys.NewVirtualFileSystem()
+	n := node.New(fs)
+	if err := n.Open("/path/to/a/dir", database.ConnectionInfo{
+		Driver: database.SQLite,
+		Memory: true,
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer n.Close()
+
+	if _, err := n.EnsureSchema(nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	version, err := n.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	updates := len(node.NewSchemaProviderWithMocks(nil).Updates())
+	if expected, actual := updates, version; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	backups, err := n.Backups()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []int{version}, backups; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	// The backup restores the database as it was.
+	if err := n.Restore(context.Background(), version); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	drifts, err := n.VerifySchema()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 0, len(drifts); expected != actual {
+		t.Errorf("expected: %d, actual: %d (%v)", expected, actual, drifts)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	fs := fsys.NewVirtualFileSystem()
+	db := newScratchDB(t)
+	defer db.Close()
+
+	err := query.Transaction(context.Background(), db, func(tx database.Tx) error {
+		return node.Hook(&node.Context{}, fs, nil, "/path/to/a/dir", 1, tx)
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	err = query.Transaction(context.Background(), db, func(tx database.Tx) error {
+		if _, err := tx.Exec("INSERT INTO test VALUES(2, 'bar')"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("CREATE TABLE other (id INTEGER)")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	if err := node.NewNodeWithDB(db, fs, "/path/to/a/dir").Restore(context.Background(), 1); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	var tables, names []string
+	err = query.Transaction(context.Background(), db, func(tx database.Tx) error {
+		var err error
+		if tables, err = query.SelectStrings(context.Background(), tx, sqlite.StmtTableNames()); err != nil {
+			return err
+		}
+		names, err = query.SelectStrings(context.Background(), tx, "SELECT name FROM test ORDER BY id")
+		return err
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []string{"test"}, tables; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []string{"foo"}, names; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestRestoreWithMissingBackup(t *testing.T) {
+	fs := fsys.NewVirtualFileSystem()
+	db := newScratchDB(t)
+	defer db.Close()
+
+	if err := node.NewNodeWithDB(db, fs, "/path/to/a/dir").Restore(context.Background(), 1); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+// Return a new in-memory sqlite database, with a test table holding one row.
+func newScratchDB(t *testing.T) database.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("CREATE TABLE test (id INTEGER, name TEXT)"); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO test VALUES(1, 'foo')"); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	return database.NewShimDB(db, sqlite)
+}
+
+func writeFile(t *testing.T, fileSystem fsys.FileSystem, path, content string) {
+	file, err := fileSystem.Create(path)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write([]byte(content)); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package node
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/schema"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+// FreshSchema returns the statement that's used to create the node-local
+// database schema from scratch.
+func FreshSchema() string {
+	return freshSchema
+}
+
+// DumpSchema applies all the schema updates to a scratch in-memory database
+// and returns the dump of the resulting schema. The dump is what the fresh
+// schema is expected to be.
+func DumpSchema(fileSystem fsys.FileSystem) (string, error) {
+	dialect, err := database.NewDialect(database.SQLite)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	db, err := sql.Open(dialect.DriverName(), ":memory:")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open scratch database")
+	}
+	defer db.Close()
+
+	// Every connection to an in-memory database is a new database, so make
+	// sure that only one is ever used.
+	db.SetMaxOpenConns(1)
+
+	provider := schemaProvider{
+		fileSystem: fileSystem,
+	}
+	s := schema.New(fileSystem, provider.Updates())
+	shim := database.NewShimDB(db, dialect)
+	if _, err := s.Ensure(shim); err != nil {
+		return "", errors.Wrap(err, "failed to apply schema updates")
+	}
+	dump, err := s.Dump(shim)
+	return dump, errors.Wrap(err, "failed to dump schema")
+}
+
+// CheckFreshSchema verifies that the fresh schema matches the dump of all
+// the schema updates, so that a fresh database and an updated one can't
+// diverge.
+func CheckFreshSchema(fileSystem fsys.FileSystem) error {
+	dump, err := DumpSchema(fileSystem)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if strings.TrimSpace(dump) != strings.TrimSpace(freshSchema) {
+		return errors.Errorf("fresh schema doesn't match the schema updates, regenerate schema.go using \"bicycolet db schema dump --write\"")
+	}
+	return nil
+}
+
+// SchemaDotGo renders the given schema dump as the source of schema.go.
+func SchemaDotGo(dump string) ([]byte, error) {
+	return schema.DotGo(dump, "node", "freshSchema")
+}
@@ -0,0 +1,37 @@
+package node_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+)
+
+var update = flag.Bool("update", false, "rewrite schema.go from the schema updates")
+
+// The fresh schema must always match the flattening of all the schema
+// updates. Run the tests with -update to rewrite schema.go.
+func TestFreshSchema(t *testing.T) {
+	fs := fsys.NewVirtualFileSystem()
+
+	if *update {
+		dump, err := node.DumpSchema(fs)
+		if err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+		source, err := node.SchemaDotGo(dump)
+		if err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+		if err := ioutil.WriteFile("schema.go", source, 0644); err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+		t.Skip("rewrote schema.go, run the tests again to verify it")
+	}
+
+	if err := node.CheckFreshSchema(fs); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
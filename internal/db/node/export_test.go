@@ -1,13 +1,16 @@
 package node
 
-import "github.com/bicycolet/bicycolet/internal/fsys"
+import (
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+)
 
 // Rexport the hook and check functions for testing.
 var (
 	Hook = hook
 )
 
-type Context = context
+type Context = hookContext
 
 func NewNodeWithMocks(databaseIO DatabaseIO,
 	schemaProvider SchemaProvider,
@@ -17,6 +20,7 @@ func NewNodeWithMocks(databaseIO DatabaseIO,
 		databaseIO:     databaseIO,
 		schemaProvider: schemaProvider,
 		fileSystem:     fileSystem,
+		openTimeout:    defaultOpenTimeout,
 	}
 }
 
@@ -25,3 +29,31 @@ func NewSchemaProviderWithMocks(fileSystem fsys.FileSystem) SchemaProvider {
 		fileSystem: fileSystem,
 	}
 }
+
+func NewNodeWithDB(db database.DB, fileSystem fsys.FileSystem, path string) *Node {
+	return &Node{
+		database:     db,
+		databasePath: path,
+		fileSystem:   fileSystem,
+	}
+}
+
+// NewNodeWithPatches creates a node whose schema provider runs the given
+// patches instead of the actual ones.
+func NewNodeWithPatches(fileSystem fsys.FileSystem, patches []Patch) *Node {
+	n := New(fileSystem)
+	n.schemaProvider = patchesProvider{
+		schemaProvider: schemaProvider{fileSystem: fileSystem},
+		patches:        patches,
+	}
+	return n
+}
+
+type patchesProvider struct {
+	schemaProvider
+	patches []Patch
+}
+
+func (p patchesProvider) Patches() []Patch {
+	return p.patches
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/bicycolet/bicycolet/internal/fsys (interfaces: FileSystem)
+// Source: github.com/bicycolet/bicycolet/internal/fsys (interfaces: FileSystem,Releaser)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -7,7 +7,7 @@ package mocks
 import (
 	fsys "github.com/bicycolet/bicycolet/internal/fsys"
 	gomock "github.com/golang/mock/gomock"
-	os "os"
+	fs "io/fs"
 	filepath "path/filepath"
 	reflect "reflect"
 	time "time"
@@ -112,7 +112,7 @@ func (mr *MockFileSystemMockRecorder) Lock(arg0 interface{}) *gomock.Call {
 }
 
 // Mkdir mocks base method
-func (m *MockFileSystem) Mkdir(arg0 string, arg1 os.FileMode) error {
+func (m *MockFileSystem) Mkdir(arg0 string, arg1 fs.FileMode) error {
 	ret := m.ctrl.Call(m, "Mkdir", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
@@ -124,7 +124,7 @@ func (mr *MockFileSystemMockRecorder) Mkdir(arg0, arg1 interface{}) *gomock.Call
 }
 
 // MkdirAll mocks base method
-func (m *MockFileSystem) MkdirAll(arg0 string, arg1 os.FileMode) error {
+func (m *MockFileSystem) MkdirAll(arg0 string, arg1 fs.FileMode) error {
 	ret := m.ctrl.Call(m, "MkdirAll", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
@@ -149,7 +149,7 @@ func (mr *MockFileSystemMockRecorder) Open(arg0 interface{}) *gomock.Call {
 }
 
 // OpenFile mocks base method
-func (m *MockFileSystem) OpenFile(arg0 string, arg1 int, arg2 os.FileMode) (fsys.File, error) {
+func (m *MockFileSystem) OpenFile(arg0 string, arg1 int, arg2 fs.FileMode) (fsys.File, error) {
 	ret := m.ctrl.Call(m, "OpenFile", arg0, arg1, arg2)
 	ret0, _ := ret[0].(fsys.File)
 	ret1, _ := ret[1].(error)
@@ -220,3 +220,38 @@ func (m *MockFileSystem) Walk(arg0 string, arg1 filepath.WalkFunc) error {
 func (mr *MockFileSystemMockRecorder) Walk(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Walk", reflect.TypeOf((*MockFileSystem)(nil).Walk), arg0, arg1)
 }
+
+// MockReleaser is a mock of Releaser interface
+type MockReleaser struct {
+	ctrl     *gomock.Controller
+	recorder *MockReleaserMockRecorder
+}
+
+// MockReleaserMockRecorder is the mock recorder for MockReleaser
+type MockReleaserMockRecorder struct {
+	mock *MockReleaser
+}
+
+// NewMockReleaser creates a new mock instance
+func NewMockReleaser(ctrl *gomock.Controller) *MockReleaser {
+	mock := &MockReleaser{ctrl: ctrl}
+	mock.recorder = &MockReleaserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockReleaser) EXPECT() *MockReleaserMockRecorder {
+	return m.recorder
+}
+
+// Release mocks base method
+func (m *MockReleaser) Release() error {
+	ret := m.ctrl.Call(m, "Release")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release
+func (mr *MockReleaserMockRecorder) Release() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockReleaser)(nil).Release))
+}
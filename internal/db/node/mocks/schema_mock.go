@@ -35,6 +35,26 @@ func (m *MockSchema) EXPECT() *MockSchemaMockRecorder {
 	return m.recorder
 }
 
+// AcceptChecksums mocks base method
+func (m *MockSchema) AcceptChecksums(arg0 bool) {
+	m.ctrl.Call(m, "AcceptChecksums", arg0)
+}
+
+// AcceptChecksums indicates an expected call of AcceptChecksums
+func (mr *MockSchemaMockRecorder) AcceptChecksums(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptChecksums", reflect.TypeOf((*MockSchema)(nil).AcceptChecksums), arg0)
+}
+
+// AppVersion mocks base method
+func (m *MockSchema) AppVersion(arg0 string) {
+	m.ctrl.Call(m, "AppVersion", arg0)
+}
+
+// AppVersion indicates an expected call of AppVersion
+func (mr *MockSchemaMockRecorder) AppVersion(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppVersion", reflect.TypeOf((*MockSchema)(nil).AppVersion), arg0)
+}
+
 // Ensure mocks base method
 func (m *MockSchema) Ensure(arg0 database.DB) (int, error) {
 	ret := m.ctrl.Call(m, "Ensure", arg0)
@@ -58,6 +78,16 @@ func (mr *MockSchemaMockRecorder) File(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "File", reflect.TypeOf((*MockSchema)(nil).File), arg0)
 }
 
+// Fresh mocks base method
+func (m *MockSchema) Fresh(arg0 string) {
+	m.ctrl.Call(m, "Fresh", arg0)
+}
+
+// Fresh indicates an expected call of Fresh
+func (mr *MockSchemaMockRecorder) Fresh(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fresh", reflect.TypeOf((*MockSchema)(nil).Fresh), arg0)
+}
+
 // Hook mocks base method
 func (m *MockSchema) Hook(arg0 schema.Hook) {
 	m.ctrl.Call(m, "Hook", arg0)
@@ -68,6 +98,45 @@ func (mr *MockSchemaMockRecorder) Hook(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hook", reflect.TypeOf((*MockSchema)(nil).Hook), arg0)
 }
 
+// Plan mocks base method
+func (m *MockSchema) Plan(arg0 database.DB) (schema.Plan, error) {
+	ret := m.ctrl.Call(m, "Plan", arg0)
+	ret0, _ := ret[0].(schema.Plan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Plan indicates an expected call of Plan
+func (mr *MockSchemaMockRecorder) Plan(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Plan", reflect.TypeOf((*MockSchema)(nil).Plan), arg0)
+}
+
+// Rollback mocks base method
+func (m *MockSchema) Rollback(arg0 database.DB, arg1 int) (int, error) {
+	ret := m.ctrl.Call(m, "Rollback", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rollback indicates an expected call of Rollback
+func (mr *MockSchemaMockRecorder) Rollback(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockSchema)(nil).Rollback), arg0, arg1)
+}
+
+// Verify mocks base method
+func (m *MockSchema) Verify(arg0 database.DB) ([]schema.Drift, error) {
+	ret := m.ctrl.Call(m, "Verify", arg0)
+	ret0, _ := ret[0].([]schema.Drift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify
+func (mr *MockSchemaMockRecorder) Verify(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockSchema)(nil).Verify), arg0)
+}
+
 // MockSchemaProvider is a mock of SchemaProvider interface
 type MockSchemaProvider struct {
 	ctrl     *gomock.Controller
@@ -91,6 +160,30 @@ func (m *MockSchemaProvider) EXPECT() *MockSchemaProviderMockRecorder {
 	return m.recorder
 }
 
+// Downs mocks base method
+func (m *MockSchemaProvider) Downs() map[int]schema.Update {
+	ret := m.ctrl.Call(m, "Downs")
+	ret0, _ := ret[0].(map[int]schema.Update)
+	return ret0
+}
+
+// Downs indicates an expected call of Downs
+func (mr *MockSchemaProviderMockRecorder) Downs() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Downs", reflect.TypeOf((*MockSchemaProvider)(nil).Downs))
+}
+
+// Patches mocks base method
+func (m *MockSchemaProvider) Patches() []node.Patch {
+	ret := m.ctrl.Call(m, "Patches")
+	ret0, _ := ret[0].([]node.Patch)
+	return ret0
+}
+
+// Patches indicates an expected call of Patches
+func (mr *MockSchemaProviderMockRecorder) Patches() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patches", reflect.TypeOf((*MockSchemaProvider)(nil).Patches))
+}
+
 // Schema mocks base method
 func (m *MockSchemaProvider) Schema() node.Schema {
 	ret := m.ctrl.Call(m, "Schema")
@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"database/sql/driver"
 	"path/filepath"
 	"sync"
@@ -49,15 +50,29 @@ type SchemaProvider interface {
 	// Updates returns the schema updates that is required for the updating of
 	// the database.
 	Updates() []schema.Update
+
+	// Downs returns the updates undoing the schema updates, indexed by the
+	// version of the update they undo.
+	Downs() map[int]schema.Update
+
+	// Patches returns the data patches to run once the schema is up to
+	// date, in order.
+	Patches() []Patch
 }
 
 // Schema captures the schema of a database in terms of a series of ordered
 // updates.
 type Schema interface {
 
+	// Fresh sets a statement that will be used to create the schema from
+	// scratch when bootstraping an empty database. It should be a
+	// "flattening" of the available updates, generated using the Dump()
+	// method. If not given, all patches will be applied in order.
+	Fresh(string)
+
 	// File extra queries from a file. If the file is exists, all SQL queries in it
 	// will be executed transactionally at the very start of Ensure(), before
-	// anything else is done.
+	// anything else is done. Files added more than once are run in order.
 	File(string)
 	// Hook instructs the schema to invoke the given function whenever a update is
 	// about to be applied. The function gets passed the update version number and
@@ -66,14 +81,62 @@ type Schema interface {
 	// replaced.
 	Hook(schema.Hook)
 
+	// AppVersion sets the version of the application applying the updates,
+	// which is recorded along with them.
+	AppVersion(string)
+
+	// AcceptChecksums instructs Ensure to replace the checksums of the applied
+	// updates that have changed with the ones of the current updates, instead
+	// of failing.
+	AcceptChecksums(bool)
+
 	// Ensure makes sure that the actual schema in the given database matches the
 	// one defined by our updates.
 	//
 	// All updates are applied transactionally. In case any error occurs the
 	// transaction will be rolled back and the database will remain unchanged.
 	Ensure(database.DB) (int, error)
+
+	// Rollback undoes the updates applied to the given database, from the
+	// current version down to the given version (excluded).
+	//
+	// All down updates are run transactionally. In case any error occurs the
+	// transaction will be rolled back and the database will remain unchanged.
+	Rollback(database.DB, int) (int, error)
+
+	// Verify compares the tables and indexes of the given database with the
+	// ones expected from the updates applied to it, returning the objects
+	// that are missing, extra or changed.
+	Verify(database.DB) ([]schema.Drift, error)
+
+	// Plan does a dry run of Ensure against the given database, returning
+	// the steps it would take along with the statements each of them
+	// executes.
+	Plan(database.DB) (schema.Plan, error)
 }
 
+// defaultOpenTimeout is how long opening the database waits for a connection
+// before giving up.
+const defaultOpenTimeout = 10 * time.Second
+
+const (
+	// databaseFile is the name of the file of a SQLite node database, in the
+	// node database directory.
+	databaseFile = "local.db"
+
+	// lockFile is the name of the file that's locked for as long as a SQLite
+	// node database is open, in the node database directory.
+	lockFile = "local.db.lock"
+
+	// globalPatchFile and localPatchFile are the names of the files of extra
+	// queries run before updating the schema, in the node database directory.
+	// The global one is meant for the data shared by every node, the local
+	// one for the data of this node only. With the node database being the
+	// only database, both are run against it, global first.
+	globalPatchFile = "patch.global.sql"
+	localPatchFile  = "patch.local.sql"
+)
+
 // Node represents a local node in a cluster
 type Node struct {
 	database       database.DB
@@ -82,47 +145,173 @@ type Node struct {
 	schemaProvider SchemaProvider
 	fileSystem     fsys.FileSystem
 	openTimeout    time.Duration
+	lock           fsys.Releaser
 	once           sync.Once
+
+	appVersion      string
+	acceptChecksums bool
 }
 
 // New creates a cluster ensuring that sane defaults are employed.
-func New(fileSystem fsys.FileSystem) *Node {
+//
+// The given options customize the database once it's opened, for recording
+// its activity.
+func New(fileSystem fsys.FileSystem, options ...database.Option) *Node {
 	return &Node{
-		databaseIO: databaseIO{},
+		databaseIO: databaseIO{
+			options: options,
+		},
 		schemaProvider: &schemaProvider{
 			fileSystem: fileSystem,
 		},
-		fileSystem: fileSystem,
+		fileSystem:  fileSystem,
+		openTimeout: defaultOpenTimeout,
 	}
 }
 
 // Open the node-local database object.
+//
+// A SQLite database lives in the local.db file of the given directory, which
+// is locked for as long as the database is open, so that no two nodes ever
+// share it.
 func (n *Node) Open(path string, connectionInfo database.ConnectionInfo) error {
-	// These are used to tune the transaction BEGIN behavior instead of using the
-	// similar "locking_mode" pragma (locking for the whole database connection).
-	db, err := n.databaseIO.Open(database.DriverName(), connectionInfo.String())
+	if connectionInfo.DriverName() == database.SQLite && !connectionInfo.Memory {
+		lock, _, err := n.fileSystem.Lock(filepath.Join(path, lockFile))
+		if err != nil {
+			return errors.Wrap(err, "database is in use by another process")
+		}
+		n.lock = lock
+		connectionInfo.DBName = filepath.Join(path, databaseFile)
+	}
+
+	db, err := n.databaseIO.Open(connectionInfo.DriverName(), connectionInfo.String())
+	if err != nil {
+		n.release()
+		return errors.WithStack(err)
+	}
+
+	// Connect straight away, so that an unreachable database is reported
+	// when opening it instead of on first use, without hanging for any
+	// longer than the open timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), n.openTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		n.release()
+		return errors.Wrap(err, "failed to connect to database")
+	}
 
 	n.database = db
 	n.databasePath = path
 
+	return nil
+}
+
+// Close the node-local database object, releasing the lock on its directory.
+func (n *Node) Close() error {
+	err := n.database.Close()
+	if releaseErr := n.release(); err == nil {
+		err = releaseErr
+	}
+	return errors.WithStack(err)
+}
+
+// Release the lock on the database directory, if any.
+func (n *Node) release() error {
+	if n.lock == nil {
+		return nil
+	}
+	err := n.lock.Release()
+	n.lock = nil
 	return errors.WithStack(err)
 }
 
+// AppVersion sets the version of the application, which is recorded along
+// with the schema updates it applies.
+func (n *Node) AppVersion(version string) {
+	n.appVersion = version
+}
+
+// AcceptChecksums instructs EnsureSchema to accept the schema updates that
+// have changed since they were applied, replacing their checksums, instead of
+// failing.
+func (n *Node) AcceptChecksums(accept bool) {
+	n.acceptChecksums = accept
+}
+
 // EnsureSchema applies all relevant schema updates to the node-local
 // database.
 //
 // Return the initial schema version found before starting the update, along
 // with any error occurred.
 func (n *Node) EnsureSchema(hookFn schema.Hook) (int, error) {
-	ctx := &context{}
+	ctx := &hookContext{}
+	current, err := n.ensureSchema(ctx, hookFn).Ensure(n.database)
+	if err != nil {
+		return current, err
+	}
+	return current, n.pruneBackups(ctx)
+}
+
+// PlanSchema does a dry run of applying the schema updates to the node-local
+// database, leaving it unchanged and taking no backup.
+//
+// Return the steps that applying the updates would take, along with any error
+// occurred.
+func (n *Node) PlanSchema() (schema.Plan, error) {
+	plan, err := n.ensureSchema(&hookContext{}, nil).Plan(n.database)
+	return plan, errors.WithStack(err)
+}
 
+// Return the schema that applies the schema updates to the node-local
+// database, invoking the given hook before each of them.
+func (n *Node) ensureSchema(ctx *hookContext, hookFn schema.Hook) Schema {
 	schema := n.schemaProvider.Schema()
-	schema.File(filepath.Join(n.databasePath, "patch.local.sql"))
+	// The fresh schema is a dump of a SQLite database, any other database
+	// is created by applying all the updates.
+	if n.database.Dialect().DriverName() == database.SQLite {
+		schema.Fresh(freshSchema)
+	}
+	schema.File(filepath.Join(n.databasePath, globalPatchFile))
+	schema.File(filepath.Join(n.databasePath, localPatchFile))
+	schema.AppVersion(n.appVersion)
+	schema.AcceptChecksums(n.acceptChecksums)
 	schema.Hook(func(version int, tx database.Tx) error {
 		err := hook(ctx, n.fileSystem, hookFn, n.databasePath, version, tx)
 		return errors.WithStack(err)
 	})
-	return schema.Ensure(n.database)
+	return schema
+}
+
+// RollbackSchema undoes the schema updates of the node-local database down to
+// the given version, taking a backup of the database before doing so.
+//
+// Return the initial schema version found before starting the rollback, along
+// with any error occurred.
+func (n *Node) RollbackSchema(version int) (int, error) {
+	ctx := &hookContext{}
+
+	schema := n.schemaProvider.Schema()
+	schema.AppVersion(n.appVersion)
+	schema.Hook(func(version int, tx database.Tx) error {
+		err := hook(ctx, n.fileSystem, nil, n.databasePath, version, tx)
+		return errors.WithStack(err)
+	})
+	current, err := schema.Rollback(n.database, version)
+	if err != nil {
+		return current, errors.WithStack(err)
+	}
+	return current, n.pruneBackups(ctx)
+}
+
+// VerifySchema compares the tables and indexes of the node-local database
+// with the ones expected from the schema updates applied to it.
+//
+// Return the objects that are missing, extra or changed, along with any error
+// occurred.
+func (n *Node) VerifySchema() ([]schema.Drift, error) {
+	drifts, err := n.schemaProvider.Schema().Verify(n.database)
+	return drifts, errors.WithStack(err)
 }
 
 // DB return the current database source.
@@ -130,12 +319,31 @@ func (n *Node) DB() database.DB {
 	return n.database
 }
 
-type context struct {
+// hookContext tracks the state of the hook across the updates of a single
+// run.
+type hookContext struct {
 	backupDone bool
 }
 
-func hook(ctx *context, fsys fsys.FileSystem, hook schema.Hook, dir string, version int, tx database.Tx) error {
+// Remove the backups past the retention, if a new one was taken by the run
+// of the hook with the given context.
+func (n *Node) pruneBackups(ctx *hookContext) error {
 	if !ctx.backupDone {
+		return nil
+	}
+	err := pruneBackups(n.fileSystem, n.databasePath)
+	return errors.WithStack(err)
+}
+
+func hook(ctx *hookContext, fsys fsys.FileSystem, hook schema.Hook, dir string, version int, tx database.Tx) error {
+	// Take a backup of the database before the first update is applied, so
+	// that the update can be undone by restoring it. There's nothing to back
+	// up for an empty database, custom query files (signaled by
+	// version == -1) don't need one and a dry run doesn't change anything.
+	if !ctx.backupDone && version > 0 && !schema.IsDryRun(tx) {
+		if err := backup(fsys, dir, version, tx); err != nil {
+			return errors.Wrap(err, "failed to backup database")
+		}
 		ctx.backupDone = true
 	}
 
@@ -1,6 +1,7 @@
 package node_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bicycolet/bicycolet/internal/db/database"
@@ -57,7 +58,8 @@ func TestOpen(t *testing.T) {
 
 	deps := createNodeDeps(t, ctrl)
 	gomock.InOrder(
-		deps.databaseIO.EXPECT().Open(database.DriverName(), info.String()).Return(mockDB, nil),
+		deps.databaseIO.EXPECT().Open(database.Postgres, info.String()).Return(mockDB, nil),
+		mockDB.EXPECT().PingContext(gomock.Any()).Return(nil),
 	)
 
 	err := deps.node.Open("/path/to/a/dir", info)
@@ -79,7 +81,8 @@ func TestOpenWithEmptyName(t *testing.T) {
 
 	deps := createNodeDeps(t, ctrl)
 	gomock.InOrder(
-		deps.databaseIO.EXPECT().Open(database.DriverName(), info.String()).Return(mockDB, nil),
+		deps.databaseIO.EXPECT().Open(database.Postgres, info.String()).Return(mockDB, nil),
+		mockDB.EXPECT().PingContext(gomock.Any()).Return(nil),
 	)
 
 	err := deps.node.Open("", info)
@@ -99,7 +102,7 @@ func TestOpenWithErrorFromOpening(t *testing.T) {
 
 	deps := createNodeDeps(t, ctrl)
 	gomock.InOrder(
-		deps.databaseIO.EXPECT().Open(database.DriverName(), info.String()).Return(nil, errors.New("bad")),
+		deps.databaseIO.EXPECT().Open(database.Postgres, info.String()).Return(nil, errors.New("bad")),
 	)
 
 	err := deps.node.Open("", info)
@@ -111,6 +114,113 @@ func TestOpenWithErrorFromOpening(t *testing.T) {
 	}
 }
 
+func TestOpenWithErrorFromConnecting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := connectionInfo()
+
+	mockDB := mocks.NewMockDB(ctrl)
+
+	deps := createNodeDeps(t, ctrl)
+	gomock.InOrder(
+		deps.databaseIO.EXPECT().Open(database.Postgres, info.String()).Return(mockDB, nil),
+		mockDB.EXPECT().PingContext(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("expected connecting to have a deadline")
+			}
+			return errors.New("bad")
+		}),
+		mockDB.EXPECT().Close().Return(nil),
+	)
+
+	err := deps.node.Open("/path/to/a/dir", info)
+	if err == nil {
+		t.Errorf("expected err to not be nil: got %v", err)
+	}
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestOpenWithSQLite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := connectionInfo()
+	info.Driver = database.SQLite
+
+	mockDB := mocks.NewMockDB(ctrl)
+	mockReleaser := mocks.NewMockReleaser(ctrl)
+
+	dsn := "/path/to/a/dir/local.db?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on&_txlock=immediate"
+
+	deps := createNodeDeps(t, ctrl)
+	gomock.InOrder(
+		deps.fileSystem.EXPECT().Lock("/path/to/a/dir/local.db.lock").Return(mockReleaser, false, nil),
+		deps.databaseIO.EXPECT().Open(database.SQLite, dsn).Return(mockDB, nil),
+		mockDB.EXPECT().PingContext(gomock.Any()).Return(nil),
+		mockDB.EXPECT().Close().Return(nil),
+		mockReleaser.EXPECT().Release().Return(nil),
+	)
+
+	if err := deps.node.Open("/path/to/a/dir", info); err != nil {
+		t.Errorf("expected err to be nil: got %v", err)
+	}
+	if err := deps.node.Close(); err != nil {
+		t.Errorf("expected err to be nil: got %v", err)
+	}
+}
+
+func TestOpenWithSQLiteInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := connectionInfo()
+	info.Driver = database.SQLite
+
+	deps := createNodeDeps(t, ctrl)
+	gomock.InOrder(
+		deps.fileSystem.EXPECT().Lock("/path/to/a/dir/local.db.lock").Return(nil, true, errors.New("bad")),
+	)
+
+	err := deps.node.Open("/path/to/a/dir", info)
+	if err == nil {
+		t.Errorf("expected err to not be nil: got %v", err)
+	}
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestOpenWithSQLiteReleasesLockOnFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := connectionInfo()
+	info.Driver = database.SQLite
+
+	mockDB := mocks.NewMockDB(ctrl)
+	mockReleaser := mocks.NewMockReleaser(ctrl)
+
+	deps := createNodeDeps(t, ctrl)
+	gomock.InOrder(
+		deps.fileSystem.EXPECT().Lock("/path/to/a/dir/local.db.lock").Return(mockReleaser, false, nil),
+		deps.databaseIO.EXPECT().Open(database.SQLite, gomock.Any()).Return(mockDB, nil),
+		mockDB.EXPECT().PingContext(gomock.Any()).Return(errors.New("bad")),
+		mockDB.EXPECT().Close().Return(nil),
+		mockReleaser.EXPECT().Release().Return(nil),
+	)
+
+	err := deps.node.Open("/path/to/a/dir", info)
+	if err == nil {
+		t.Errorf("expected err to not be nil: got %v", err)
+	}
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
 // EnsureSchema
 
 func TestEnsureSchema(t *testing.T) {
@@ -124,9 +234,14 @@ func TestEnsureSchema(t *testing.T) {
 
 	deps := createNodeDeps(t, ctrl)
 	gomock.InOrder(
-		deps.databaseIO.EXPECT().Open(database.DriverName(), info.String()).Return(mockDB, nil),
+		deps.databaseIO.EXPECT().Open(database.Postgres, info.String()).Return(mockDB, nil),
+		mockDB.EXPECT().PingContext(gomock.Any()).Return(nil),
 		deps.schemaProvider.EXPECT().Schema().Return(mockSchema),
+		mockDB.EXPECT().Dialect().Return(postgres),
+		mockSchema.EXPECT().File("/path/to/a/dir/patch.global.sql"),
 		mockSchema.EXPECT().File("/path/to/a/dir/patch.local.sql"),
+		mockSchema.EXPECT().AppVersion("0.1"),
+		mockSchema.EXPECT().AcceptChecksums(true),
 		mockSchema.EXPECT().Hook(gomock.Any()),
 		mockSchema.EXPECT().Ensure(mockDB).Return(0, nil),
 	)
@@ -135,6 +250,8 @@ func TestEnsureSchema(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected err to not be nil: got %v", err)
 	}
+	deps.node.AppVersion("0.1")
+	deps.node.AcceptChecksums(true)
 	version, err := deps.node.EnsureSchema(func(version int, tx database.Tx) error {
 		return nil
 	})
@@ -146,6 +263,45 @@ func TestEnsureSchema(t *testing.T) {
 	}
 }
 
+func TestEnsureSchemaWithSQLite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := connectionInfo()
+	info.Driver = database.SQLite
+	info.Memory = true
+
+	mockDB := mocks.NewMockDB(ctrl)
+	mockSchema := mocks.NewMockSchema(ctrl)
+
+	deps := createNodeDeps(t, ctrl)
+	gomock.InOrder(
+		deps.databaseIO.EXPECT().Open(database.SQLite, info.String()).Return(mockDB, nil),
+		mockDB.EXPECT().PingContext(gomock.Any()).Return(nil),
+		deps.schemaProvider.EXPECT().Schema().Return(mockSchema),
+		mockDB.EXPECT().Dialect().Return(sqlite),
+		mockSchema.EXPECT().Fresh(node.FreshSchema()),
+		mockSchema.EXPECT().File("/path/to/a/dir/patch.global.sql"),
+		mockSchema.EXPECT().File("/path/to/a/dir/patch.local.sql"),
+		mockSchema.EXPECT().AppVersion("0.1"),
+		mockSchema.EXPECT().AcceptChecksums(true),
+		mockSchema.EXPECT().Hook(gomock.Any()),
+		mockSchema.EXPECT().Ensure(mockDB).Return(0, nil),
+	)
+
+	err := deps.node.Open("/path/to/a/dir", info)
+	if err != nil {
+		t.Errorf("expected err to not be nil: got %v", err)
+	}
+	deps.node.AppVersion("0.1")
+	deps.node.AcceptChecksums(true)
+	if _, err := deps.node.EnsureSchema(func(version int, tx database.Tx) error {
+		return nil
+	}); err != nil {
+		t.Errorf("expected err to not be nil: got %v", err)
+	}
+}
+
 // hook
 
 func TestHook(t *testing.T) {
@@ -161,7 +317,7 @@ func TestHook(t *testing.T) {
 	err := node.Hook(ctx, deps.fileSystem, func(version int, tx database.Tx) error {
 		called = true
 		return nil
-	}, "/path/to/a/dir", 1, mockTx)
+	}, "/path/to/a/dir", 0, mockTx)
 	if err != nil {
 		t.Errorf("expected err to be nil: got %v", err)
 	}
@@ -181,7 +337,7 @@ func TestHookWithHookFailure(t *testing.T) {
 
 	err := node.Hook(ctx, deps.fileSystem, func(version int, tx database.Tx) error {
 		return errors.New("bad")
-	}, "/path/to/a/dir", 1, mockTx)
+	}, "/path/to/a/dir", 0, mockTx)
 	if err == nil {
 		t.Errorf("expected err to not be nil: got %v", err)
 	}
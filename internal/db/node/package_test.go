@@ -9,7 +9,7 @@ import (
 )
 
 //go:generate mockgen -package mocks -destination mocks/database_mock.go github.com/bicycolet/bicycolet/internal/db/node DatabaseRegistrar,DatabaseOpener,DatabaseIO
-//go:generate mockgen -package mocks -destination mocks/filesystem_mock.go github.com/bicycolet/bicycolet/internal/fsys FileSystem
+//go:generate mockgen -package mocks -destination mocks/filesystem_mock.go github.com/bicycolet/bicycolet/internal/fsys FileSystem,Releaser
 //go:generate mockgen -package mocks -destination mocks/schema_mock.go github.com/bicycolet/bicycolet/internal/db/node Schema,SchemaProvider
 //go:generate mockgen -package mocks -destination mocks/db_mock.go github.com/bicycolet/bicycolet/internal/db/database DB,Tx,Rows
 //go:generate mockgen -package mocks -destination mocks/driver_mock.go database/sql/driver Driver
@@ -67,3 +67,17 @@ func (m typeMatcher) Matches(x interface{}) bool {
 func (m typeMatcher) String() string {
 	return fmt.Sprintf("%v", m.x)
 }
+
+// The dialects the mocked databases speak.
+var (
+	sqlite   = newDialect(database.SQLite)
+	postgres = newDialect(database.Postgres)
+)
+
+func newDialect(driverName string) database.Dialect {
+	dialect, err := database.NewDialect(driverName)
+	if err != nil {
+		panic(err)
+	}
+	return dialect
+}
@@ -0,0 +1,126 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/pkg/errors"
+)
+
+// Patch is a named data migration of the node-local database. Unlike the
+// schema updates, which change the structure of the database, patches fix up
+// its data and are run once the schema is up to date.
+type Patch struct {
+	// Name identifies the patch in the patches table once it's run, so it
+	// must never change.
+	Name string
+
+	// Run applies the patch, within the transaction recording it.
+	Run func(database.Tx) error
+
+	// Background patches are run once the daemon is up, rather than while
+	// the database is opened, for the patches that take too long to hold up
+	// the start of the daemon.
+	Background bool
+}
+
+// PatchStatus is a patch of the node-local database, along with when it was
+// run.
+type PatchStatus struct {
+	Name       string
+	Background bool
+
+	// AppliedAt is when the patch was run, or the zero time if it's pending.
+	AppliedAt time.Time
+}
+
+// StmtSelectPatches represents a query to get the name of the patches that
+// were run, along with when they were.
+const StmtSelectPatches = `
+SELECT name, applied_at FROM patches ORDER BY id
+`
+
+// StmtInsertPatch provides a function for creating the sql statement that
+// records a patch as run.
+var StmtInsertPatch = func(dialect database.Dialect) string {
+	return fmt.Sprintf(`
+INSERT INTO patches (name, applied_at) VALUES (?, %s)
+`, dialect.Now())
+}
+
+// patches returns the data patches of the node-local database, in the order
+// they're run. New patches are appended to the list.
+func patches() []Patch {
+	return []Patch{}
+}
+
+// EnsurePatches runs the patches of the node-local database that weren't run
+// yet, in order. Each patch is run in its own transaction, which records it
+// in the patches table. If background is true only the patches to run in
+// background are run, otherwise only the other ones.
+//
+// Return the names of the patches that were run, along with any error
+// occurred. The patches run before the error occurred are kept.
+func (n *Node) EnsurePatches(ctx context.Context, background bool) ([]string, error) {
+	var applied []string
+	for _, patch := range n.schemaProvider.Patches() {
+		if patch.Background != background {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return applied, errors.WithStack(err)
+		}
+
+		var ran bool
+		if err := query.Transaction(ctx, n.database, func(tx database.Tx) error {
+			count, err := query.Count(ctx, tx, "patches", query.Eq("name", patch.Name))
+			if err != nil || count > 0 {
+				return errors.WithStack(err)
+			}
+			if err := patch.Run(tx); err != nil {
+				return errors.WithStack(err)
+			}
+			ran = true
+			_, err = tx.ExecContext(ctx, StmtInsertPatch(tx.Dialect()), patch.Name)
+			return errors.WithStack(err)
+		}); err != nil {
+			return applied, errors.Wrapf(err, "failed to apply patch %q", patch.Name)
+		}
+		if ran {
+			applied = append(applied, patch.Name)
+		}
+	}
+	return applied, nil
+}
+
+// Patches returns the patches of the node-local database in the order they're
+// run, along with when they were.
+func (n *Node) Patches(ctx context.Context) ([]PatchStatus, error) {
+	var applied []PatchStatus
+	if err := query.Transaction(ctx, n.database, func(tx database.Tx) error {
+		dest := func(i int) []interface{} {
+			applied = append(applied, PatchStatus{})
+			return []interface{}{&applied[i].Name, &applied[i].AppliedAt}
+		}
+		return query.SelectObjects(ctx, tx, dest, StmtSelectPatches)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch applied patches")
+	}
+	appliedAt := make(map[string]time.Time, len(applied))
+	for _, status := range applied {
+		appliedAt[status.Name] = status.AppliedAt
+	}
+
+	var statuses []PatchStatus
+	for _, patch := range n.schemaProvider.Patches() {
+		statuses = append(statuses, PatchStatus{
+			Name:       patch.Name,
+			Background: patch.Background,
+			AppliedAt:  appliedAt[patch.Name],
+		})
+	}
+	return statuses, nil
+}
@@ -0,0 +1,158 @@
+package node_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+func TestEnsurePatches(t *testing.T) {
+	var ran []string
+	patch := func(name string, background bool) node.Patch {
+		return node.Patch{
+			Name: name,
+			Run: func(tx database.Tx) error {
+				ran = append(ran, name)
+				return nil
+			},
+			Background: background,
+		}
+	}
+	n := openNodeWithPatches(t, []node.Patch{
+		patch("first", false),
+		patch("slow", true),
+		patch("second", false),
+	})
+	defer n.Close()
+
+	applied, err := n.EnsurePatches(context.Background(), false)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []string{"first", "second"}, applied; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	applied, err = n.EnsurePatches(context.Background(), true)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []string{"slow"}, applied; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	// Patches are only ever run once.
+	for _, background := range []bool{false, true} {
+		applied, err = n.EnsurePatches(context.Background(), background)
+		if err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+		if expected, actual := 0, len(applied); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	}
+	if expected, actual := []string{"first", "second", "slow"}, ran; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	statuses, err := n.Patches(context.Background())
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 3, len(statuses); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+	for i, name := range []string{"first", "slow", "second"} {
+		if expected, actual := name, statuses[i].Name; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+		if statuses[i].AppliedAt.IsZero() {
+			t.Errorf("expected patch %q to be applied", name)
+		}
+	}
+	if expected, actual := true, statuses[1].Background; expected != actual {
+		t.Errorf("expected: %t, actual: %t", expected, actual)
+	}
+}
+
+// A failing patch is rolled back, along with any patch after it, while the
+// patches before it are kept.
+func TestEnsurePatchesWithFailure(t *testing.T) {
+	n := openNodeWithPatches(t, []node.Patch{
+		{
+			Name: "first",
+			Run: func(tx database.Tx) error {
+				_, err := tx.Exec("INSERT INTO config (key, value) VALUES ('first', '1')")
+				return err
+			},
+		},
+		{
+			Name: "broken",
+			Run: func(tx database.Tx) error {
+				if _, err := tx.Exec("INSERT INTO config (key, value) VALUES ('broken', '1')"); err != nil {
+					return err
+				}
+				return errors.New("bad")
+			},
+		},
+		{
+			Name: "last",
+			Run: func(tx database.Tx) error {
+				t.Errorf("expected patch after a failure to not be run")
+				return nil
+			},
+		},
+	})
+	defer n.Close()
+
+	applied, err := n.EnsurePatches(context.Background(), false)
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Fatalf("expected: %s, actual: %s", expected, actual)
+	}
+	if expected, actual := []string{"first"}, applied; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	var count int
+	if err := query.Transaction(context.Background(), n.DB(), func(tx database.Tx) error {
+		count, err = query.Count(context.Background(), tx, "config", query.Eq("key", "broken"))
+		return err
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 0, count; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+
+	statuses, err := n.Patches(context.Background())
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	for i, pending := range []bool{false, true, true} {
+		if expected, actual := pending, statuses[i].AppliedAt.IsZero(); expected != actual {
+			t.Errorf("expected patch %q pending to be %t", statuses[i].Name, expected)
+		}
+	}
+}
+
+// Return an in-memory node with an up to date schema, which runs the given
+// patches.
+func openNodeWithPatches(t *testing.T, patches []node.Patch) *node.Node {
+	n := node.NewNodeWithPatches(fsys.NewVirtualFileSystem(), patches)
+	if err := n.Open("", database.ConnectionInfo{
+		Driver: database.SQLite,
+		Memory: true,
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if _, err := n.EnsureSchema(nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	return n
+}
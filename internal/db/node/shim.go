@@ -8,8 +8,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+// databaseIO opens databases whose shims are customized by the given options,
+// which record the activity of the database.
 type databaseIO struct {
-	// TODO (Simon): Add metrics here
+	options []database.Option
 }
 
 func (databaseIO) Register(driverName string, driver driver.Driver) {
@@ -20,10 +22,19 @@ func (databaseIO) Drivers() []string {
 	return sql.Drivers()
 }
 
-func (databaseIO) Open(driverName, dataSourceName string) (database.DB, error) {
+func (d databaseIO) Open(driverName, dataSourceName string) (database.DB, error) {
+	dialect, err := database.NewDialect(driverName)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
 	db, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return database.ShimDB(db, err)
+	// Every connection to an in-memory database is a new database, so make
+	// sure that only one is ever used.
+	if dataSourceName == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+	return database.NewShimDB(db, dialect, d.options...), nil
 }
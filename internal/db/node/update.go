@@ -1,6 +1,8 @@
 package node
 
 import (
+	"fmt"
+
 	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/bicycolet/bicycolet/internal/db/schema"
 	"github.com/bicycolet/bicycolet/internal/fsys"
@@ -12,31 +14,76 @@ type schemaProvider struct {
 
 func (s schemaProvider) Schema() Schema {
 	schema := schema.New(s.fileSystem, s.Updates())
-	schema.Fresh(freshSchema)
+	for version, down := range s.Downs() {
+		schema.Down(version, down)
+	}
 	return schema
 }
 
 func (s schemaProvider) Updates() []schema.Update {
 	return []schema.Update{
 		updateFromV0,
+		updateFromV1,
+	}
+}
+
+func (s schemaProvider) Downs() map[int]schema.Update {
+	return map[int]schema.Update{
+		1: downToV0,
+		2: downToV1,
 	}
 }
 
+func (s schemaProvider) Patches() []Patch {
+	return patches()
+}
+
 func updateFromV0(tx database.Tx) error {
-	stmt := `
+	dialect := tx.Dialect()
+	stmt := fmt.Sprintf(`
 CREATE TABLE config (
-	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	id %s,
 	key VARCHAR(255) NOT NULL,
 	value TEXT,
 	UNIQUE (key)
 );
 CREATE TABLE patches (
-	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	id %s,
 	name VARCHAR(255) NOT NULL,
-	applied_at DATETIME NOT NULL,
+	applied_at %s NOT NULL,
 	UNIQUE (name)
 );
-`
+`, dialect.AutoIncrement(), dialect.AutoIncrement(), dialect.DateTime())
 	_, err := tx.Exec(stmt)
 	return err
 }
+
+// updateFromV1 creates the raft_nodes table, which was only ever created by
+// the fresh schema. The table already exists in databases that were created
+// from scratch at version 1.
+func updateFromV1(tx database.Tx) error {
+	stmt := fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS raft_nodes (
+	id %s,
+	address TEXT NOT NULL,
+	UNIQUE (address)
+);
+`, tx.Dialect().AutoIncrement())
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func downToV0(tx database.Tx) error {
+	_, err := tx.Exec(`
+DROP TABLE config;
+DROP TABLE patches;
+DROP TABLE IF EXISTS raft_nodes;
+`)
+	return err
+}
+
+// downToV1 keeps the raft_nodes table, since databases that were created from
+// scratch at version 1 have it as well.
+func downToV1(tx database.Tx) error {
+	return nil
+}
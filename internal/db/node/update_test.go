@@ -2,10 +2,13 @@ package node_test
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/bicycolet/bicycolet/internal/db/node"
 	"github.com/bicycolet/bicycolet/internal/db/node/mocks"
+	"github.com/bicycolet/bicycolet/internal/fsys"
 	"github.com/golang/mock/gomock"
 )
 
@@ -28,7 +31,7 @@ func TestSchemaProviderUpdate(t *testing.T) {
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	updates := node.NewSchemaProviderWithMocks(mockFileSystem).Updates()
-	if expected, actual := 1, len(updates); expected != actual {
+	if expected, actual := 2, len(updates); expected != actual {
 		t.Errorf("expected: %d, actual: %d", expected, actual)
 	}
 }
@@ -38,13 +41,139 @@ func TestSchemaProviderUpdateAndExec(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
-	mockTx.EXPECT().Exec(TypeMatcher(reflect.String)).Return(nil, nil)
-
 	updates := node.NewSchemaProviderWithMocks(mockFileSystem).Updates()
-	err := updates[0](mockTx)
+	for _, update := range updates {
+		mockTx.EXPECT().Exec(TypeMatcher(reflect.String)).Return(nil, nil)
+
+		if err := update(mockTx); err != nil {
+			t.Errorf("err should be nil")
+		}
+	}
+}
+
+func TestSchemaProviderDowns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFileSystem := mocks.NewMockFileSystem(ctrl)
+
+	provider := node.NewSchemaProviderWithMocks(mockFileSystem)
+	for version := range provider.Downs() {
+		if version < 1 || version > len(provider.Updates()) {
+			t.Errorf("unexpected down update for version %d", version)
+		}
+	}
+}
+
+// Every schema update can be rolled back, after which the schema can be
+// applied again.
+func TestRollbackSchema(t *testing.T) {
+	n := node.New(fsys.NewVirtualFileSystem())
+	if err := n.Open("", database.ConnectionInfo{
+		Driver: database.SQLite,
+		Memory: true,
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer n.Close()
+
+	if _, err := n.EnsureSchema(nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	// Roll back one version at a time, the fresh schema only records the
+	// latest version.
+	updates := len(node.NewSchemaProviderWithMocks(nil).Updates())
+	for version := updates - 1; version >= 0; version-- {
+		current, err := n.RollbackSchema(version)
+		if err != nil {
+			t.Fatalf("expected err to be nil: %v", err)
+		}
+		if expected, actual := version+1, current; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	}
+
+	initial, err := n.EnsureSchema(nil)
 	if err != nil {
-		t.Errorf("err should be nil")
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 0, initial; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+// The fresh schema creates the same objects as the updates.
+func TestVerifySchema(t *testing.T) {
+	n := node.New(fsys.NewVirtualFileSystem())
+	if err := n.Open("", database.ConnectionInfo{
+		Driver: database.SQLite,
+		Memory: true,
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer n.Close()
+
+	if _, err := n.EnsureSchema(nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	drifts, err := n.VerifySchema()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 0, len(drifts); expected != actual {
+		t.Errorf("expected: %d, actual: %d (%v)", expected, actual, drifts)
+	}
+}
+
+func TestPlanSchema(t *testing.T) {
+	n := node.New(fsys.NewVirtualFileSystem())
+	if err := n.Open("", database.ConnectionInfo{
+		Driver: database.SQLite,
+		Memory: true,
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer n.Close()
+
+	if _, err := n.EnsureSchema(nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if _, err := n.RollbackSchema(1); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	backups, err := n.Backups()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	plan, err := n.PlanSchema()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, plan.Current; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := 1, len(plan.Steps); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := "CREATE TABLE IF NOT EXISTS raft_nodes", plan.Steps[0].Statements[0]; !strings.HasPrefix(actual, expected) {
+		t.Errorf("expected %q to start with %q", actual, expected)
+	}
+
+	// The dry run takes no backup and leaves the database alone.
+	if actual, err := n.Backups(); err != nil || !reflect.DeepEqual(backups, actual) {
+		t.Errorf("expected: %v, actual: %v (%v)", backups, actual, err)
+	}
+	initial, err := n.EnsureSchema(nil)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, initial; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
 	}
 }
@@ -0,0 +1,291 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db"
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+)
+
+func TestNodeOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := database.ConnectionInfo{}
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+	mockDB := mocks.NewMockDB(ctrl)
+	mockStmt := mocks.NewMockStmt(ctrl)
+
+	gomock.InOrder(
+		mockNode.EXPECT().Open("/path/to/a/dir", info).Return(nil),
+		mockNode.EXPECT().EnsureSchema(gomock.Any()).Return(0, nil),
+		mockNode.EXPECT().EnsurePatches(gomock.Any(), false).Return(nil, nil),
+		mockNode.EXPECT().DB().Return(mockDB),
+		mockDB.EXPECT().PrepareContext(gomock.Any(), gomock.Any()).Return(mockStmt, nil).Times(len(db.Stmts())),
+	)
+
+	var called bool
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	err := node.Open("/path/to/a/dir", info, func(*db.Node) error {
+		called = true
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := true, called; expected != actual {
+		t.Errorf("expected: %t, actual: %t", expected, actual)
+	}
+	if expected, actual := "/path/to/a/dir", node.Dir(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestNodeOpenWithExistingSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := database.ConnectionInfo{}
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+	mockDB := mocks.NewMockDB(ctrl)
+	mockStmt := mocks.NewMockStmt(ctrl)
+
+	gomock.InOrder(
+		mockNode.EXPECT().Open("/path/to/a/dir", info).Return(nil),
+		mockNode.EXPECT().EnsureSchema(gomock.Any()).Return(1, nil),
+		mockNode.EXPECT().EnsurePatches(gomock.Any(), false).Return(nil, nil),
+		mockNode.EXPECT().DB().Return(mockDB),
+		mockDB.EXPECT().PrepareContext(gomock.Any(), gomock.Any()).Return(mockStmt, nil).Times(len(db.Stmts())),
+	)
+
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	err := node.Open("/path/to/a/dir", info, func(*db.Node) error {
+		t.Errorf("expected fresh hook to not be called")
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
+
+func TestNodeOpenWithEnsureSchemaFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := database.ConnectionInfo{}
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+
+	gomock.InOrder(
+		mockNode.EXPECT().Open("/path/to/a/dir", info).Return(nil),
+		mockNode.EXPECT().EnsureSchema(gomock.Any()).Return(-1, errors.New("bad")),
+	)
+
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	err := node.Open("/path/to/a/dir", info, nil)
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestNodeOpenWithPatchFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := database.ConnectionInfo{}
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+
+	gomock.InOrder(
+		mockNode.EXPECT().Open("/path/to/a/dir", info).Return(nil),
+		mockNode.EXPECT().EnsureSchema(gomock.Any()).Return(0, nil),
+		mockNode.EXPECT().EnsurePatches(gomock.Any(), false).Return(nil, errors.New("bad")),
+	)
+
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	err := node.Open("/path/to/a/dir", info, func(*db.Node) error {
+		t.Errorf("expected fresh hook to not be called")
+		return nil
+	})
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestNodeOpenWithPrepareFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := database.ConnectionInfo{}
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+	mockDB := mocks.NewMockDB(ctrl)
+	mockStmt := mocks.NewMockStmt(ctrl)
+
+	stmts := db.Stmts()
+	gomock.InOrder(
+		mockNode.EXPECT().Open("/path/to/a/dir", info).Return(nil),
+		mockNode.EXPECT().EnsureSchema(gomock.Any()).Return(1, nil),
+		mockNode.EXPECT().EnsurePatches(gomock.Any(), false).Return(nil, nil),
+		mockNode.EXPECT().DB().Return(mockDB),
+		mockDB.EXPECT().PrepareContext(gomock.Any(), stmts[0].SQL).Return(mockStmt, nil),
+		mockDB.EXPECT().PrepareContext(gomock.Any(), stmts[1].SQL).Return(nil, errors.New("bad")),
+		mockStmt.EXPECT().Close().Return(nil),
+	)
+
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	err := node.Open("/path/to/a/dir", info, func(*db.Node) error {
+		t.Errorf("expected fresh hook to not be called")
+		return nil
+	})
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestNodeClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := database.ConnectionInfo{}
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+	mockDB := mocks.NewMockDB(ctrl)
+	mockStmt := mocks.NewMockStmt(ctrl)
+
+	n := len(db.Stmts())
+	gomock.InOrder(
+		mockNode.EXPECT().Open("/path/to/a/dir", info).Return(nil),
+		mockNode.EXPECT().EnsureSchema(gomock.Any()).Return(1, nil),
+		mockNode.EXPECT().EnsurePatches(gomock.Any(), false).Return(nil, nil),
+		mockNode.EXPECT().DB().Return(mockDB),
+		mockDB.EXPECT().PrepareContext(gomock.Any(), gomock.Any()).Return(mockStmt, nil).Times(n),
+		mockStmt.EXPECT().Close().Return(nil).Times(n),
+		mockNode.EXPECT().Close().Return(nil),
+	)
+
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	if err := node.Open("/path/to/a/dir", info, nil); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if err := node.Close(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
+
+func TestNodeOpenWithChecksums(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	info := database.ConnectionInfo{}
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+
+	gomock.InOrder(
+		mockNode.EXPECT().AppVersion("0.1"),
+		mockNode.EXPECT().AcceptChecksums(true),
+		mockNode.EXPECT().Open("/path/to/a/dir", info).Return(nil),
+		mockNode.EXPECT().EnsureSchema(gomock.Any()).Return(1, errors.New("bad")),
+	)
+
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	node.AppVersion("0.1")
+	node.AcceptChecksums(true)
+	if err := node.Open("/path/to/a/dir", info, nil); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestNodeTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+	mockDB := mocks.NewMockDB(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gomock.InOrder(
+		mockNode.EXPECT().DB().Return(mockDB),
+		mockTransaction.EXPECT().Transaction(ctx, mockDB, gomock.Any()).DoAndReturn(func(ctx context.Context, db database.DB, fn func(database.Tx) error) error {
+			return fn(mockTx)
+		}),
+		mockQuery.EXPECT().SelectConfig(ctx, mockTx, "config").Return(map[string]string{"foo": "bar"}, nil),
+	)
+
+	var config map[string]string
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	err := node.Transaction(ctx, func(tx *db.NodeTx) error {
+		var err error
+		config, err = tx.Config()
+		return err
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := "bar", config["foo"]; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestNodeTransactionUpdateConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNode := mocks.NewMockQueryNode(ctrl)
+	mockTransaction := mocks.NewMockTransaction(ctrl)
+	mockQuery := mocks.NewMockQuery(ctrl)
+	mockDB := mocks.NewMockDB(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+
+	values := map[string]string{"foo": "bar", "baz": ""}
+
+	gomock.InOrder(
+		mockNode.EXPECT().DB().Return(mockDB),
+		mockTransaction.EXPECT().Transaction(gomock.Any(), mockDB, gomock.Any()).DoAndReturn(func(ctx context.Context, db database.DB, fn func(database.Tx) error) error {
+			return fn(mockTx)
+		}),
+		mockQuery.EXPECT().UpdateConfig(gomock.Any(), mockTx, "config", values).Return(nil),
+	)
+
+	node := db.NewNodeWithMocks(mockNode, mockTransaction, mockQuery)
+	err := node.Transaction(context.Background(), func(tx *db.NodeTx) error {
+		return tx.UpdateConfig(values)
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
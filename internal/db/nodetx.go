@@ -1,7 +1,10 @@
 package db
 
 import (
+	"context"
+
 	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/pkg/errors"
 )
 
 // NodeTx models a single interaction with a node-local database.
@@ -9,13 +12,47 @@ import (
 // It wraps low-level db.Tx objects and offers a high-level API to fetch and
 // update data.
 type NodeTx struct {
-	tx    database.Tx // Handle to a transaction in the node-level SQLite database.
+	ctx   context.Context // Context the queries of the transaction are bound to.
+	tx    database.Tx     // Handle to a transaction in the node-level SQLite database.
 	query Query
+	stmts map[int]database.Stmt // Registered statements, prepared against the database.
 }
 
 // NewNodeTx creates a new transaction node with sane defaults
-func NewNodeTx(tx database.Tx) *NodeTx {
+func NewNodeTx(ctx context.Context, tx database.Tx) *NodeTx {
+	return NewNodeTxWithQuery(ctx, tx, queryShim{})
+}
+
+// NewNodeTxWithQuery creates a new transaction node with the given query
+// implementation.
+func NewNodeTxWithQuery(ctx context.Context, tx database.Tx, query Query) *NodeTx {
 	return &NodeTx{
-		tx: tx,
+		ctx:   ctx,
+		tx:    tx,
+		query: query,
+	}
+}
+
+// Config fetches all node-level config keys.
+func (n *NodeTx) Config() (map[string]string, error) {
+	return n.query.SelectConfig(n.ctx, n.tx, "config")
+}
+
+// UpdateConfig updates the given node-level configuration.
+//
+// The keys in the values map are upserted, and any key with an empty value is
+// deleted.
+func (n *NodeTx) UpdateConfig(values map[string]string) error {
+	return n.query.UpdateConfig(n.ctx, n.tx, "config", values)
+}
+
+// Return the prepared statement registered with the given code, bound to the
+// transaction. Transactions created by NewNodeTx have no prepared statements,
+// only the ones of Node.Transaction do.
+func (n *NodeTx) stmt(code int) (database.Stmt, error) {
+	stmt, ok := n.stmts[code]
+	if !ok {
+		return nil, errors.Errorf("no statement prepared with code %d", code)
 	}
+	return n.tx.StmtContext(n.ctx, stmt), nil
 }
@@ -0,0 +1,5 @@
+package db_test
+
+//go:generate mockgen -package mocks -destination mocks/db_mock.go github.com/bicycolet/bicycolet/internal/db/database DB,Tx,Stmt
+//go:generate mockgen -package mocks -destination mocks/node_mock.go github.com/bicycolet/bicycolet/internal/db QueryNode,Transaction
+//go:generate mockgen -package mocks -destination mocks/query_mock.go github.com/bicycolet/bicycolet/internal/db Query
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"context"
+
 	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/bicycolet/bicycolet/internal/db/query"
 )
@@ -9,7 +11,11 @@ import (
 type ObjectsQuery interface {
 	// SelectObjects executes a statement which must yield rows with a specific
 	// columns schema. It invokes the given Dest hook for each yielded row.
-	SelectObjects(database.Tx, query.Dest, string, ...interface{}) error
+	SelectObjects(context.Context, database.Tx, query.Dest, string, ...interface{}) error
+
+	// SelectObjectsStmt is like SelectObjects, but executes the given
+	// prepared statement instead of parsing the query again.
+	SelectObjectsStmt(context.Context, database.Stmt, query.Dest, ...interface{}) error
 
 	// UpsertObject inserts or replaces a new row with the given column values,
 	// to the given table using columns order. For example:
@@ -17,14 +23,14 @@ type ObjectsQuery interface {
 	// UpsertObject(tx, "cars", []string{"id", "brand"}, []interface{}{1, "ferrari"})
 	//
 	// The number of elements in 'columns' must match the one in 'values'.
-	UpsertObject(database.Tx, string, []string, []interface{}) (int64, error)
+	UpsertObject(context.Context, database.Tx, string, []string, []interface{}) (int64, error)
 
 	// DeleteObject removes the row identified by the given ID. The given table
 	// must have a primary key column called 'id'.
 	//
 	// It returns a flag indicating if a matching row was actually found and
 	// deleted or not.
-	DeleteObject(database.Tx, string, int64) (bool, error)
+	DeleteObject(context.Context, database.Tx, string, int64) (bool, error)
 }
 
 // StringsQuery defines queries to the database for string queries
@@ -32,14 +38,28 @@ type StringsQuery interface {
 
 	// SelectStrings executes a statement which must yield rows with a single
 	// string column. It returns the list of column values.
-	SelectStrings(database.Tx, string, ...interface{}) ([]string, error)
+	SelectStrings(context.Context, database.Tx, string, ...interface{}) ([]string, error)
 }
 
 // CountQuery defines queries to the database for count queries
 type CountQuery interface {
 
-	// Count returns the number of rows in the given table.
-	Count(database.Tx, string, string, ...interface{}) (int, error)
+	// Count returns the number of rows in the given table, optionally
+	// filtered by the given conditions.
+	Count(context.Context, database.Tx, string, ...query.Condition) (int, error)
+}
+
+// ConfigQuery defines queries to the database for config queries
+type ConfigQuery interface {
+
+	// SelectConfig executes a query statement against a "config" table, which
+	// must have 'key' and 'value' columns. By default this query returns all
+	// keys, but additional conditions can be specified.
+	SelectConfig(context.Context, database.Tx, string, ...query.Condition) (map[string]string, error)
+
+	// UpdateConfig updates the given keys in the given table. Config keys set
+	// to empty values will be deleted.
+	UpdateConfig(context.Context, database.Tx, string, map[string]string) error
 }
 
 // Query defines different queries for accessing the database
@@ -47,11 +67,54 @@ type Query interface {
 	ObjectsQuery
 	StringsQuery
 	CountQuery
+	ConfigQuery
 }
 
 // Transaction defines a method for executing transactions over the
 // database
 type Transaction interface {
 	// Transaction executes the given function within a database transaction.
-	Transaction(database.DB, func(database.Tx) error) error
+	// If the context is done before the transaction is committed, the
+	// transaction is rolled back.
+	Transaction(context.Context, database.DB, func(database.Tx) error) error
+}
+
+type queryShim struct{}
+
+func (queryShim) SelectObjects(ctx context.Context, tx database.Tx, dest query.Dest, stmt string, args ...interface{}) error {
+	return query.SelectObjects(ctx, tx, dest, stmt, args...)
+}
+
+func (queryShim) SelectObjectsStmt(ctx context.Context, stmt database.Stmt, dest query.Dest, args ...interface{}) error {
+	return query.SelectObjectsStmt(ctx, stmt, dest, args...)
+}
+
+func (queryShim) UpsertObject(ctx context.Context, tx database.Tx, table string, columns []string, values []interface{}) (int64, error) {
+	return query.UpsertObject(ctx, tx, table, columns, values)
+}
+
+func (queryShim) DeleteObject(ctx context.Context, tx database.Tx, table string, id int64) (bool, error) {
+	return query.DeleteObject(ctx, tx, table, id)
+}
+
+func (queryShim) SelectStrings(ctx context.Context, tx database.Tx, stmt string, args ...interface{}) ([]string, error) {
+	return query.SelectStrings(ctx, tx, stmt, args...)
+}
+
+func (queryShim) Count(ctx context.Context, tx database.Tx, table string, conditions ...query.Condition) (int, error) {
+	return query.Count(ctx, tx, table, conditions...)
+}
+
+func (queryShim) SelectConfig(ctx context.Context, tx database.Tx, table string, conditions ...query.Condition) (map[string]string, error) {
+	return query.SelectConfig(ctx, tx, table, conditions...)
+}
+
+func (queryShim) UpdateConfig(ctx context.Context, tx database.Tx, table string, values map[string]string) error {
+	return query.UpdateConfig(ctx, tx, table, values)
+}
+
+type transactionShim struct{}
+
+func (transactionShim) Transaction(ctx context.Context, db database.DB, f func(database.Tx) error) error {
+	return query.Transaction(ctx, db, f)
 }
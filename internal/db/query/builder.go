@@ -0,0 +1,382 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/pkg/errors"
+)
+
+// The identifiers the builders accept, which are quoted as per the dialect
+// regardless.
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Condition is a boolean expression of a WHERE clause.
+type Condition interface {
+	build(database.Dialect) (string, []interface{}, error)
+}
+
+// Eq returns a condition matching the rows where the column equals the
+// value, or is NULL if the value is nil.
+func Eq(column string, value interface{}) Condition {
+	return eqCondition{column: column, value: value}
+}
+
+type eqCondition struct {
+	column string
+	value  interface{}
+}
+
+func (c eqCondition) build(dialect database.Dialect) (string, []interface{}, error) {
+	column, err := quoteIdentifier(dialect, c.column)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	if c.value == nil {
+		return fmt.Sprintf("%s IS NULL", column), nil, nil
+	}
+	return fmt.Sprintf("%s = ?", column), []interface{}{c.value}, nil
+}
+
+// In returns a condition matching the rows where the column equals any of
+// the values.
+func In(column string, values ...interface{}) Condition {
+	return inCondition{column: column, values: values}
+}
+
+type inCondition struct {
+	column string
+	values []interface{}
+}
+
+func (c inCondition) build(dialect database.Dialect) (string, []interface{}, error) {
+	column, err := quoteIdentifier(dialect, c.column)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	if len(c.values) == 0 {
+		return "", nil, errors.Errorf("no values to match column %q against", c.column)
+	}
+	return fmt.Sprintf("%s IN %s", column, Params(len(c.values))), c.values, nil
+}
+
+// Expr returns a condition made of the given SQL expression and arguments,
+// which is used as is. It must never be made of untrusted input.
+func Expr(sql string, args ...interface{}) Condition {
+	return exprCondition{sql: sql, args: args}
+}
+
+type exprCondition struct {
+	sql  string
+	args []interface{}
+}
+
+func (c exprCondition) build(dialect database.Dialect) (string, []interface{}, error) {
+	return c.sql, c.args, nil
+}
+
+// SelectBuilder builds a SELECT statement.
+type SelectBuilder struct {
+	columns []string
+	count   bool
+	table   string
+	where   []Condition
+	orderBy []string
+	limit   int
+}
+
+// Select starts building a statement selecting the given columns, or all of
+// them if none is given.
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// SelectCount starts building a statement counting rows.
+func SelectCount() *SelectBuilder {
+	return &SelectBuilder{count: true}
+}
+
+// From sets the table to select from.
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.table = table
+	return b
+}
+
+// Where adds conditions the selected rows must all match.
+func (b *SelectBuilder) Where(conditions ...Condition) *SelectBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// OrderBy adds columns to sort the selected rows by.
+func (b *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, columns...)
+	return b
+}
+
+// Limit sets the maximum number of rows to select.
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Build returns the statement in the given dialect, along with its arguments.
+func (b *SelectBuilder) Build(dialect database.Dialect) (string, []interface{}, error) {
+	columns := "*"
+	switch {
+	case b.count:
+		columns = "COUNT(*)"
+	case len(b.columns) > 0:
+		quoted, err := quoteIdentifiers(dialect, b.columns)
+		if err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+		columns = strings.Join(quoted, ", ")
+	}
+	table, err := quoteIdentifier(dialect, b.table)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	stmt := fmt.Sprintf("SELECT %s FROM %s", columns, table)
+	where, args, err := buildWhere(dialect, b.where)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	stmt += where
+
+	if len(b.orderBy) > 0 {
+		quoted, err := quoteIdentifiers(dialect, b.orderBy)
+		if err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+		stmt += " ORDER BY " + strings.Join(quoted, ", ")
+	}
+	if b.limit > 0 {
+		stmt += " LIMIT " + strconv.Itoa(b.limit)
+	}
+	return stmt, args, nil
+}
+
+// InsertBuilder builds an INSERT statement.
+type InsertBuilder struct {
+	table     string
+	columns   []string
+	rows      [][]interface{}
+	keys      []string
+	returning string
+}
+
+// Insert starts building a statement inserting rows into the given table.
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Columns sets the columns of the inserted rows.
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = columns
+	return b
+}
+
+// Values adds a row to insert, with a value for each of the columns.
+func (b *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// Upsert makes the statement replace any existing row conflicting with an
+// inserted one on the given key columns.
+func (b *InsertBuilder) Upsert(keys ...string) *InsertBuilder {
+	b.keys = keys
+	return b
+}
+
+// Returning makes the statement yield the given column of the inserted rows,
+// if the dialect supports it.
+func (b *InsertBuilder) Returning(column string) *InsertBuilder {
+	b.returning = column
+	return b
+}
+
+// Build returns the statement in the given dialect, along with its arguments.
+func (b *InsertBuilder) Build(dialect database.Dialect) (string, []interface{}, error) {
+	table, err := quoteIdentifier(dialect, b.table)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	columns, err := quoteIdentifiers(dialect, b.columns)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	if len(columns) == 0 {
+		return "", nil, errors.Errorf("no columns to insert")
+	}
+	if len(b.rows) == 0 {
+		return "", nil, errors.Errorf("no rows to insert")
+	}
+
+	var (
+		values []string
+		args   []interface{}
+	)
+	for _, row := range b.rows {
+		if len(row) != len(columns) {
+			return "", nil, errors.Errorf("row has %d values, not %d", len(row), len(columns))
+		}
+		values = append(values, Params(len(row)))
+		args = append(args, row...)
+	}
+
+	var stmt string
+	if len(b.keys) > 0 {
+		// The dialect quotes the identifiers of the upsert itself.
+		if _, err := quoteIdentifiers(dialect, b.keys); err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+		stmt = dialect.Upsert(b.table, b.columns, b.keys, strings.Join(values, ", "))
+	} else {
+		stmt = fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+			table, strings.Join(columns, ", "), strings.Join(values, ", "))
+	}
+
+	if b.returning != "" {
+		if _, err := quoteIdentifier(dialect, b.returning); err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+		stmt += dialect.Returning(b.returning)
+	}
+	return stmt, args, nil
+}
+
+// UpdateBuilder builds an UPDATE statement.
+type UpdateBuilder struct {
+	table   string
+	columns []string
+	values  []interface{}
+	where   []Condition
+}
+
+// Update starts building a statement updating the rows of the given table.
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set sets the column of the updated rows to the value.
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b.columns = append(b.columns, column)
+	b.values = append(b.values, value)
+	return b
+}
+
+// Where adds conditions the updated rows must all match.
+func (b *UpdateBuilder) Where(conditions ...Condition) *UpdateBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// Build returns the statement in the given dialect, along with its arguments.
+func (b *UpdateBuilder) Build(dialect database.Dialect) (string, []interface{}, error) {
+	table, err := quoteIdentifier(dialect, b.table)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	columns, err := quoteIdentifiers(dialect, b.columns)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	if len(columns) == 0 {
+		return "", nil, errors.Errorf("no columns to update")
+	}
+
+	sets := make([]string, len(columns))
+	for i, column := range columns {
+		sets[i] = fmt.Sprintf("%s = ?", column)
+	}
+	stmt := fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(sets, ", "))
+
+	where, args, err := buildWhere(dialect, b.where)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	return stmt + where, append(append([]interface{}{}, b.values...), args...), nil
+}
+
+// DeleteBuilder builds a DELETE statement.
+type DeleteBuilder struct {
+	table string
+	where []Condition
+}
+
+// Delete starts building a statement deleting the rows of the given table.
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where adds conditions the deleted rows must all match.
+func (b *DeleteBuilder) Where(conditions ...Condition) *DeleteBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// Build returns the statement in the given dialect, along with its arguments.
+func (b *DeleteBuilder) Build(dialect database.Dialect) (string, []interface{}, error) {
+	table, err := quoteIdentifier(dialect, b.table)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	where, args, err := buildWhere(dialect, b.where)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	return fmt.Sprintf("DELETE FROM %s", table) + where, args, nil
+}
+
+// Return the WHERE clause matching all the given conditions, along with its
+// arguments. The clause is empty if there are no conditions.
+func buildWhere(dialect database.Dialect, conditions []Condition) (string, []interface{}, error) {
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+
+	var (
+		exprs []string
+		args  []interface{}
+	)
+	for _, condition := range conditions {
+		expr, conditionArgs, err := condition.build(dialect)
+		if err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+		// Raw expressions are parenthesized so that they can't change the
+		// meaning of the other conditions.
+		if _, ok := condition.(exprCondition); ok && len(conditions) > 1 {
+			expr = "(" + expr + ")"
+		}
+		exprs = append(exprs, expr)
+		args = append(args, conditionArgs...)
+	}
+	return " WHERE " + strings.Join(exprs, " AND "), args, nil
+}
+
+// Return the given identifier quoted as per the dialect, or an error if it's
+// not a valid one.
+func quoteIdentifier(dialect database.Dialect, identifier string) (string, error) {
+	if !identifierRegexp.MatchString(identifier) {
+		return "", errors.Errorf("invalid identifier %q", identifier)
+	}
+	return dialect.Quote(identifier), nil
+}
+
+func quoteIdentifiers(dialect database.Dialect, identifiers []string) ([]string, error) {
+	quoted := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		var err error
+		if quoted[i], err = quoteIdentifier(dialect, identifier); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return quoted, nil
+}
@@ -0,0 +1,168 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+)
+
+type builder interface {
+	Build(database.Dialect) (string, []interface{}, error)
+}
+
+func TestBuild(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		builder builder
+		stmt    string
+		args    []interface{}
+	}{
+		{
+			name:    "select all",
+			builder: query.Select().From("cars"),
+			stmt:    `SELECT * FROM "cars"`,
+		},
+		{
+			name: "select",
+			builder: query.Select("id", "brand").From("cars").
+				Where(query.Eq("brand", "fiat"), query.In("colour", "red", "blue")).
+				OrderBy("brand", "id").
+				Limit(10),
+			stmt: `SELECT "id", "brand" FROM "cars" WHERE "brand" = ? AND "colour" IN (?, ?) ORDER BY "brand", "id" LIMIT 10`,
+			args: []interface{}{"fiat", "red", "blue"},
+		},
+		{
+			name:    "select null",
+			builder: query.Select("id").From("cars").Where(query.Eq("colour", nil)),
+			stmt:    `SELECT "id" FROM "cars" WHERE "colour" IS NULL`,
+		},
+		{
+			name:    "select with expression",
+			builder: query.Select("id").From("cars").Where(query.Expr("id > ? OR id < ?", 10, 2)),
+			stmt:    `SELECT "id" FROM "cars" WHERE id > ? OR id < ?`,
+			args:    []interface{}{10, 2},
+		},
+		{
+			name:    "select with expressions",
+			builder: query.Select("id").From("cars").Where(query.Expr("id > ? OR id < ?", 10, 2), query.Eq("brand", "fiat")),
+			stmt:    `SELECT "id" FROM "cars" WHERE (id > ? OR id < ?) AND "brand" = ?`,
+			args:    []interface{}{10, 2, "fiat"},
+		},
+		{
+			name:    "count",
+			builder: query.SelectCount().From("cars").Where(query.Eq("brand", "fiat")),
+			stmt:    `SELECT COUNT(*) FROM "cars" WHERE "brand" = ?`,
+			args:    []interface{}{"fiat"},
+		},
+		{
+			name:    "insert",
+			builder: query.Insert("cars").Columns("brand", "colour").Values("fiat", "red").Values("ferrari", "blue"),
+			stmt:    `INSERT INTO "cars" ("brand", "colour") VALUES (?, ?), (?, ?)`,
+			args:    []interface{}{"fiat", "red", "ferrari", "blue"},
+		},
+		{
+			name:    "upsert",
+			builder: query.Insert("cars").Columns("id", "brand").Values(1, "fiat").Upsert("id").Returning("id"),
+			stmt:    `INSERT INTO "cars" ("id", "brand") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "brand" = EXCLUDED."brand"`,
+			args:    []interface{}{1, "fiat"},
+		},
+		{
+			name:    "update",
+			builder: query.Update("cars").Set("brand", "fiat").Set("colour", "red").Where(query.Eq("id", 1)),
+			stmt:    `UPDATE "cars" SET "brand" = ?, "colour" = ? WHERE "id" = ?`,
+			args:    []interface{}{"fiat", "red", 1},
+		},
+		{
+			name:    "delete",
+			builder: query.Delete("cars").Where(query.In("id", 1, 2)),
+			stmt:    `DELETE FROM "cars" WHERE "id" IN (?, ?)`,
+			args:    []interface{}{1, 2},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stmt, args, err := test.builder.Build(sqlite)
+			if err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+			if expected, actual := test.stmt, stmt; expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+			if expected, actual := test.args, args; !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestBuildWithPostgres(t *testing.T) {
+	dialect, err := database.NewDialect(database.Postgres)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	stmt, _, err := query.Insert("cars").Columns("id", "brand").Values(1, "fiat").Upsert("id").Returning("id").Build(dialect)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	expected := `INSERT INTO "cars" ("id", "brand") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "brand" = EXCLUDED."brand" RETURNING "id"`
+	if actual := stmt; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestBuildWithInvalidStatements(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		builder builder
+	}{
+		{
+			name:    "invalid table",
+			builder: query.Select().From("cars; DROP TABLE cars"),
+		},
+		{
+			name:    "no table",
+			builder: query.Delete(""),
+		},
+		{
+			name:    "invalid column",
+			builder: query.Select(`id"`).From("cars"),
+		},
+		{
+			name:    "invalid condition",
+			builder: query.Delete("cars").Where(query.Eq("1=1 OR id", 1)),
+		},
+		{
+			name:    "invalid order",
+			builder: query.Select().From("cars").OrderBy("id DESC"),
+		},
+		{
+			name:    "empty in",
+			builder: query.Delete("cars").Where(query.In("id")),
+		},
+		{
+			name:    "no columns",
+			builder: query.Insert("cars").Values(1),
+		},
+		{
+			name:    "no rows",
+			builder: query.Insert("cars").Columns("id"),
+		},
+		{
+			name:    "mismatched row",
+			builder: query.Insert("cars").Columns("id", "brand").Values(1),
+		},
+		{
+			name:    "no updates",
+			builder: query.Update("cars").Where(query.Eq("id", 1)),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := test.builder.Build(sqlite)
+			if err == nil {
+				t.Errorf("expected err not to be nil")
+			}
+		})
+	}
+}
@@ -1,8 +1,7 @@
 package query
 
 import (
-	"fmt"
-	"strings"
+	"context"
 
 	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/pkg/errors"
@@ -10,16 +9,16 @@ import (
 
 // SelectConfig executes a query statement against a "config" table, which must
 // have 'key' and 'value' columns. By default this query returns all keys, but
-// additional WHERE filters can be specified.
+// additional conditions can be specified, which must all match.
 //
 // Returns a map of key names to their associated values.
-func SelectConfig(tx database.Tx, table string, where string, args ...interface{}) (map[string]string, error) {
-	query := fmt.Sprintf("SELECT key, value FROM %s", table)
-	if where != "" {
-		query += fmt.Sprintf(" WHERE %s", where)
+func SelectConfig(ctx context.Context, tx database.Tx, table string, conditions ...Condition) (map[string]string, error) {
+	query, args, err := Select("key", "value").From(table).Where(conditions...).Build(tx.Dialect())
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
 
-	rows, err := tx.Query(query, args...)
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -42,7 +41,7 @@ func SelectConfig(tx database.Tx, table string, where string, args ...interface{
 
 // UpdateConfig updates the given keys in the given table. Config keys set to
 // empty values will be deleted.
-func UpdateConfig(tx database.Tx, table string, values map[string]string) error {
+func UpdateConfig(ctx context.Context, tx database.Tx, table string, values map[string]string) error {
 	var deletes []string
 	changes := make(map[string]string)
 
@@ -54,10 +53,10 @@ func UpdateConfig(tx database.Tx, table string, values map[string]string) error
 		changes[key] = value
 	}
 
-	if err := UpsertConfig(tx, table, changes); err != nil {
+	if err := UpsertConfig(ctx, tx, table, changes); err != nil {
 		return errors.Wrap(err, "updating values failed")
 	}
-	if err := DeleteConfig(tx, table, deletes); err != nil {
+	if err := DeleteConfig(ctx, tx, table, deletes); err != nil {
 		return errors.Wrap(err, "deleting values failed")
 	}
 
@@ -66,37 +65,39 @@ func UpdateConfig(tx database.Tx, table string, values map[string]string) error
 
 // UpsertConfig defines a way to Insert or updates the key/value rows of the
 // given config table.
-func UpsertConfig(tx database.Tx, table string, values map[string]string) error {
+func UpsertConfig(ctx context.Context, tx database.Tx, table string, values map[string]string) error {
 	if len(values) == 0 {
 		return nil
 	}
 
-	var exprs []string
-	var params []interface{}
-
-	query := fmt.Sprintf("INSERT OR REPLACE INTO %s (key, value) VALUES", table)
+	builder := Insert(table).Columns("key", "value").Upsert("key")
 	for key, value := range values {
-		exprs = append(exprs, "(?, ?)")
-		params = append(params, key)
-		params = append(params, value)
+		builder = builder.Values(key, value)
 	}
-	_, err := tx.Exec(fmt.Sprintf("%s %s", query, strings.Join(exprs, ",")), params...)
+	query, params, err := builder.Build(tx.Dialect())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = tx.ExecContext(ctx, query, params...)
 	return err
 }
 
 // DeleteConfig defines a way to delete the given key rows from the given
 // config table.
-func DeleteConfig(tx database.Tx, table string, keys []string) error {
+func DeleteConfig(ctx context.Context, tx database.Tx, table string, keys []string) error {
 	n := len(keys)
 	if n == 0 {
 		return nil
 	}
 
-	query := fmt.Sprintf("DELETE FROM %s WHERE key IN %s", table, Params(n))
 	values := make([]interface{}, n)
 	for i, key := range keys {
 		values[i] = key
 	}
-	_, err := tx.Exec(query, values...)
+	query, args, err := Delete(table).Where(In("key", values...)).Build(tx.Dialect())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
 	return errors.WithStack(err)
 }
@@ -3,6 +3,7 @@
 package query_test
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
 	"testing"
@@ -16,7 +17,7 @@ func TestSelectConfig_Selects(t *testing.T) {
 	tx, close := newTxForConfig(t)
 	defer close()
 
-	values, err := query.SelectConfig(tx, "test", "")
+	values, err := query.SelectConfig(context.Background(), tx, "test")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -33,7 +34,7 @@ func TestSelectConfig_WithFilters(t *testing.T) {
 	tx, close := newTxForConfig(t)
 	defer close()
 
-	values, err := query.SelectConfig(tx, "test", "key=?", "bar")
+	values, err := query.SelectConfig(context.Background(), tx, "test", query.Eq("key", "bar"))
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -51,12 +52,12 @@ func TestUpdateConfig_NewKeys(t *testing.T) {
 	defer close()
 
 	values := map[string]string{"foo": "y"}
-	err := query.UpdateConfig(tx, "test", values)
+	err := query.UpdateConfig(context.Background(), tx, "test", values)
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	values, err = query.SelectConfig(tx, "test", "")
+	values, err = query.SelectConfig(context.Background(), tx, "test")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -76,12 +77,12 @@ func TestDeleteConfig_Delete(t *testing.T) {
 
 	values := map[string]string{"foo": ""}
 
-	err := query.UpdateConfig(tx, "test", values)
+	err := query.UpdateConfig(context.Background(), tx, "test", values)
 
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
-	values, err = query.SelectConfig(tx, "test", "")
+	values, err = query.SelectConfig(context.Background(), tx, "test")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -96,7 +97,7 @@ func TestDeleteConfig_Delete(t *testing.T) {
 // Return a new transaction against an in-memory SQLite database with a single
 // test table populated with a few rows.
 func newTxForConfig(t *testing.T) (database.Tx, func()) {
-	db, err := sql.Open(database.DriverName(), connectionInfo())
+	db, err := sql.Open(database.Postgres, connectionInfo())
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -111,7 +112,7 @@ func newTxForConfig(t *testing.T) (database.Tx, func()) {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	tx, err := database.ShimTx(db.Begin())
+	tx, err := database.NewShimDB(db, postgres).Begin()
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -15,10 +16,11 @@ func TestSelectConfig(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT key, value FROM config").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT "key", "value" FROM "config"`).Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(StringScanMatcher("foo"), StringScanMatcher("bar")).Return(nil),
 		mockRows.EXPECT().Next().Return(false),
@@ -26,7 +28,7 @@ func TestSelectConfig(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	records, err := query.SelectConfig(mockTx, "config", "")
+	records, err := query.SelectConfig(context.Background(), mockTx, "config")
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -40,10 +42,11 @@ func TestSelectConfigWithWhereClause(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT key, value FROM config WHERE value=?", "bar").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT "key", "value" FROM "config" WHERE "value" = ?`, "bar").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(StringScanMatcher("foo"), StringScanMatcher("bar")).Return(nil),
 		mockRows.EXPECT().Next().Return(false),
@@ -51,7 +54,7 @@ func TestSelectConfigWithWhereClause(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	records, err := query.SelectConfig(mockTx, "config", "value=?", "bar")
+	records, err := query.SelectConfig(context.Background(), mockTx, "config", query.Eq("value", "bar"))
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -65,13 +68,14 @@ func TestSelectConfigWithQueryFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT key, value FROM config").Return(mockRows, errors.New("bad")),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT "key", "value" FROM "config"`).Return(mockRows, errors.New("bad")),
 	)
 
-	_, err := query.SelectConfig(mockTx, "config", "")
+	_, err := query.SelectConfig(context.Background(), mockTx, "config")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -82,16 +86,17 @@ func TestSelectConfigWithScanFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT key, value FROM config").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT "key", "value" FROM "config"`).Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(StringScanMatcher("foo"), StringScanMatcher("bar")).Return(errors.New("bad")),
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectConfig(mockTx, "config", "")
+	_, err := query.SelectConfig(context.Background(), mockTx, "config")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -102,14 +107,15 @@ func TestUpdateObject(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("INSERT OR REPLACE INTO config (key, value) VALUES (?, ?)", []interface{}{"foo", "bar"}).Return(mockResult, nil),
-		mockTx.EXPECT().Exec("DELETE FROM config WHERE key IN (?)", "baz").Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "config" ("key", "value") VALUES (?, ?) ON CONFLICT ("key") DO UPDATE SET "value" = EXCLUDED."value"`, []interface{}{"foo", "bar"}).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DELETE FROM "config" WHERE "key" IN (?)`, "baz").Return(mockResult, nil),
 	)
 
-	err := query.UpdateConfig(mockTx, "config", map[string]string{"foo": "bar", "baz": ""})
+	err := query.UpdateConfig(context.Background(), mockTx, "config", map[string]string{"foo": "bar", "baz": ""})
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -120,13 +126,14 @@ func TestUpdateObjectWithUpsertFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("INSERT OR REPLACE INTO config (key, value) VALUES (?, ?)", []interface{}{"foo", "bar"}).Return(mockResult, errors.New("bad")),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "config" ("key", "value") VALUES (?, ?) ON CONFLICT ("key") DO UPDATE SET "value" = EXCLUDED."value"`, []interface{}{"foo", "bar"}).Return(mockResult, errors.New("bad")),
 	)
 
-	err := query.UpdateConfig(mockTx, "config", map[string]string{"foo": "bar", "baz": ""})
+	err := query.UpdateConfig(context.Background(), mockTx, "config", map[string]string{"foo": "bar", "baz": ""})
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -137,14 +144,15 @@ func TestUpdateObjectWithDeleteFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("INSERT OR REPLACE INTO config (key, value) VALUES (?, ?)", []interface{}{"foo", "bar"}).Return(mockResult, nil),
-		mockTx.EXPECT().Exec("DELETE FROM config WHERE key IN (?)", "baz").Return(mockResult, errors.New("bad")),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "config" ("key", "value") VALUES (?, ?) ON CONFLICT ("key") DO UPDATE SET "value" = EXCLUDED."value"`, []interface{}{"foo", "bar"}).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DELETE FROM "config" WHERE "key" IN (?)`, "baz").Return(mockResult, errors.New("bad")),
 	)
 
-	err := query.UpdateConfig(mockTx, "config", map[string]string{"foo": "bar", "baz": ""})
+	err := query.UpdateConfig(context.Background(), mockTx, "config", map[string]string{"foo": "bar", "baz": ""})
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -1,24 +1,21 @@
 package query
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/pkg/errors"
 )
 
-// StmtCount provides a function for creating the sql template for querying.
-var StmtCount = func(table, where string) string {
-	stmt := fmt.Sprintf("SELECT COUNT(*) FROM %s", table)
-	if where != "" {
-		stmt += fmt.Sprintf(" WHERE %s", where)
+// Count returns the number of rows in the given table, optionally filtered by
+// the given conditions, which must all match.
+func Count(ctx context.Context, tx database.Tx, table string, conditions ...Condition) (int, error) {
+	stmt, args, err := SelectCount().From(table).Where(conditions...).Build(tx.Dialect())
+	if err != nil {
+		return -1, errors.WithStack(err)
 	}
-	return stmt
-}
 
-// Count returns the number of rows in the given table.
-func Count(tx database.Tx, table, where string, args ...interface{}) (int, error) {
-	rows, err := tx.Query(StmtCount(table, where), args...)
+	rows, err := tx.QueryContext(ctx, stmt, args...)
 	if err != nil {
 		return -1, err
 	}
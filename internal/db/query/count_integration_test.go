@@ -3,6 +3,7 @@
 package query_test
 
 import (
+	"context"
 	"database/sql"
 	"strconv"
 	"testing"
@@ -14,23 +15,19 @@ import (
 // Count returns the current number of rows.
 func TestCount_Cases(t *testing.T) {
 	cases := []struct {
-		where string
-		args  []interface{}
-		count int
+		conditions []query.Condition
+		count      int
 	}{
 		{
-			"id=?",
-			[]interface{}{999},
+			[]query.Condition{query.Eq("id", 999)},
 			0,
 		},
 		{
-			"id=?",
-			[]interface{}{1},
+			[]query.Condition{query.Eq("id", 1)},
 			1,
 		},
 		{
-			"",
-			[]interface{}{},
+			nil,
 			2,
 		},
 	}
@@ -39,7 +36,7 @@ func TestCount_Cases(t *testing.T) {
 			tx, close := newTxForCount(t)
 			defer close()
 
-			count, err := query.Count(tx, "test", c.where, c.args...)
+			count, err := query.Count(context.Background(), tx, "test", c.conditions...)
 			if err != nil {
 				t.Errorf("expected err to be nil: %v", err)
 			}
@@ -53,7 +50,7 @@ func TestCount_Cases(t *testing.T) {
 // Return a new transaction against an in-memory SQLite database with a single
 // test table and a few rows.
 func newTxForCount(t *testing.T) (database.Tx, func()) {
-	db, err := sql.Open(database.DriverName(), connectionInfo())
+	db, err := sql.Open(database.Postgres, connectionInfo())
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -68,7 +65,7 @@ func newTxForCount(t *testing.T) (database.Tx, func()) {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	tx, err := database.ShimTx(db.Begin())
+	tx, err := database.NewShimDB(db, postgres).Begin()
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bicycolet/bicycolet/internal/db/query"
@@ -14,10 +15,11 @@ func TestCount(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(query.StmtCount("schema", "a=b")).Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT COUNT(*) FROM "schema" WHERE "a" = ?`, "b").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(IntScanMatcher(1)).Return(nil),
 		mockRows.EXPECT().Next().Return(false),
@@ -25,7 +27,7 @@ func TestCount(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	count, err := query.Count(mockTx, "schema", "a=b")
+	count, err := query.Count(context.Background(), mockTx, "schema", query.Eq("a", "b"))
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -39,13 +41,14 @@ func TestCountWithQueryFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(query.StmtCount("schema", "a=b")).Return(mockRows, errors.New("bad")),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT COUNT(*) FROM "schema" WHERE "a" = ?`, "b").Return(mockRows, errors.New("bad")),
 	)
 
-	_, err := query.Count(mockTx, "schema", "a=b")
+	_, err := query.Count(context.Background(), mockTx, "schema", query.Eq("a", "b"))
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -56,15 +59,16 @@ func TestCountWithNoNext(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(query.StmtCount("schema", "a=b")).Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT COUNT(*) FROM "schema" WHERE "a" = ?`, "b").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(false),
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.Count(mockTx, "schema", "a=b")
+	_, err := query.Count(context.Background(), mockTx, "schema", query.Eq("a", "b"))
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -75,16 +79,17 @@ func TestCountWithScanFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(query.StmtCount("schema", "a=b")).Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT COUNT(*) FROM "schema" WHERE "a" = ?`, "b").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(IntScanMatcher(1)).Return(errors.New("bad")),
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.Count(mockTx, "schema", "a=b")
+	_, err := query.Count(context.Background(), mockTx, "schema", query.Eq("a", "b"))
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -95,17 +100,18 @@ func TestCountWithMoreRows(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(query.StmtCount("schema", "a=b")).Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT COUNT(*) FROM "schema" WHERE "a" = ?`, "b").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(IntScanMatcher(1)).Return(nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.Count(mockTx, "schema", "a=b")
+	_, err := query.Count(context.Background(), mockTx, "schema", query.Eq("a", "b"))
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -116,10 +122,11 @@ func TestCountWithErrFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(query.StmtCount("schema", "a=b")).Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT COUNT(*) FROM "schema" WHERE "a" = ?`, "b").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(IntScanMatcher(1)).Return(nil),
 		mockRows.EXPECT().Next().Return(false),
@@ -127,7 +134,7 @@ func TestCountWithErrFailure(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.Count(mockTx, "schema", "a=b")
+	_, err := query.Count(context.Background(), mockTx, "schema", query.Eq("a", "b"))
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
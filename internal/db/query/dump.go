@@ -0,0 +1,170 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/pkg/errors"
+)
+
+// The format used for dumping time values, which is the same one the sqlite
+// driver uses for writing them.
+const dumpTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// Dump returns a text of SQL commands that can be used to recreate all the
+// tables in the database, along with all their rows. Executing the dump
+// against an empty database yields a copy of the dumped one.
+func Dump(ctx context.Context, tx database.Tx) (string, error) {
+	var names, statements []string
+	dest := func(i int) []interface{} {
+		names = append(names, "")
+		statements = append(statements, "")
+		return []interface{}{&names[i], &statements[i]}
+	}
+	dialect := tx.Dialect()
+	if err := SelectObjects(ctx, tx, dest, dialect.StmtTables()); err != nil {
+		return "", errors.Wrap(err, "failed to fetch tables")
+	}
+
+	var dump []string
+	for i, name := range names {
+		rows, err := dumpRows(ctx, tx, dialect, name)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to dump table %q", name)
+		}
+		dump = append(dump, statements[i]+";")
+		dump = append(dump, rows...)
+	}
+	return strings.Join(dump, "\n"), nil
+}
+
+// Restore replaces all the tables in the database with the ones in the given
+// dump, as returned by Dump. The statements of the dump are executed one by
+// one, as split by SplitStatements.
+func Restore(ctx context.Context, tx database.Tx, dump string) error {
+	dialect := tx.Dialect()
+	if _, err := tx.ExecContext(ctx, dialect.StmtDeferForeignKeys()); err != nil {
+		return errors.Wrap(err, "failed to defer foreign keys")
+	}
+
+	names, err := SelectStrings(ctx, tx, dialect.StmtTableNames())
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch tables")
+	}
+	// Drop the tables in the reverse order they were created in, so that
+	// tables are dropped before the ones they reference.
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if _, err := tx.ExecContext(ctx, "DROP TABLE "+dialect.Quote(name)); err != nil {
+			return errors.Wrapf(err, "failed to drop table %q", name)
+		}
+	}
+
+	// The statements are executed one by one, so that the failing one is
+	// reported by its line.
+	err = ExecScript(ctx, tx, dump)
+	return errors.Wrap(err, "failed to execute dump")
+}
+
+// Return an insert statement for every row of the given table, followed by a
+// statement moving the sequence of its id column past them if the dialect
+// needs one.
+func dumpRows(ctx context.Context, tx database.Tx, dialect database.Dialect, table string) ([]string, error) {
+	stmt, args, err := Select().From(table).Build(dialect)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	rows, err := tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	columns := make([]string, len(types))
+	for i, typ := range types {
+		columns[i] = typ.Name()
+	}
+
+	var statements []string
+	for rows.Next() {
+		raw := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range raw {
+			dest[i] = &raw[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		values := make([]string, len(raw))
+		for i, value := range raw {
+			if values[i], err = dumpValue(dialect, types[i], value); err != nil {
+				return nil, errors.Wrapf(err, "column %q", columns[i])
+			}
+		}
+		statements = append(statements, fmt.Sprintf(
+			"INSERT INTO %s VALUES(%s);", dialect.Quote(table), strings.Join(values, ", ")))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(statements) > 0 && hasColumn(columns, "id") {
+		if stmt := dialect.StmtResetSequence(table, "id"); stmt != "" {
+			statements = append(statements, stmt+";")
+		}
+	}
+	return statements, nil
+}
+
+func hasColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the SQL literal of the given value of a column of the given type.
+// Drivers yield the values of text columns as bytes as well, which are only
+// dumped as binary data if the column holds it.
+func dumpValue(dialect database.Dialect, typ database.ColumnType, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case string:
+		return quote(v), nil
+	case []byte:
+		if strings.EqualFold(typ.DatabaseTypeName(), dialect.Blob()) {
+			return dialect.BlobLiteral(v), nil
+		}
+		return quote(string(v)), nil
+	case time.Time:
+		return quote(v.Format(dumpTimeFormat)), nil
+	default:
+		return "", errors.Errorf("unsupported type %T", value)
+	}
+}
+
+func quote(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
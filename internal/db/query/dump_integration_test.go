@@ -0,0 +1,58 @@
+// +build integration
+
+package query_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/query"
+)
+
+// Booleans and binary data survive a dump and a restore.
+func TestDumpAndRestore_Postgres(t *testing.T) {
+	db := newDB(t)
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("CREATE TABLE dump_test (id INTEGER, flag BOOLEAN, data BYTEA, name TEXT)"); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO dump_test VALUES (1, ?, ?, ?), (2, ?, ?, ?)",
+		true, []byte{0x00, '\\', 0xff, '\''}, `a\b`,
+		false, []byte{}, "c"); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	dump, err := query.Dump(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if err := query.Restore(context.Background(), tx, dump); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	type row struct {
+		ID   int64  `db:"id"`
+		Flag bool   `db:"flag"`
+		Data []byte `db:"data"`
+		Name string `db:"name"`
+	}
+	var rows []row
+	if err := query.SelectStructs(context.Background(), tx, &rows, "SELECT id, flag, data, name FROM dump_test ORDER BY id"); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	expected := []row{
+		{ID: 1, Flag: true, Data: []byte{0x00, '\\', 0xff, '\''}, Name: `a\b`},
+		{ID: 2, Flag: false, Data: []byte{}, Name: "c"},
+	}
+	if actual := rows; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
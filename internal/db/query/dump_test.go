@@ -0,0 +1,264 @@
+package query_test
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/db/query/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+)
+
+func TestDump(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockTables := mocks.NewMockRows(ctrl)
+	mockRows := mocks.NewMockRows(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().QueryContext(gomock.Any(), sqlite.StmtTables()).Return(mockTables, nil),
+		mockTables.EXPECT().Next().Return(true),
+		mockTables.EXPECT().Scan(StringScanMatcher("test"), StringScanMatcher("CREATE TABLE test (id INTEGER, name TEXT, data BLOB)")).Return(nil),
+		mockTables.EXPECT().Next().Return(false),
+		mockTables.EXPECT().Err().Return(nil),
+		mockTables.EXPECT().Close().Return(nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT * FROM "test"`).Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			columnType(ctrl, "id", "INTEGER", true),
+			columnType(ctrl, "name", "TEXT", true),
+			columnType(ctrl, "data", "BLOB", true),
+		}, nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(ValueScanMatcher(int64(1)), ValueScanMatcher("it's"), gomock.Any()).Return(nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(ValueScanMatcher(int64(2)), gomock.Any(), ValueScanMatcher([]byte("x"))).Return(nil),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+
+	dump, err := query.Dump(context.Background(), mockTx)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	expected := `CREATE TABLE test (id INTEGER, name TEXT, data BLOB);
+INSERT INTO "test" VALUES(1, 'it''s', NULL);
+INSERT INTO "test" VALUES(2, NULL, X'78');`
+	if actual := dump; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestDumpAndRestore(t *testing.T) {
+	source := openSQLite(t)
+	defer source.Close()
+
+	err := query.Transaction(context.Background(), source, func(tx database.Tx) error {
+		if _, err := tx.Exec("CREATE TABLE test (id INTEGER, flag BOOLEAN, data BLOB, name TEXT)"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("INSERT INTO test VALUES (1, ?, ?, ?), (2, ?, ?, ?)",
+			true, []byte{0x00, '\\', 0xff, '\''}, `a\b`,
+			false, []byte{}, "c")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	var dump string
+	if err := query.Transaction(context.Background(), source, func(tx database.Tx) error {
+		dump, err = query.Dump(context.Background(), tx)
+		return err
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	target := openSQLite(t)
+	defer target.Close()
+
+	type row struct {
+		ID   int64  `db:"id"`
+		Flag bool   `db:"flag"`
+		Data []byte `db:"data"`
+		Name string `db:"name"`
+	}
+	var rows []row
+	if err := query.Transaction(context.Background(), target, func(tx database.Tx) error {
+		if err := query.Restore(context.Background(), tx, dump); err != nil {
+			return err
+		}
+		return query.SelectStructs(context.Background(), tx, &rows, "SELECT id, flag, data, name FROM test ORDER BY id")
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	expected := []row{
+		{ID: 1, Flag: true, Data: []byte{0x00, '\\', 0xff, '\''}, Name: `a\b`},
+		{ID: 2, Flag: false, Data: []byte{}, Name: "c"},
+	}
+	if actual := rows; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestDumpWithUnsupportedType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockTables := mocks.NewMockRows(ctrl)
+	mockRows := mocks.NewMockRows(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().QueryContext(gomock.Any(), sqlite.StmtTables()).Return(mockTables, nil),
+		mockTables.EXPECT().Next().Return(true),
+		mockTables.EXPECT().Scan(StringScanMatcher("test"), StringScanMatcher("CREATE TABLE test (id INTEGER)")).Return(nil),
+		mockTables.EXPECT().Next().Return(false),
+		mockTables.EXPECT().Err().Return(nil),
+		mockTables.EXPECT().Close().Return(nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), `SELECT * FROM "test"`).Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			columnType(ctrl, "id", "INTEGER", true),
+		}, nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(ValueScanMatcher(struct{}{})).Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+
+	_, err := query.Dump(context.Background(), mockTx)
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestDumpWithQueryFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+
+	gomock.InOrder(
+		mockTx.EXPECT().QueryContext(gomock.Any(), sqlite.StmtTables()).Return(nil, errors.New("bad")),
+	)
+
+	_, err := query.Dump(context.Background(), mockTx)
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+	mockColumnType := mocks.NewMockColumnType(ctrl)
+	mockResult := mocks.NewMockResult(ctrl)
+
+	dump := "CREATE TABLE test (id INTEGER);\nINSERT INTO \"test\" VALUES(1);"
+
+	gomock.InOrder(
+		mockTx.EXPECT().ExecContext(gomock.Any(), sqlite.StmtDeferForeignKeys()).Return(mockResult, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), sqlite.StmtTableNames()).Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			mockColumnType,
+		}, nil),
+		mockColumnType.EXPECT().DatabaseTypeName().Return("TEXT"),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(StringScanMatcher("schema")).Return(nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(StringScanMatcher("test")).Return(nil),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DROP TABLE "test"`).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DROP TABLE "schema"`).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), "CREATE TABLE test (id INTEGER)").Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "test" VALUES(1)`).Return(mockResult, nil),
+	)
+
+	if err := query.Restore(context.Background(), mockTx, dump); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
+
+func TestRestoreWithDropFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+	mockColumnType := mocks.NewMockColumnType(ctrl)
+	mockResult := mocks.NewMockResult(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().ExecContext(gomock.Any(), sqlite.StmtDeferForeignKeys()).Return(mockResult, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), sqlite.StmtTableNames()).Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			mockColumnType,
+		}, nil),
+		mockColumnType.EXPECT().DatabaseTypeName().Return("TEXT"),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(StringScanMatcher("test")).Return(nil),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DROP TABLE "test"`).Return(nil, errors.New("bad")),
+	)
+
+	if err := query.Restore(context.Background(), mockTx, "CREATE TABLE test (id INTEGER);"); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestRestoreWithExecFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+	mockColumnType := mocks.NewMockColumnType(ctrl)
+	mockResult := mocks.NewMockResult(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().ExecContext(gomock.Any(), sqlite.StmtDeferForeignKeys()).Return(mockResult, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), sqlite.StmtTableNames()).Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			mockColumnType,
+		}, nil),
+		mockColumnType.EXPECT().DatabaseTypeName().Return("TEXT"),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), "CREATE TABLE test (id INTEGER)").Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "test" VALUES('a')`).Return(nil, errors.New("bad")),
+	)
+
+	err := query.Restore(context.Background(), mockTx, "CREATE TABLE test (id INTEGER);\nINSERT INTO \"test\" VALUES('a');\n")
+	if expected, actual := "failed to execute dump: failed to execute statement at line 2: bad", err.Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+// Return a new in-memory SQLite database.
+func openSQLite(t *testing.T) database.DB {
+	db, err := sql.Open(database.SQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	return database.NewShimDB(db, sqlite)
+}
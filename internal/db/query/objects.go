@@ -1,8 +1,8 @@
 package query
 
 import (
-	"fmt"
-	"strings"
+	"context"
+	"reflect"
 
 	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/pkg/errors"
@@ -15,11 +15,27 @@ type Dest func(i int) []interface{}
 
 // SelectObjects executes a statement which must yield rows with a specific
 // columns schema. It invokes the given Dest hook for each yielded row.
-func SelectObjects(tx database.Tx, dest Dest, query string, args ...interface{}) error {
-	rows, err := tx.Query(query, args...)
+func SelectObjects(ctx context.Context, tx database.Tx, dest Dest, query string, args ...interface{}) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	return scanObjects(rows, dest)
+}
+
+// SelectObjectsStmt is like SelectObjects, but executes the given prepared
+// statement instead of parsing the query again.
+func SelectObjectsStmt(ctx context.Context, stmt database.Stmt, dest Dest, args ...interface{}) error {
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return scanObjects(rows, dest)
+}
+
+// Scan every row into the objects returned by the given Dest hook, closing
+// the rows once done.
+func scanObjects(rows database.Rows, dest Dest) error {
 	defer rows.Close()
 
 	for i := 0; rows.Next(); i++ {
@@ -27,7 +43,7 @@ func SelectObjects(tx database.Tx, dest Dest, query string, args ...interface{})
 			return errors.WithStack(err)
 		}
 	}
-	err = rows.Err()
+	err := rows.Err()
 	return errors.WithStack(err)
 }
 
@@ -37,7 +53,7 @@ func SelectObjects(tx database.Tx, dest Dest, query string, args ...interface{})
 // UpsertObject(tx, "cars", []string{"id", "brand"}, []interface{}{1, "ferrari"})
 //
 // The number of elements in 'columns' must match the one in 'values'.
-func UpsertObject(tx database.Tx, table string, columns []string, values []interface{}) (int64, error) {
+func UpsertObject(ctx context.Context, tx database.Tx, table string, columns []string, values []interface{}) (int64, error) {
 	n := len(columns)
 	if n == 0 {
 		return -1, errors.Errorf("columns length is zero")
@@ -46,28 +62,83 @@ func UpsertObject(tx database.Tx, table string, columns []string, values []inter
 		return -1, errors.Errorf("columns length does not match values length")
 	}
 
-	stmt := fmt.Sprintf(
-		"INSERT OR REPLACE INTO %s (%s) VALUES %s",
-		table, strings.Join(columns, ", "), Params(n))
-	result, err := tx.Exec(stmt, values...)
+	id, err := upsert(ctx, tx, table, "id", columns, values)
+	return id, errors.WithStack(err)
+}
+
+// Insert or replace a row of the given table, whose primary key is the given
+// column, and return its id.
+func upsert(ctx context.Context, tx database.Tx, table, key string, columns []string, values []interface{}) (int64, error) {
+	dialect := tx.Dialect()
+	stmt, args, err := Insert(table).Columns(columns...).Values(values...).Upsert(key).Returning(key).Build(dialect)
 	if err != nil {
 		return -1, errors.WithStack(err)
 	}
+
+	// Dialects that can't report the last insert id hand it back as a row
+	// instead.
+	if dialect.Returning(key) != "" {
+		rows, err := tx.QueryContext(ctx, stmt, args...)
+		if err != nil {
+			return -1, errors.WithStack(err)
+		}
+		defer rows.Close()
+		if !rows.Next() {
+			return -1, errors.Errorf("upsert returned no rows")
+		}
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return -1, errors.WithStack(err)
+		}
+		return id, errors.WithStack(rows.Err())
+	}
+
+	result, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+
 	id, err := result.LastInsertId()
 	if err != nil {
 		return -1, errors.WithStack(err)
 	}
+
+	// Updating the row conflicting on the given key leaves the last insert
+	// id alone, so that key is the id of the row.
+	for i, column := range columns {
+		if column != key {
+			continue
+		}
+		if key, ok := integer(values[i]); ok {
+			id = key
+		}
+	}
 	return id, nil
 }
 
+// Return the given value as an int64, if it's an integer.
+func integer(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
 // DeleteObject removes the row identified by the given ID. The given table
 // must have a primary key column called 'id'.
 //
 // It returns a flag indicating if a matching row was actually found and
 // deleted or not.
-func DeleteObject(tx database.Tx, table string, id int64) (bool, error) {
-	stmt := fmt.Sprintf("DELETE FROM %s WHERE id=?", table)
-	result, err := tx.Exec(stmt, id)
+func DeleteObject(ctx context.Context, tx database.Tx, table string, id int64) (bool, error) {
+	stmt, args, err := Delete(table).Where(Eq("id", id)).Build(tx.Dialect())
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	result, err := tx.ExecContext(ctx, stmt, args...)
 	if err != nil {
 		return false, errors.WithStack(err)
 	}
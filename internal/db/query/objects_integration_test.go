@@ -3,6 +3,7 @@
 package query_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -33,7 +34,7 @@ func TestSelectObjects_Error(t *testing.T) {
 			tx, close := newTxForObjects(t)
 			defer close()
 
-			err := query.SelectObjects(tx, c.dest, c.query)
+			err := query.SelectObjects(context.Background(), tx, c.dest, c.query)
 			if expected, actual := c.err, err.Error(); expected != actual {
 				t.Errorf("expected: %v, actual: %v", expected, actual)
 			}
@@ -63,7 +64,7 @@ func TestSelectObjects_Success(t *testing.T) {
 	}
 
 	stmt := "SELECT id, name FROM test WHERE name=?"
-	err := query.SelectObjects(tx, dest, stmt, "bar")
+	err := query.SelectObjects(context.Background(), tx, dest, stmt, "bar")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -99,7 +100,7 @@ func TestUpsertObject_Error(t *testing.T) {
 			tx, close := newTxForObjects(t)
 			defer close()
 
-			id, err := query.UpsertObject(tx, "foo", c.columns, c.values)
+			id, err := query.UpsertObject(context.Background(), tx, "foo", c.columns, c.values)
 			if expected, actual := int64(-1), id; expected != actual {
 				t.Errorf("expected: %v, actual: %v", expected, actual)
 			}
@@ -115,7 +116,7 @@ func TestUpsertObject_Insert(t *testing.T) {
 	tx, close := newTxForObjects(t)
 	defer close()
 
-	id, err := query.UpsertObject(tx, "test", []string{"name"}, []interface{}{"egg"})
+	id, err := query.UpsertObject(context.Background(), tx, "test", []string{"name"}, []interface{}{"egg"})
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -140,7 +141,7 @@ func TestUpsertObject_Insert(t *testing.T) {
 	}
 
 	stmt := "SELECT id, name FROM test WHERE name=?"
-	err = query.SelectObjects(tx, dest, stmt, "egg")
+	err = query.SelectObjects(context.Background(), tx, dest, stmt, "egg")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -158,7 +159,7 @@ func TestUpsertObject_Update(t *testing.T) {
 	tx, close := newTxForObjects(t)
 	defer close()
 
-	id, err := query.UpsertObject(tx, "test", []string{"id", "name"}, []interface{}{1, "egg"})
+	id, err := query.UpsertObject(context.Background(), tx, "test", []string{"id", "name"}, []interface{}{1, "egg"})
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -183,7 +184,7 @@ func TestUpsertObject_Update(t *testing.T) {
 	}
 
 	stmt := "SELECT id, name FROM test WHERE name=?"
-	err = query.SelectObjects(tx, dest, stmt, "egg")
+	err = query.SelectObjects(context.Background(), tx, dest, stmt, "egg")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -201,7 +202,7 @@ func TestDeleteObject_Error(t *testing.T) {
 	tx, close := newTxForObjects(t)
 	defer close()
 
-	deleted, err := query.DeleteObject(tx, "foo", 1)
+	deleted, err := query.DeleteObject(context.Background(), tx, "foo", 1)
 	if expected, actual := "no such table: foo", err.Error(); expected != actual {
 		t.Errorf("expected: %v, actual: %v", expected, actual)
 	}
@@ -215,7 +216,7 @@ func TestDeleteObject_Deleted(t *testing.T) {
 	tx, close := newTxForObjects(t)
 	defer close()
 
-	deleted, err := query.DeleteObject(tx, "test", 1)
+	deleted, err := query.DeleteObject(context.Background(), tx, "test", 1)
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -229,7 +230,7 @@ func TestDeleteObject_NotDeleted(t *testing.T) {
 	tx, close := newTxForObjects(t)
 	defer close()
 
-	deleted, err := query.DeleteObject(tx, "test", 1000)
+	deleted, err := query.DeleteObject(context.Background(), tx, "test", 1000)
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -241,7 +242,7 @@ func TestDeleteObject_NotDeleted(t *testing.T) {
 // Return a new transaction against an in-memory SQLite database with a single
 // test table populated with a few rows for testing object-related queries.
 func newTxForObjects(t *testing.T) (database.Tx, func()) {
-	db, err := sql.Open(database.DriverName(), connectionInfo())
+	db, err := sql.Open(database.Postgres, connectionInfo())
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -256,7 +257,7 @@ func newTxForObjects(t *testing.T) (database.Tx, func()) {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	tx, err := database.ShimTx(db.Begin())
+	tx, err := database.NewShimDB(db, postgres).Begin()
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -15,10 +16,11 @@ func TestSelectObjects(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(IntScanMatcher(1), IntScanMatcher(2)).Return(nil),
 		mockRows.EXPECT().Next().Return(false),
@@ -27,7 +29,7 @@ func TestSelectObjects(t *testing.T) {
 	)
 
 	record := make([]int, 2)
-	err := query.SelectObjects(mockTx, func(i int) []interface{} {
+	err := query.SelectObjects(context.Background(), mockTx, func(i int) []interface{} {
 		return []interface{}{
 			&record[0],
 			&record[1],
@@ -46,13 +48,14 @@ func TestSelectObjectsWithQueryFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, errors.New("bad")),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, errors.New("bad")),
 	)
 
-	err := query.SelectObjects(mockTx, func(i int) []interface{} {
+	err := query.SelectObjects(context.Background(), mockTx, func(i int) []interface{} {
 		return []interface{}{}
 	}, "SELECT * FROM schema")
 	if err == nil {
@@ -65,17 +68,18 @@ func TestSelectObjectsWithScanFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(IntScanMatcher(1), IntScanMatcher(2)).Return(errors.New("bad")),
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
 	record := make([]int, 2)
-	err := query.SelectObjects(mockTx, func(i int) []interface{} {
+	err := query.SelectObjects(context.Background(), mockTx, func(i int) []interface{} {
 		return []interface{}{
 			&record[0],
 			&record[1],
@@ -86,23 +90,75 @@ func TestSelectObjectsWithScanFailure(t *testing.T) {
 	}
 }
 
+func TestSelectObjectsStmt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStmt := mocks.NewMockStmt(ctrl)
+	mockRows := mocks.NewMockRows(ctrl)
+
+	gomock.InOrder(
+		mockStmt.EXPECT().QueryContext(gomock.Any(), "fred").Return(mockRows, nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(IntScanMatcher(1), IntScanMatcher(2)).Return(nil),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+
+	record := make([]int, 2)
+	err := query.SelectObjectsStmt(context.Background(), mockStmt, func(i int) []interface{} {
+		return []interface{}{
+			&record[0],
+			&record[1],
+		}
+	}, "fred")
+	if err != nil {
+		t.Errorf("expected err to be nil")
+	}
+	if expected, actual := []int{1, 2}, record; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSelectObjectsStmtWithQueryFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStmt := mocks.NewMockStmt(ctrl)
+
+	gomock.InOrder(
+		mockStmt.EXPECT().QueryContext(gomock.Any(), "fred").Return(nil, errors.New("bad")),
+	)
+
+	err := query.SelectObjectsStmt(context.Background(), mockStmt, func(i int) []interface{} {
+		return []interface{}{}
+	}, "fred")
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
 func TestUpsertObject(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("INSERT OR REPLACE INTO schema (id, name) VALUES (?, ?)", []interface{}{1, "fred"}).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "schema" ("id", "name") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`, []interface{}{1, "fred"}).Return(mockResult, nil),
 		mockResult.EXPECT().LastInsertId().Return(int64(5), nil),
 	)
 
-	id, err := query.UpsertObject(mockTx, "schema", []string{"id", "name"}, []interface{}{1, "fred"})
+	// Updating the conflicting row leaves the last insert id alone, so the
+	// given id is the one of the row.
+	id, err := query.UpsertObject(context.Background(), mockTx, "schema", []string{"id", "name"}, []interface{}{1, "fred"})
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
-	if expected, actual := int64(5), id; expected != actual {
+	if expected, actual := int64(1), id; expected != actual {
 		t.Errorf("expected: %v, actual: %v", expected, actual)
 	}
 }
@@ -112,13 +168,14 @@ func TestUpsertObjectWithExecFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("INSERT OR REPLACE INTO schema (id, name) VALUES (?, ?)", []interface{}{1, "fred"}).Return(mockResult, errors.New("bad")),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "schema" ("id", "name") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`, []interface{}{1, "fred"}).Return(mockResult, errors.New("bad")),
 	)
 
-	_, err := query.UpsertObject(mockTx, "schema", []string{"id", "name"}, []interface{}{1, "fred"})
+	_, err := query.UpsertObject(context.Background(), mockTx, "schema", []string{"id", "name"}, []interface{}{1, "fred"})
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -129,14 +186,15 @@ func TestUpsertObjectWithLastInsertedIdFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("INSERT OR REPLACE INTO schema (id, name) VALUES (?, ?)", []interface{}{1, "fred"}).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "schema" ("id", "name") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`, []interface{}{1, "fred"}).Return(mockResult, nil),
 		mockResult.EXPECT().LastInsertId().Return(int64(5), errors.New("bad")),
 	)
 
-	_, err := query.UpsertObject(mockTx, "schema", []string{"id", "name"}, []interface{}{1, "fred"})
+	_, err := query.UpsertObject(context.Background(), mockTx, "schema", []string{"id", "name"}, []interface{}{1, "fred"})
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -147,8 +205,9 @@ func TestUpsertObjectWithNoColumns(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 
-	_, err := query.UpsertObject(mockTx, "schema", []string{}, []interface{}{1, "fred"})
+	_, err := query.UpsertObject(context.Background(), mockTx, "schema", []string{}, []interface{}{1, "fred"})
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -159,8 +218,9 @@ func TestUpsertObjectWithColumnsValuesMissmatch(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 
-	_, err := query.UpsertObject(mockTx, "schema", []string{"id"}, []interface{}{1, "fred"})
+	_, err := query.UpsertObject(context.Background(), mockTx, "schema", []string{"id"}, []interface{}{1, "fred"})
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -171,14 +231,15 @@ func TestDeleteObject(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("DELETE FROM schema WHERE id=?", int64(1)).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DELETE FROM "schema" WHERE "id" = ?`, int64(1)).Return(mockResult, nil),
 		mockResult.EXPECT().RowsAffected().Return(int64(1), nil),
 	)
 
-	ok, err := query.DeleteObject(mockTx, "schema", int64(1))
+	ok, err := query.DeleteObject(context.Background(), mockTx, "schema", int64(1))
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -192,13 +253,14 @@ func TestDeleteObjectWithExecFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("DELETE FROM schema WHERE id=?", int64(1)).Return(mockResult, errors.New("bad")),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DELETE FROM "schema" WHERE "id" = ?`, int64(1)).Return(mockResult, errors.New("bad")),
 	)
 
-	_, err := query.DeleteObject(mockTx, "schema", int64(1))
+	_, err := query.DeleteObject(context.Background(), mockTx, "schema", int64(1))
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -209,14 +271,15 @@ func TestDeleteObjectWithRowsAffectedFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("DELETE FROM schema WHERE id=?", int64(1)).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DELETE FROM "schema" WHERE "id" = ?`, int64(1)).Return(mockResult, nil),
 		mockResult.EXPECT().RowsAffected().Return(int64(1), errors.New("bad")),
 	)
 
-	_, err := query.DeleteObject(mockTx, "schema", int64(1))
+	_, err := query.DeleteObject(context.Background(), mockTx, "schema", int64(1))
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -227,14 +290,15 @@ func TestDeleteObjectWithTooManyRowsAffected(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockResult := mocks.NewMockResult(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("DELETE FROM schema WHERE id=?", int64(1)).Return(mockResult, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DELETE FROM "schema" WHERE "id" = ?`, int64(1)).Return(mockResult, nil),
 		mockResult.EXPECT().RowsAffected().Return(int64(2), nil),
 	)
 
-	_, err := query.DeleteObject(mockTx, "schema", int64(1))
+	_, err := query.DeleteObject(context.Background(), mockTx, "schema", int64(1))
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
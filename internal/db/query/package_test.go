@@ -4,10 +4,11 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/golang/mock/gomock"
 )
 
-//go:generate mockgen -package mocks -destination mocks/db_mock.go github.com/bicycolet/bicycolet/internal/db/database DB,Tx,Rows,ColumnType
+//go:generate mockgen -package mocks -destination mocks/db_mock.go github.com/bicycolet/bicycolet/internal/db/database DB,Tx,Stmt,Rows,ColumnType
 //go:generate mockgen -package mocks -destination mocks/result_mock.go database/sql Result
 
 type intScanMatcher struct {
@@ -49,3 +50,32 @@ func (m stringScanMatcher) Matches(x interface{}) bool {
 func (m stringScanMatcher) String() string {
 	return m.x
 }
+
+type valueScanMatcher struct {
+	x interface{}
+}
+
+func ValueScanMatcher(v interface{}) gomock.Matcher {
+	return valueScanMatcher{
+		x: v,
+	}
+}
+
+func (m valueScanMatcher) Matches(x interface{}) bool {
+	ref := reflect.ValueOf(x).Elem()
+	ref.Set(reflect.ValueOf(m.x))
+	return true
+}
+
+func (m valueScanMatcher) String() string {
+	return fmt.Sprintf("%v", m.x)
+}
+
+// sqlite is the dialect the mocked databases speak.
+var sqlite = func() database.Dialect {
+	dialect, err := database.NewDialect(database.SQLite)
+	if err != nil {
+		panic(err)
+	}
+	return dialect
+}()
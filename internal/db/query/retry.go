@@ -1,28 +1,62 @@
 package query
 
 import (
-	"strings"
+	"database/sql/driver"
+	"net"
 	"time"
 
 	"github.com/bicycolet/bicycolet/internal/resilience/clock"
 	"github.com/bicycolet/bicycolet/internal/resilience/retrier"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 )
 
+const (
+	// retryAmount is the maximum number of times a function is retried.
+	retryAmount = 10
+
+	// retryBackoff is how long to wait for before the first retry, every
+	// other retry waits for twice as long as the previous one.
+	retryBackoff = 25 * time.Millisecond
+
+	// retryMaxElapsed is the time after which a function is no longer
+	// retried.
+	retryMaxElapsed = 5 * time.Second
+)
+
 // Retry wraps a function that interacts with the database, and retries it in
-// case a transient error is hit.
+// case a transient error is hit, backing off exponentially between attempts.
+// Any other error is returned straight away.
+//
+// The number of times the function has been retried is returned, so that
+// callers can report on contention.
 //
 // This should by typically used to wrap transactions.
-func Retry(sleeper clock.Sleeper, f func() error) error {
-	retry := retrier.New(sleeper, 10, 250*time.Millisecond)
+func Retry(sleeper clock.Sleeper, f func() error) (int, error) {
+	retry := retrier.New(sleeper, retryAmount, retryBackoff,
+		retrier.WithBackoff(retrier.Exponential),
+		retrier.WithJitter(),
+		retrier.WithMaxElapsedTime(retryMaxElapsed),
+	)
+
+	var (
+		attempts int
+		result   error
+	)
 	err := retry.Run(func() error {
+		attempts++
 		err := f()
 		if IsRetriableError(err) {
-			return nil
+			return err
 		}
-		return errors.WithStack(err)
+		result = err
+		return nil
 	})
-	return errors.WithStack(err)
+	if err != nil {
+		return attempts - 1, errors.WithStack(err)
+	}
+	return attempts - 1, errors.WithStack(result)
 }
 
 // IsRetriableError returns true if the given error might be transient and the
@@ -32,8 +66,26 @@ func IsRetriableError(err error) bool {
 	if err == nil {
 		return false
 	}
+	if err == driver.ErrBadConn {
+		return true
+	}
 
-	if strings.Contains(errors.Cause(err).Error(), "bad connection") {
+	switch e := err.(type) {
+	case sqlite3.Error:
+		// The database is being written to by another connection.
+		return e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked
+	case *pq.Error:
+		switch e.Code {
+		case "40001", "40P01":
+			// The transaction lost out to a concurrent one, either because
+			// they can't be serialized or because they deadlocked.
+			return true
+		}
+		// Class 08 is the one of the connection exceptions.
+		return e.Code.Class() == "08"
+	case net.Error:
+		// The connection to the database server was lost or couldn't be
+		// established.
 		return true
 	}
 	return false
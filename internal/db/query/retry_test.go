@@ -1,9 +1,14 @@
 package query_test
 
 import (
+	"database/sql/driver"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 )
 
@@ -20,3 +25,132 @@ func TestIsRetriableErrorWithNil(t *testing.T) {
 		t.Errorf("expected ok to be false")
 	}
 }
+
+func TestIsRetriableErrorWithDriverErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		err    error
+		result bool
+	}{
+		{
+			name:   "bad connection",
+			err:    errors.WithStack(driver.ErrBadConn),
+			result: true,
+		},
+		{
+			name:   "sqlite busy",
+			err:    sqlite3.Error{Code: sqlite3.ErrBusy},
+			result: true,
+		},
+		{
+			name:   "sqlite locked",
+			err:    sqlite3.Error{Code: sqlite3.ErrLocked},
+			result: true,
+		},
+		{
+			name:   "sqlite constraint",
+			err:    sqlite3.Error{Code: sqlite3.ErrConstraint},
+			result: false,
+		},
+		{
+			name:   "postgres serialization failure",
+			err:    &pq.Error{Code: "40001"},
+			result: true,
+		},
+		{
+			name:   "postgres deadlock",
+			err:    &pq.Error{Code: "40P01"},
+			result: true,
+		},
+		{
+			name:   "postgres connection failure",
+			err:    &pq.Error{Code: "08006"},
+			result: true,
+		},
+		{
+			name:   "postgres unique violation",
+			err:    &pq.Error{Code: "23505"},
+			result: false,
+		},
+		{
+			name:   "network",
+			err:    &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			result: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if expected, actual := test.result, query.IsRetriableError(test.err); expected != actual {
+				t.Errorf("expected: %t, actual: %t", expected, actual)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	sleeper := &recordingSleeper{}
+
+	var calls int
+	retries, err := query.Retry(sleeper, func() error {
+		calls++
+		if calls < 3 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 2, retries; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := 2, len(sleeper.durations); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestRetryWithNonRetriableError(t *testing.T) {
+	sleeper := &recordingSleeper{}
+
+	var calls int
+	retries, err := query.Retry(sleeper, func() error {
+		calls++
+		return errors.New("bad")
+	})
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+	if expected, actual := 0, retries; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := 1, calls; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestRetryWithPersistentRetriableError(t *testing.T) {
+	sleeper := &recordingSleeper{}
+
+	retries, err := query.Retry(sleeper, func() error {
+		return &pq.Error{Code: "40001"}
+	})
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+	if retries == 0 {
+		t.Errorf("expected retries not to be zero")
+	}
+	if expected, actual := retries, len(sleeper.durations); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+type recordingSleeper struct {
+	durations []time.Duration
+}
+
+func (s *recordingSleeper) Sleep(d time.Duration) {
+	s.durations = append(s.durations, d)
+}
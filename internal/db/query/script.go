@@ -0,0 +1,220 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/pkg/errors"
+)
+
+// Statement is a single statement of a SQL script.
+type Statement struct {
+	// SQL of the statement, without the semicolon terminating it.
+	SQL string
+
+	// Line of the script the statement starts on, counting from 1.
+	Line int
+}
+
+// SplitStatements splits the given SQL script into its statements, which are
+// terminated by semicolons.
+//
+// Semicolons within quoted strings and identifiers, dollar-quoted strings,
+// comments and the body of a trigger don't terminate a statement. Statements
+// made of comments only are left out.
+func SplitStatements(script string) ([]Statement, error) {
+	var (
+		statements []Statement
+		runes      = []rune(script)
+		line       = 1
+		start      = -1   // Index of the first token of the current statement.
+		end        int    // Index right after its last token.
+		startLine  int    // Line of its first token.
+		code       []rune // Its tokens, without comments.
+	)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			if r == '\n' {
+				line++
+			}
+		case r == '-' && next(runes, i) == '-':
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+		case r == '/' && next(runes, i) == '*':
+			closing := indexRunes(runes, i+2, []rune("*/"))
+			if closing < 0 {
+				return nil, errors.Errorf("unterminated comment at line %d", line)
+			}
+			line += countRunes(runes[i:closing], '\n')
+			i = closing + 1
+		case r == ';' && !withinTrigger(code):
+			if start >= 0 {
+				statements = append(statements, Statement{
+					SQL:  string(runes[start:end]),
+					Line: startLine,
+				})
+			}
+			start, code = -1, nil
+		default:
+			if start < 0 {
+				start, startLine = i, line
+			}
+			var err error
+			if end, err = endOfToken(runes, i); err != nil {
+				return nil, errors.Errorf("%v at line %d", err, line)
+			}
+			line += countRunes(runes[i:end], '\n')
+			code = append(append(code, runes[i:end]...), ' ')
+			i = end - 1
+		}
+	}
+	if start >= 0 {
+		statements = append(statements, Statement{
+			SQL:  string(runes[start:end]),
+			Line: startLine,
+		})
+	}
+	return statements, nil
+}
+
+// ExecScript executes the statements of the given SQL script one by one, as
+// split by SplitStatements. The error of a failing statement reports the line
+// of the script it starts on.
+func ExecScript(ctx context.Context, tx database.Tx, script string) error {
+	statements, err := SplitStatements(script)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement.SQL); err != nil {
+			return errors.Wrapf(err, "failed to execute statement at line %d", statement.Line)
+		}
+	}
+	return nil
+}
+
+// Return the index right after the end of the token starting at the given
+// index: a quoted string or identifier, a dollar-quoted string, a PostgreSQL
+// escape string such as E'it\'s', a word or a single symbol.
+func endOfToken(runes []rune, i int) (int, error) {
+	switch r := runes[i]; {
+	case r == '\'' || r == '"' || r == '`':
+		// Doubling the quote escapes it, which is the same as closing the
+		// quote and opening it again straight away.
+		for j := i + 1; j < len(runes); j++ {
+			if runes[j] == r {
+				return j + 1, nil
+			}
+		}
+		return -1, errors.Errorf("unterminated quote %c", r)
+	case r == '$':
+		tag, ok := dollarTag(runes, i)
+		if !ok {
+			return i + 1, nil
+		}
+		end := indexRunes(runes, i+len(tag), tag)
+		if end < 0 {
+			return -1, errors.Errorf("unterminated dollar-quoted string %s", string(tag))
+		}
+		return end + len(tag), nil
+	case (r == 'E' || r == 'e') && next(runes, i) == '\'':
+		// Backslashes escape the character following them, quotes included.
+		for j := i + 2; j < len(runes); j++ {
+			switch runes[j] {
+			case '\\':
+				j++
+			case '\'':
+				if next(runes, j) != '\'' {
+					return j + 1, nil
+				}
+				j++
+			}
+		}
+		return -1, errors.Errorf("unterminated quote '")
+	case isWordRune(r):
+		j := i + 1
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		return j, nil
+	default:
+		return i + 1, nil
+	}
+}
+
+// Return the tag opening a dollar-quoted string at the given index, such as
+// $$ or $body$, if there's one. Positional parameters such as $1 aren't.
+func dollarTag(runes []rune, i int) ([]rune, bool) {
+	for j := i + 1; j < len(runes); j++ {
+		switch r := runes[j]; {
+		case r == '$':
+			return runes[i : j+1], true
+		case unicode.IsDigit(r) && j == i+1:
+			return nil, false
+		case !isWordRune(r):
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// Return whether the given tokens are those of a statement creating a SQLite
+// trigger whose body isn't complete yet, since the statements of the body are
+// terminated by semicolons as well. The body ends with the END following the
+// semicolon of its last statement, unlike the END of a CASE expression.
+func withinTrigger(code []rune) bool {
+	words := strings.Fields(strings.ToUpper(string(code)))
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	switch {
+	case words[1] == "TRIGGER":
+	case len(words) > 2 && (words[1] == "TEMP" || words[1] == "TEMPORARY") && words[2] == "TRIGGER":
+	default:
+		return false
+	}
+	for _, word := range words {
+		if word == "BEGIN" {
+			n := len(words)
+			return words[n-1] != "END" || words[n-2] != ";"
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Return the index of the first occurrence of the given pattern in the runes,
+// looking from the given index onwards, or -1 if there's none.
+func indexRunes(runes []rune, from int, pattern []rune) int {
+	for i := from; i+len(pattern) <= len(runes); i++ {
+		if string(runes[i:i+len(pattern)]) == string(pattern) {
+			return i
+		}
+	}
+	return -1
+}
+
+func countRunes(runes []rune, r rune) int {
+	var n int
+	for _, c := range runes {
+		if c == r {
+			n++
+		}
+	}
+	return n
+}
+
+func next(runes []rune, i int) rune {
+	if i+1 < len(runes) {
+		return runes[i+1]
+	}
+	return 0
+}
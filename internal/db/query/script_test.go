@@ -0,0 +1,187 @@
+package query_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/db/query/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+)
+
+func TestSplitStatements(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		script string
+		result []query.Statement
+	}{
+		{
+			name:   "empty",
+			script: "",
+			result: nil,
+		},
+		{
+			name:   "single statement without semicolon",
+			script: "SELECT 1",
+			result: []query.Statement{{SQL: "SELECT 1", Line: 1}},
+		},
+		{
+			name:   "many statements",
+			script: "SELECT 1;\n\nSELECT 2; SELECT 3;\n",
+			result: []query.Statement{
+				{SQL: "SELECT 1", Line: 1},
+				{SQL: "SELECT 2", Line: 3},
+				{SQL: "SELECT 3", Line: 3},
+			},
+		},
+		{
+			name:   "quotes",
+			script: "INSERT INTO t VALUES ('a;b', 'it''s;');\nSELECT \"x;y\" FROM `t;`;",
+			result: []query.Statement{
+				{SQL: "INSERT INTO t VALUES ('a;b', 'it''s;')", Line: 1},
+				{SQL: "SELECT \"x;y\" FROM `t;`", Line: 2},
+			},
+		},
+		{
+			name:   "multi-line quotes",
+			script: "INSERT INTO t VALUES ('a\n;\nb');\nSELECT 1;",
+			result: []query.Statement{
+				{SQL: "INSERT INTO t VALUES ('a\n;\nb')", Line: 1},
+				{SQL: "SELECT 1", Line: 4},
+			},
+		},
+		{
+			name:   "comments",
+			script: "-- first; comment\nSELECT 1; -- trailing;\n/* block;\ncomment; */ SELECT /* ; */ 2;\n-- only a comment;\n",
+			result: []query.Statement{
+				{SQL: "SELECT 1", Line: 2},
+				{SQL: "SELECT /* ; */ 2", Line: 4},
+			},
+		},
+		{
+			name:   "dollar-quoting",
+			script: "CREATE FUNCTION f() RETURNS void AS $body$\nBEGIN\n  DELETE FROM t; -- $$;\nEND;\n$body$ LANGUAGE plpgsql;\nSELECT $$a;b$$;",
+			result: []query.Statement{
+				{SQL: "CREATE FUNCTION f() RETURNS void AS $body$\nBEGIN\n  DELETE FROM t; -- $$;\nEND;\n$body$ LANGUAGE plpgsql", Line: 1},
+				{SQL: "SELECT $$a;b$$", Line: 6},
+			},
+		},
+		{
+			name:   "positional parameters",
+			script: "SELECT * FROM t WHERE id = $1; SELECT 2",
+			result: []query.Statement{
+				{SQL: "SELECT * FROM t WHERE id = $1", Line: 1},
+				{SQL: "SELECT 2", Line: 1},
+			},
+		},
+		{
+			name:   "trigger",
+			script: "CREATE TRIGGER tr AFTER INSERT ON t BEGIN\n  DELETE FROM u;\n  DELETE FROM v;\nEND;\nSELECT 1;",
+			result: []query.Statement{
+				{SQL: "CREATE TRIGGER tr AFTER INSERT ON t BEGIN\n  DELETE FROM u;\n  DELETE FROM v;\nEND", Line: 1},
+				{SQL: "SELECT 1", Line: 5},
+			},
+		},
+		{
+			name:   "trigger with case expression",
+			script: "CREATE TRIGGER t AFTER INSERT ON a BEGIN UPDATE a SET x = CASE WHEN 1 THEN 1 END; END; SELECT 1;",
+			result: []query.Statement{
+				{SQL: "CREATE TRIGGER t AFTER INSERT ON a BEGIN UPDATE a SET x = CASE WHEN 1 THEN 1 END; END", Line: 1},
+				{SQL: "SELECT 1", Line: 1},
+			},
+		},
+		{
+			name:   "escape strings",
+			script: "INSERT INTO t VALUES (E'it\\'s; fine', e'a\\\\', E'b''c;'); SELECT 2;",
+			result: []query.Statement{
+				{SQL: "INSERT INTO t VALUES (E'it\\'s; fine', e'a\\\\', E'b''c;')", Line: 1},
+				{SQL: "SELECT 2", Line: 1},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := query.SplitStatements(test.script)
+			if err != nil {
+				t.Fatalf("expected err to be nil: %v", err)
+			}
+			if expected, actual := test.result, statements; !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %q, actual: %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestSplitStatementsWithUnterminatedTokens(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		script string
+		result string
+	}{
+		{
+			name:   "quote",
+			script: "SELECT 1;\nSELECT 'a;",
+			result: "unterminated quote ' at line 2",
+		},
+		{
+			name:   "escape string",
+			script: "SELECT E'a\\';",
+			result: "unterminated quote ' at line 1",
+		},
+		{
+			name:   "dollar-quoted string",
+			script: "SELECT $tag$a;",
+			result: "unterminated dollar-quoted string $tag$ at line 1",
+		},
+		{
+			name:   "comment",
+			script: "\n\nSELECT 1 /* a;",
+			result: "unterminated comment at line 3",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := query.SplitStatements(test.script)
+			if err == nil {
+				t.Fatalf("expected err not to be nil")
+			}
+			if expected, actual := test.result, err.Error(); !strings.Contains(actual, expected) {
+				t.Errorf("expected %q to contain %q", actual, expected)
+			}
+		})
+	}
+}
+
+func TestExecScript(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().ExecContext(gomock.Any(), "DELETE FROM a").Return(nil, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), "DELETE FROM b").Return(nil, nil),
+	)
+
+	if err := query.ExecScript(context.Background(), mockTx, "DELETE FROM a;\nDELETE FROM b;\n"); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
+
+func TestExecScriptWithExecFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().ExecContext(gomock.Any(), "DELETE FROM a").Return(nil, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), "DELETE FROM b").Return(nil, errors.New("bad")),
+	)
+
+	err := query.ExecScript(context.Background(), mockTx, "DELETE FROM a;\n\nDELETE FROM b;\nDELETE FROM c;\n")
+	if expected, actual := "failed to execute statement at line 3: bad", err.Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -10,7 +11,7 @@ import (
 
 // SelectStrings executes a statement which must yield rows with a single string
 // column. It returns the list of column values.
-func SelectStrings(tx database.Tx, query string, args ...interface{}) ([]string, error) {
+func SelectStrings(ctx context.Context, tx database.Tx, query string, args ...interface{}) ([]string, error) {
 	var values []string
 	scan := func(rows database.Rows) error {
 		var value string
@@ -21,13 +22,13 @@ func SelectStrings(tx database.Tx, query string, args ...interface{}) ([]string,
 		return nil
 	}
 
-	err := scanSingleColumn(tx, query, args, "TEXT", scan)
+	err := scanSingleColumn(ctx, tx, query, args, "TEXT", scan)
 	return values, errors.WithStack(err)
 }
 
 // SelectIntegers executes a statement which must yield rows with a single integer
 // column. It returns the list of column values.
-func SelectIntegers(tx database.Tx, query string, args ...interface{}) ([]int, error) {
+func SelectIntegers(ctx context.Context, tx database.Tx, query string, args ...interface{}) ([]int, error) {
 	var values []int
 	scan := func(rows database.Rows) error {
 		var value int
@@ -38,7 +39,7 @@ func SelectIntegers(tx database.Tx, query string, args ...interface{}) ([]int, e
 		return nil
 	}
 
-	err := scanSingleColumn(tx, query, args, "INTEGER", scan)
+	err := scanSingleColumn(ctx, tx, query, args, "INTEGER", scan)
 	return values, errors.WithStack(err)
 }
 
@@ -46,7 +47,7 @@ func SelectIntegers(tx database.Tx, query string, args ...interface{}) ([]int, e
 // given insert statement template, which must define exactly one insertion
 // column and one substitution placeholder for the values. For example:
 // InsertStrings(tx, "INSERT INTO foo(name) VALUES %s", []string{"bar"}).
-func InsertStrings(tx database.Tx, stmt string, values []string) error {
+func InsertStrings(ctx context.Context, tx database.Tx, stmt string, values []string) error {
 	n := len(values)
 	if n == 0 {
 		return nil
@@ -60,7 +61,7 @@ func InsertStrings(tx database.Tx, stmt string, values []string) error {
 	}
 
 	stmt = fmt.Sprintf(stmt, strings.Join(params, ", "))
-	_, err := tx.Exec(stmt, args...)
+	_, err := tx.ExecContext(ctx, stmt, args...)
 	return errors.WithStack(err)
 }
 
@@ -70,8 +71,8 @@ type scanFunc func(database.Rows) error
 // Execute the given query and ensure that it yields rows with a single column
 // of the given database type. For every row yielded, execute the given
 // scanner.
-func scanSingleColumn(tx database.Tx, query string, args []interface{}, typeName string, scan scanFunc) error {
-	rows, err := tx.Query(query, args...)
+func scanSingleColumn(ctx context.Context, tx database.Tx, query string, args []interface{}, typeName string, scan scanFunc) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return errors.WithStack(err)
 	}
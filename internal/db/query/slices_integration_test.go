@@ -3,6 +3,7 @@
 package query_test
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
 	"testing"
@@ -36,7 +37,7 @@ func TestStrings_Error(t *testing.T) {
 			tx, close := newTxForSlices(t)
 			defer close()
 
-			values, err := query.SelectStrings(tx, c.query)
+			values, err := query.SelectStrings(context.Background(), tx, c.query)
 			if expected, actual := c.err, err.Error(); expected != actual {
 				t.Errorf("expected: %v, actual: %v", expected, actual)
 			}
@@ -52,7 +53,7 @@ func TestStrings(t *testing.T) {
 	tx, close := newTxForSlices(t)
 	defer close()
 
-	values, err := query.SelectStrings(tx, "SELECT name FROM test ORDER BY name")
+	values, err := query.SelectStrings(context.Background(), tx, "SELECT name FROM test ORDER BY name")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -68,7 +69,7 @@ func TestIntegers_Error(t *testing.T) {
 			tx, close := newTxForSlices(t)
 			defer close()
 
-			values, err := query.SelectIntegers(tx, c.query)
+			values, err := query.SelectIntegers(context.Background(), tx, c.query)
 			if expected, actual := c.err, err.Error(); expected != actual {
 				t.Errorf("expected: %v, actual: %v", expected, actual)
 			}
@@ -84,7 +85,7 @@ func TestIntegers(t *testing.T) {
 	tx, close := newTxForSlices(t)
 	defer close()
 
-	values, err := query.SelectIntegers(tx, "SELECT id FROM test ORDER BY id")
+	values, err := query.SelectIntegers(context.Background(), tx, "SELECT id FROM test ORDER BY id")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -98,12 +99,12 @@ func TestInsertStrings_Bulk(t *testing.T) {
 	tx, close := newTxForSlices(t)
 	defer close()
 
-	err := query.InsertStrings(tx, "INSERT INTO test(name) VALUES %s", []string{"xx", "yy"})
+	err := query.InsertStrings(context.Background(), tx, "INSERT INTO test(name) VALUES %s", []string{"xx", "yy"})
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	values, err := query.SelectStrings(tx, "SELECT name FROM test ORDER BY name DESC LIMIT 2")
+	values, err := query.SelectStrings(context.Background(), tx, "SELECT name FROM test ORDER BY name DESC LIMIT 2")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -115,7 +116,7 @@ func TestInsertStrings_Bulk(t *testing.T) {
 // Return a new transaction against an in-memory SQLite database with a single
 // test table populated with a few rows.
 func newTxForSlices(t *testing.T) (database.Tx, func()) {
-	db, err := sql.Open(database.DriverName(), connectionInfo())
+	db, err := sql.Open(database.Postgres, connectionInfo())
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -130,7 +131,7 @@ func newTxForSlices(t *testing.T) (database.Tx, func()) {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	tx, err := database.ShimTx(db.Begin())
+	tx, err := database.NewShimDB(db, postgres).Begin()
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
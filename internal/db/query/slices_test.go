@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -20,7 +21,7 @@ func TestSelectStrings(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -36,7 +37,7 @@ func TestSelectStrings(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	values, err := query.SelectStrings(mockTx, "SELECT * FROM schema")
+	values, err := query.SelectStrings(context.Background(), mockTx, "SELECT * FROM schema")
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -54,7 +55,7 @@ func TestSelectStringsWithScanFailure(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -64,7 +65,7 @@ func TestSelectStringsWithScanFailure(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectStrings(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectStrings(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -77,10 +78,10 @@ func TestSelectStringsWithQueryFailure(t *testing.T) {
 	mockTx := mocks.NewMockTx(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(nil, errors.New("bad")),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(nil, errors.New("bad")),
 	)
 
-	_, err := query.SelectStrings(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectStrings(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -94,12 +95,12 @@ func TestSelectStringsWithColumnTypesFailure(t *testing.T) {
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return(nil, errors.New("bad")),
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectStrings(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectStrings(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -114,7 +115,7 @@ func TestSelectStringsWithMultipleColumnTypes(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 			mockColumnType,
@@ -122,7 +123,7 @@ func TestSelectStringsWithMultipleColumnTypes(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectStrings(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectStrings(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -137,7 +138,7 @@ func TestSelectStringsWithInvalidColumnType(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -145,7 +146,7 @@ func TestSelectStringsWithInvalidColumnType(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectStrings(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectStrings(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -160,7 +161,7 @@ func TestSelectIntegers(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -176,7 +177,7 @@ func TestSelectIntegers(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	values, err := query.SelectIntegers(mockTx, "SELECT * FROM schema")
+	values, err := query.SelectIntegers(context.Background(), mockTx, "SELECT * FROM schema")
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -194,7 +195,7 @@ func TestSelectIntegersWithScanFailure(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -204,7 +205,7 @@ func TestSelectIntegersWithScanFailure(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectIntegers(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectIntegers(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -217,10 +218,10 @@ func TestSelectIntegersWithQueryFailure(t *testing.T) {
 	mockTx := mocks.NewMockTx(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(nil, errors.New("bad")),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(nil, errors.New("bad")),
 	)
 
-	_, err := query.SelectIntegers(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectIntegers(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -234,12 +235,12 @@ func TestSelectIntegersWithColumnTypesFailure(t *testing.T) {
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return(nil, errors.New("bad")),
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectIntegers(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectIntegers(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -254,7 +255,7 @@ func TestSelectIntegersWithMultipleColumnTypes(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 			mockColumnType,
@@ -262,7 +263,7 @@ func TestSelectIntegersWithMultipleColumnTypes(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectIntegers(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectIntegers(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -277,7 +278,7 @@ func TestSelectIntegersWithInvalidColumnType(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query("SELECT * FROM schema").Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM schema").Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -285,7 +286,7 @@ func TestSelectIntegersWithInvalidColumnType(t *testing.T) {
 		mockRows.EXPECT().Close().Return(nil),
 	)
 
-	_, err := query.SelectIntegers(mockTx, "SELECT * FROM schema")
+	_, err := query.SelectIntegers(context.Background(), mockTx, "SELECT * FROM schema")
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -298,10 +299,10 @@ func TestInsertStrings(t *testing.T) {
 	mockTx := mocks.NewMockTx(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec("INSERT INTO test(name) VALUES (?), (?)", []interface{}{"xx", "yy"}).Return(nil, nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), "INSERT INTO test(name) VALUES (?), (?)", []interface{}{"xx", "yy"}).Return(nil, nil),
 	)
 
-	err := query.InsertStrings(mockTx, "INSERT INTO test(name) VALUES %s", []string{"xx", "yy"})
+	err := query.InsertStrings(context.Background(), mockTx, "INSERT INTO test(name) VALUES %s", []string{"xx", "yy"})
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -0,0 +1,362 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/pkg/errors"
+)
+
+// The struct tag mapping fields to columns. The tag value is the name of the
+// column, optionally followed by a comma separated list of options:
+//
+//	pk        the column is the primary key of the table, it's left out of
+//	          inserts when zero and set to the id of the inserted row.
+//	omitempty the column is left out of upserts and filters when zero.
+//	json      the column holds the JSON encoding of the field, or NULL when
+//	          the field is zero.
+//
+// Fields tagged with "-" or not tagged at all are ignored, except for
+// embedded structs without a tag, whose fields are mapped as if they belonged
+// to the outer struct.
+const tagName = "db"
+
+// SelectStructs executes a statement and scans every row it yields into a new
+// element of the slice pointed to by dest, which must be a pointer to a slice
+// of structs or of pointers to structs. Every column of the rows must map to
+// a field of the struct.
+//
+// The columns are scanned according to their types: NULL leaves the field
+// zero, and columns of a JSON type are decoded into the field.
+func SelectStructs(ctx context.Context, tx database.Tx, dest interface{}, query string, args ...interface{}) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return errors.Errorf("dest must be a pointer to a slice, not %T", dest)
+	}
+	slice = slice.Elem()
+
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	m, err := mappingOf(elemType)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	columns := make([]column, len(types))
+	for i, typ := range types {
+		f, ok := m.byColumn[typ.Name()]
+		if !ok {
+			return errors.Errorf("no field of %s maps to column %q", elemType, typ.Name())
+		}
+		columns[i] = columnOf(f, typ, elemType.FieldByIndex(f.index).Type)
+	}
+
+	for rows.Next() {
+		elem := reflect.New(elemType).Elem()
+
+		dest := make([]interface{}, len(columns))
+		var setters []func() error
+		for i, c := range columns {
+			value := elem.FieldByIndex(c.field.index)
+			switch {
+			case c.json:
+				raw, name := new([]byte), c.field.column
+				dest[i] = raw
+				setters = append(setters, func() error {
+					if *raw == nil {
+						return nil
+					}
+					err := json.Unmarshal(*raw, value.Addr().Interface())
+					return errors.Wrapf(err, "failed to decode column %q", name)
+				})
+			case c.nullable:
+				// NULL leaves the field zero, rather than failing the scan.
+				ptr := reflect.New(reflect.PtrTo(value.Type()))
+				dest[i] = ptr.Interface()
+				setters = append(setters, func() error {
+					if !ptr.Elem().IsNil() {
+						value.Set(ptr.Elem().Elem())
+					}
+					return nil
+				})
+			default:
+				dest[i] = value.Addr().Interface()
+			}
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, set := range setters {
+			if err := set(); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if isPtr {
+			elem = elem.Addr()
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+
+	err = rows.Err()
+	return errors.WithStack(err)
+}
+
+// UpsertStruct inserts or replaces a row of the given table with the fields
+// of the struct pointed to by v. If the struct has a zero primary key, a new
+// row is inserted and the primary key is set to its id.
+//
+// The id of the upserted row is returned.
+func UpsertStruct(ctx context.Context, tx database.Tx, table string, v interface{}) (int64, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return -1, errors.Errorf("value must be a pointer to a struct, not %T", v)
+	}
+	value = value.Elem()
+
+	m, err := mappingOf(value.Type())
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+	if m.pk == nil {
+		return -1, errors.Errorf("%s has no primary key field", value.Type())
+	}
+
+	columns, values, err := m.values(value)
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+
+	id, err := upsert(ctx, tx, table, m.pk.column, columns, values)
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+
+	pk := value.FieldByIndex(m.pk.index)
+	switch pk.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		pk.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		pk.SetUint(uint64(id))
+	}
+	return id, nil
+}
+
+// DeleteWhere removes the rows of the given table matching the fields of the
+// given struct, or pointer to a struct. The primary key and the fields tagged
+// with omitempty are only matched on when they're not zero.
+//
+// It returns the number of deleted rows.
+func DeleteWhere(ctx context.Context, tx database.Tx, table string, filter interface{}) (int64, error) {
+	value := reflect.Indirect(reflect.ValueOf(filter))
+	if value.Kind() != reflect.Struct {
+		return -1, errors.Errorf("filter must be a struct, not %T", filter)
+	}
+
+	m, err := mappingOf(value.Type())
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+
+	columns, values, err := m.values(value)
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+	if len(columns) == 0 {
+		return -1, errors.Errorf("filter matches every row of %s", table)
+	}
+
+	builder := Delete(table)
+	for i, column := range columns {
+		builder = builder.Where(Eq(column, values[i]))
+	}
+	stmt, args, err := builder.Build(tx.Dialect())
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+	result, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+	n, err := result.RowsAffected()
+	return n, errors.WithStack(err)
+}
+
+// field describes how a struct field maps to a column.
+type field struct {
+	column    string
+	index     []int
+	pk        bool
+	omitempty bool
+	json      bool
+}
+
+// column describes how a column of the rows yielded by a statement is scanned
+// into the field it maps to.
+type column struct {
+	field    field
+	json     bool
+	nullable bool
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// Return how the column of the given type is scanned into the given field of
+// the given type. Columns of a JSON type hold the encoding of the field even
+// if it isn't tagged with json, and columns that may be NULL, or that aren't
+// known not to be, are scanned through a pointer unless the field can hold
+// NULL itself.
+func columnOf(f field, typ database.ColumnType, fieldType reflect.Type) column {
+	c := column{field: f, json: f.json}
+	switch strings.ToUpper(typ.DatabaseTypeName()) {
+	case "JSON", "JSONB":
+		c.json = true
+	}
+	if c.json {
+		return c
+	}
+	nullable, ok := typ.Nullable()
+	switch {
+	case ok && !nullable:
+	case fieldType.Kind() == reflect.Ptr, fieldType.Kind() == reflect.Interface:
+	case reflect.PtrTo(fieldType).Implements(scannerType):
+	default:
+		c.nullable = true
+	}
+	return c
+}
+
+// mapping describes how the fields of a struct map to columns.
+type mapping struct {
+	fields   []field
+	byColumn map[string]field
+	pk       *field
+}
+
+// Return the columns and the values of the fields of the given struct,
+// leaving out the zero ones that can be omitted.
+func (m *mapping) values(value reflect.Value) ([]string, []interface{}, error) {
+	var (
+		columns []string
+		values  []interface{}
+	)
+	for _, f := range m.fields {
+		v := value.FieldByIndex(f.index)
+		if (f.pk || f.omitempty) && isZero(v) {
+			continue
+		}
+		columns = append(columns, f.column)
+		if !f.json {
+			values = append(values, v.Interface())
+			continue
+		}
+		// Zero values are stored as NULL rather than as their encoding.
+		if isZero(v) {
+			values = append(values, nil)
+			continue
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to encode column %q", f.column)
+		}
+		values = append(values, string(data))
+	}
+	return columns, values, nil
+}
+
+var mappings sync.Map
+
+// Return the mapping of the given struct type, which is only worked out once
+// for every type.
+func mappingOf(t reflect.Type) (*mapping, error) {
+	if m, ok := mappings.Load(t); ok {
+		return m.(*mapping), nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("%s is not a struct", t)
+	}
+
+	m := &mapping{
+		byColumn: make(map[string]field),
+	}
+	if err := m.add(t, nil); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	mappings.Store(t, m)
+	return m, nil
+}
+
+func (m *mapping) add(t reflect.Type, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		tag, ok := sf.Tag.Lookup(tagName)
+		if !ok && sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := m.add(sf.Type, fieldIndex); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+		if !ok || tag == "-" || sf.PkgPath != "" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		f := field{
+			column: parts[0],
+			index:  fieldIndex,
+		}
+		if f.column == "" {
+			return errors.Errorf("field %s has no column name", sf.Name)
+		}
+		for _, option := range parts[1:] {
+			switch option {
+			case "pk":
+				f.pk = true
+			case "omitempty":
+				f.omitempty = true
+			case "json":
+				f.json = true
+			default:
+				return errors.Errorf("field %s has unknown option %q", sf.Name, option)
+			}
+		}
+
+		if _, ok := m.byColumn[f.column]; ok {
+			return errors.Errorf("column %q is mapped more than once", f.column)
+		}
+		if f.pk {
+			if m.pk != nil {
+				return errors.Errorf("more than one primary key field")
+			}
+			m.pk = &f
+		}
+		m.fields = append(m.fields, f)
+		m.byColumn[f.column] = f
+	}
+	return nil
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
@@ -0,0 +1,291 @@
+package query_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/db/query/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+)
+
+type car struct {
+	ID     int64             `db:"id,pk"`
+	Brand  string            `db:"brand"`
+	Colour string            `db:"colour,omitempty"`
+	Extra  map[string]string `db:"extra,json"`
+	Notes  string
+}
+
+func TestSelectStructs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT id, brand, extra FROM cars WHERE id > ?", 0).Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			columnType(ctrl, "id", "INTEGER", false),
+			columnType(ctrl, "brand", "TEXT", false),
+			columnType(ctrl, "extra", "TEXT", true),
+		}, nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(ValueScanMatcher(int64(1)), StringScanMatcher("ferrari"), ValueScanMatcher([]byte(`{"a":"b"}`))).Return(nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(ValueScanMatcher(int64(2)), StringScanMatcher("fiat"), gomock.Any()).Return(nil),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+
+	var cars []*car
+	err := query.SelectStructs(context.Background(), mockTx, &cars, "SELECT id, brand, extra FROM cars WHERE id > ?", 0)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	expected := []*car{
+		{ID: 1, Brand: "ferrari", Extra: map[string]string{"a": "b"}},
+		{ID: 2, Brand: "fiat"},
+	}
+	if actual := cars; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSelectStructsWithNullColumns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+
+	red := "red"
+	gomock.InOrder(
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT id, colour FROM cars").Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			columnType(ctrl, "id", "INTEGER", false),
+			columnType(ctrl, "colour", "TEXT", true),
+		}, nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(ValueScanMatcher(int64(1)), ValueScanMatcher(&red)).Return(nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(ValueScanMatcher(int64(2)), ValueScanMatcher((*string)(nil))).Return(nil),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+
+	var cars []car
+	err := query.SelectStructs(context.Background(), mockTx, &cars, "SELECT id, colour FROM cars")
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	expected := []car{
+		{ID: 1, Colour: "red"},
+		{ID: 2},
+	}
+	if actual := cars; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSelectStructsWithJSONColumn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT id, tags FROM cars").Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			columnType(ctrl, "id", "INTEGER", false),
+			columnType(ctrl, "tags", "jsonb", true),
+		}, nil),
+		mockRows.EXPECT().Next().Return(true),
+		mockRows.EXPECT().Scan(ValueScanMatcher(int64(1)), ValueScanMatcher([]byte(`["fast","red"]`))).Return(nil),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+
+	type taggedCar struct {
+		ID   int64    `db:"id,pk"`
+		Tags []string `db:"tags"`
+	}
+	var cars []taggedCar
+	err := query.SelectStructs(context.Background(), mockTx, &cars, "SELECT id, tags FROM cars")
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	expected := []taggedCar{
+		{ID: 1, Tags: []string{"fast", "red"}},
+	}
+	if actual := cars; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSelectStructsWithUnmappedColumn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().QueryContext(gomock.Any(), "SELECT * FROM cars").Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			columnType(ctrl, "id", "INTEGER", false),
+			columnType(ctrl, "wheels", "INTEGER", false),
+		}, nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+
+	var cars []car
+	err := query.SelectStructs(context.Background(), mockTx, &cars, "SELECT * FROM cars")
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestSelectStructsWithInvalidDest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+
+	var cars []car
+	err := query.SelectStructs(context.Background(), mockTx, cars, "SELECT * FROM cars")
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestUpsertStruct(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockResult := mocks.NewMockResult(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "cars" ("brand", "extra") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "brand" = EXCLUDED."brand", "extra" = EXCLUDED."extra"`, "ferrari", `{"a":"b"}`).Return(mockResult, nil),
+		mockResult.EXPECT().LastInsertId().Return(int64(3), nil),
+	)
+
+	c := car{
+		Brand: "ferrari",
+		Extra: map[string]string{"a": "b"},
+	}
+	id, err := query.UpsertStruct(context.Background(), mockTx, "cars", &c)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := int64(3), id; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := int64(3), c.ID; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestUpsertStructWithExecFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+
+	gomock.InOrder(
+		mockTx.EXPECT().ExecContext(gomock.Any(), `INSERT INTO "cars" ("id", "brand", "colour", "extra") VALUES (?, ?, ?, ?) ON CONFLICT ("id") DO UPDATE SET "brand" = EXCLUDED."brand", "colour" = EXCLUDED."colour", "extra" = EXCLUDED."extra"`, int64(1), "ferrari", "red", nil).Return(nil, errors.New("bad")),
+	)
+
+	c := car{
+		ID:     1,
+		Brand:  "ferrari",
+		Colour: "red",
+	}
+	_, err := query.UpsertStruct(context.Background(), mockTx, "cars", &c)
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+	if expected, actual := "bad", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestUpsertStructWithoutPrimaryKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+
+	v := struct {
+		Name string `db:"name"`
+	}{}
+	_, err := query.UpsertStruct(context.Background(), mockTx, "cars", &v)
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestDeleteWhere(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockResult := mocks.NewMockResult(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().ExecContext(gomock.Any(), `DELETE FROM "cars" WHERE "brand" = ? AND "extra" IS NULL`, "ferrari").Return(mockResult, nil),
+		mockResult.EXPECT().RowsAffected().Return(int64(2), nil),
+	)
+
+	n, err := query.DeleteWhere(context.Background(), mockTx, "cars", car{Brand: "ferrari"})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := int64(2), n; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestDeleteWhereWithEmptyFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+
+	v := struct {
+		ID int64 `db:"id,pk"`
+	}{}
+	_, err := query.DeleteWhere(context.Background(), mockTx, "cars", v)
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+// Return a column type with the given name and type, which may or may not be
+// NULL.
+func columnType(ctrl *gomock.Controller, name, typeName string, nullable bool) database.ColumnType {
+	mockColumnType := mocks.NewMockColumnType(ctrl)
+	mockColumnType.EXPECT().Name().Return(name).AnyTimes()
+	mockColumnType.EXPECT().DatabaseTypeName().Return(typeName).AnyTimes()
+	mockColumnType.EXPECT().Nullable().Return(nullable, true).AnyTimes()
+	return mockColumnType
+}
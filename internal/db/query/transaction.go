@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/bicycolet/bicycolet/internal/db/database"
@@ -8,8 +9,10 @@ import (
 )
 
 // Transaction executes the given function within a database transaction.
-func Transaction(db database.DB, f func(database.Tx) error) error {
-	tx, err := db.Begin()
+// If the context is done before the transaction is committed, the transaction
+// is rolled back.
+func Transaction(ctx context.Context, db database.DB, f func(database.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin transaction")
 	}
@@ -20,6 +23,11 @@ func Transaction(db database.DB, f func(database.Tx) error) error {
 
 	err = tx.Commit()
 	if err == sql.ErrTxDone {
+		// The transaction is rolled back as soon as the context is done, in
+		// which case nothing was committed.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return errors.Wrap(ctxErr, "transaction rolled back")
+		}
 		err = nil // Ignore duplicate commits/rollbacks
 	}
 	return errors.WithStack(err)
@@ -3,6 +3,7 @@
 package query_test
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 	"testing"
@@ -17,7 +18,7 @@ func TestTransaction_BeginError(t *testing.T) {
 	db := newDB(t)
 	db.Close()
 
-	err := query.Transaction(db, func(database.Tx) error { return nil })
+	err := query.Transaction(context.Background(), db, func(database.Tx) error { return nil })
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -31,7 +32,7 @@ func TestTransaction_FunctionError(t *testing.T) {
 	db := newDB(t)
 	defer db.Close()
 
-	err := query.Transaction(db, func(tx database.Tx) error {
+	err := query.Transaction(context.Background(), db, func(tx database.Tx) error {
 		_, err := tx.Exec("CREATE TABLE test (id INTEGER)")
 		if err != nil {
 			t.Errorf("expected err to be nil: %v", err)
@@ -48,7 +49,7 @@ func TestTransaction_FunctionError(t *testing.T) {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	tables, err := query.SelectStrings(tx, "SELECT table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE'")
+	tables, err := query.SelectStrings(context.Background(), tx, "SELECT table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE'")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -59,11 +60,11 @@ func TestTransaction_FunctionError(t *testing.T) {
 
 // Return a new in-memory SQLite database.
 func newDB(t *testing.T) database.DB {
-	db, err := sql.Open(database.DriverName(), connectionInfo())
+	db, err := sql.Open(database.Postgres, connectionInfo())
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
-	return database.NewShimDB(db)
+	return database.NewShimDB(db, postgres)
 }
 
 func contains(a []string, b string) bool {
@@ -75,6 +76,15 @@ func contains(a []string, b string) bool {
 	return false
 }
 
+// postgres is the dialect of the database the tests run against.
+var postgres = func() database.Dialect {
+	dialect, err := database.NewDialect(database.Postgres)
+	if err != nil {
+		panic(err)
+	}
+	return dialect
+}()
+
 func connectionInfo() string {
 	info := database.ConnectionInfo{
 		Host:     "localhost",
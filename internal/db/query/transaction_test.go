@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -18,13 +19,16 @@ func TestTransaction(t *testing.T) {
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTx, nil),
 		mockTx.EXPECT().Commit().Return(nil),
 	)
 
 	var called bool
-	err := query.Transaction(mockDB, func(tx database.Tx) error {
+	err := query.Transaction(ctx, mockDB, func(tx database.Tx) error {
 		called = true
 		return nil
 	})
@@ -43,10 +47,10 @@ func TestTransactionWithBeginFailure(t *testing.T) {
 	mockDB := mocks.NewMockDB(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(nil, errors.New("bad")),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(nil, errors.New("bad")),
 	)
 
-	err := query.Transaction(mockDB, func(tx database.Tx) error {
+	err := query.Transaction(context.Background(), mockDB, func(tx database.Tx) error {
 		t.Fail()
 		return nil
 	})
@@ -63,11 +67,11 @@ func TestTransactionWithFuncFailure(t *testing.T) {
 	mockTx := mocks.NewMockTx(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockTx.EXPECT().Rollback().Return(nil),
 	)
 
-	err := query.Transaction(mockDB, func(tx database.Tx) error {
+	err := query.Transaction(context.Background(), mockDB, func(tx database.Tx) error {
 		return errors.New("bad")
 	})
 	if err == nil {
@@ -83,11 +87,11 @@ func TestTransactionWithRollbackFailure(t *testing.T) {
 	mockTx := mocks.NewMockTx(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockTx.EXPECT().Rollback().Return(errors.New("bad")),
 	)
 
-	err := query.Transaction(mockDB, func(tx database.Tx) error {
+	err := query.Transaction(context.Background(), mockDB, func(tx database.Tx) error {
 		return errors.New("bad")
 	})
 	if err == nil {
@@ -103,11 +107,11 @@ func TestTransactionWithCommitTxFailure(t *testing.T) {
 	mockTx := mocks.NewMockTx(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockTx.EXPECT().Commit().Return(sql.ErrTxDone),
 	)
 
-	err := query.Transaction(mockDB, func(tx database.Tx) error {
+	err := query.Transaction(context.Background(), mockDB, func(tx database.Tx) error {
 		return nil
 	})
 	if err != nil {
@@ -115,6 +119,33 @@ func TestTransactionWithCommitTxFailure(t *testing.T) {
 	}
 }
 
+func TestTransactionWithCancelledContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mocks.NewMockDB(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gomock.InOrder(
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTx, nil),
+		mockTx.EXPECT().Commit().Return(sql.ErrTxDone),
+	)
+
+	err := query.Transaction(ctx, mockDB, func(tx database.Tx) error {
+		cancel()
+		return nil
+	})
+	if err == nil {
+		t.Fatalf("expected err not to be nil")
+	}
+	if expected, actual := context.Canceled, errors.Cause(err); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
 func TestTransactionWithCommitFailure(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -123,11 +154,11 @@ func TestTransactionWithCommitFailure(t *testing.T) {
 	mockTx := mocks.NewMockTx(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockTx.EXPECT().Commit().Return(errors.New("bad")),
 	)
 
-	err := query.Transaction(mockDB, func(tx database.Tx) error {
+	err := query.Transaction(context.Background(), mockDB, func(tx database.Tx) error {
 		return nil
 	})
 	if err == nil {
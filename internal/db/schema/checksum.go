@@ -0,0 +1,238 @@
+package schema
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/pkg/errors"
+)
+
+// Return the checksums of the given updates, indexed by version minus one.
+//
+// The checksum of an update covers its declared identifier, or else the SQL
+// it executes, along with the checksum of the update before it. So changing
+// or reordering an update changes its checksum and the one of every update
+// after it.
+func checksums(dialect database.Dialect, updates []Update, identifiers map[int]string) []string {
+	var (
+		sums     = make([]string, len(updates))
+		previous string
+	)
+	for i, update := range updates {
+		content, ok := identifiers[i+1]
+		if !ok {
+			tx := &recordingTx{dialect: dialect}
+			// The update might fail because the queries it depends on don't
+			// yield any rows, in which case the statements executed up to
+			// then are what it's checksummed with.
+			update(tx)
+			content = strings.Join(tx.statements, ";\n")
+		}
+		sum := sha256.Sum256([]byte(previous + "\n" + content))
+		sums[i] = hex.EncodeToString(sum[:])
+		previous = sums[i]
+	}
+	return sums
+}
+
+// Check the recorded checksums of the applied updates against the expected
+// ones, failing with ErrChecksumMismatch if any of them doesn't match.
+//
+// Versions that were recorded before checksums were get the expected
+// checksum recorded, since there's nothing to check them against. If accept
+// is true, mismatching checksums are replaced with the expected ones instead
+// of failing.
+func verifyChecksums(tx database.Tx, sums []string, appVersion string, accept bool) error {
+	recorded, err := selectSchemaChecksums(tx)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch update checksums")
+	}
+
+	var mismatches []string
+	for _, r := range recorded {
+		// Versions more recent than the updates are reported when applying
+		// them.
+		if r.version < 1 || r.version > len(sums) {
+			continue
+		}
+		expected := sums[r.version-1]
+		switch {
+		case !r.checksum.Valid:
+			if err := updateSchemaChecksum(tx, r.version, expected, r.appVersion.String); err != nil {
+				return errors.Wrapf(err, "failed to record checksum of version %d", r.version)
+			}
+		case r.checksum.String != expected && accept:
+			if err := updateSchemaChecksum(tx, r.version, expected, appVersion); err != nil {
+				return errors.Wrapf(err, "failed to accept checksum of version %d", r.version)
+			}
+		case r.checksum.String != expected:
+			appliedBy := "an unknown version"
+			if r.appVersion.Valid {
+				appliedBy = "version " + r.appVersion.String
+			}
+			mismatches = append(mismatches, fmt.Sprintf(
+				"update %d applied by %s has checksum %s, expected %s",
+				r.version, appliedBy, shortChecksum(r.checksum.String), shortChecksum(expected)))
+		}
+	}
+	if len(mismatches) > 0 {
+		return errors.Wrapf(ErrChecksumMismatch,
+			"updates changed since they were applied (%s)", strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// Record the expected checksums of the versions inserted by the fresh
+// schema, which only records its versions.
+func recordFreshChecksums(tx database.Tx, sums []string, appVersion string) error {
+	versions, err := selectSchemaVersions(tx)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch update versions")
+	}
+	for _, version := range versions {
+		if version < 1 || version > len(sums) {
+			continue
+		}
+		if err := updateSchemaChecksum(tx, version, sums[version-1], appVersion); err != nil {
+			return errors.Wrapf(err, "failed to record checksum of version %d", version)
+		}
+	}
+	return nil
+}
+
+// Shorten the given checksum for reporting it.
+func shortChecksum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
+
+type schemaChecksum struct {
+	version    int
+	checksum   sql.NullString
+	appVersion sql.NullString
+}
+
+// Return the checksum and the application version of every version in the
+// schema table, in increasing order.
+func selectSchemaChecksums(tx database.Tx) ([]schemaChecksum, error) {
+	var recorded []schemaChecksum
+	dest := func(i int) []interface{} {
+		recorded = append(recorded, schemaChecksum{})
+		return []interface{}{&recorded[i].version, &recorded[i].checksum, &recorded[i].appVersion}
+	}
+	err := query.SelectObjects(context.Background(), tx, dest, StmtSelectSchemaChecksums)
+	return recorded, errors.WithStack(err)
+}
+
+// Set the checksum and the application version of a version in the schema
+// table.
+func updateSchemaChecksum(tx database.Tx, version int, checksum, appVersion string) error {
+	_, err := tx.Exec(StmtUpdateSchemaChecksum, checksum, nullable(appVersion), version)
+	return errors.WithStack(err)
+}
+
+// Return the given string, or nil for storing NULL if it's empty.
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// recordingTx is a transaction that records the statements executed against
+// it instead of executing them, so that the SQL of an update can be known
+// without applying it. Queries yield no rows.
+type recordingTx struct {
+	dialect    database.Dialect
+	statements []string
+}
+
+func (t *recordingTx) Query(query string, args ...interface{}) (database.Rows, error) {
+	t.record(query, args)
+	return emptyRows{}, nil
+}
+
+func (t *recordingTx) QueryContext(ctx context.Context, query string, args ...interface{}) (database.Rows, error) {
+	return t.Query(query, args...)
+}
+
+func (t *recordingTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	t.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (t *recordingTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.Exec(query, args...)
+}
+
+func (t *recordingTx) StmtContext(ctx context.Context, stmt database.Stmt) database.Stmt {
+	return unrecordableStmt{}
+}
+
+func (t *recordingTx) Dialect() database.Dialect {
+	return t.dialect
+}
+
+func (t *recordingTx) Commit() error {
+	return nil
+}
+
+func (t *recordingTx) Rollback() error {
+	return nil
+}
+
+func (t *recordingTx) record(query string, args []interface{}) {
+	t.statements = append(t.statements, formatStatement(query, args))
+}
+
+// emptyRows are the rows yielded by the queries of a recordingTx.
+type emptyRows struct{}
+
+func (emptyRows) Columns() ([]string, error) {
+	return nil, nil
+}
+
+func (emptyRows) ColumnTypes() ([]database.ColumnType, error) {
+	return nil, nil
+}
+
+func (emptyRows) Next() bool {
+	return false
+}
+
+func (emptyRows) Scan(dest ...interface{}) error {
+	return errors.Errorf("no rows to scan")
+}
+
+func (emptyRows) Err() error {
+	return nil
+}
+
+func (emptyRows) Close() error {
+	return nil
+}
+
+// unrecordableStmt is what a recordingTx binds prepared statements to, since
+// their SQL isn't known.
+type unrecordableStmt struct{}
+
+func (unrecordableStmt) QueryContext(ctx context.Context, args ...interface{}) (database.Rows, error) {
+	return nil, errors.Errorf("prepared statements can't be recorded")
+}
+
+func (unrecordableStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return nil, errors.Errorf("prepared statements can't be recorded")
+}
+
+func (unrecordableStmt) Close() error {
+	return nil
+}
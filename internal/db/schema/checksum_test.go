@@ -0,0 +1,240 @@
+package schema_test
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/db/schema"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+func TestChecksums(t *testing.T) {
+	t.Parallel()
+
+	create := exec("CREATE TABLE test (id INTEGER)")
+	insert := exec("INSERT INTO test VALUES (1)")
+
+	sums := schema.Checksums(sqlite, []schema.Update{create, insert}, nil)
+	if expected, actual := 2, len(sums); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+	if sums[0] == sums[1] {
+		t.Errorf("expected checksums to differ: %v", sums)
+	}
+
+	// The checksums are stable.
+	if expected, actual := sums, schema.Checksums(sqlite, []schema.Update{create, insert}, nil); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	// Changing an update changes its checksum and the ones after it.
+	changed := schema.Checksums(sqlite, []schema.Update{exec("CREATE TABLE test (id TEXT)"), insert}, nil)
+	if changed[0] == sums[0] || changed[1] == sums[1] {
+		t.Errorf("expected all checksums to change: %v, %v", sums, changed)
+	}
+
+	// An update with an identifier is checksummed with it instead of its SQL.
+	identified := schema.Checksums(sqlite, []schema.Update{create, exec("INSERT INTO test VALUES (2)")}, map[int]string{2: "insert"})
+	if expected, actual := identified, schema.Checksums(sqlite, []schema.Update{create, insert}, map[int]string{2: "insert"}); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSchemaEnsureRecordsChecksums(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id INTEGER)"))
+	s.AppVersion("0.1")
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	s.Add(exec("INSERT INTO test VALUES (1)"))
+	s.AppVersion("0.2")
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	sums := schema.Checksums(sqlite, []schema.Update{
+		exec("CREATE TABLE test (id INTEGER)"),
+		exec("INSERT INTO test VALUES (1)"),
+	}, nil)
+	expected := []string{sums[0] + " 0.1", sums[1] + " 0.2"}
+	if actual := selectChecksums(t, db); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// If an applied update has changed, Ensure fails and nothing is applied.
+func TestSchemaEnsureWithChangedUpdate(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id INTEGER)"))
+	s.AppVersion("0.1")
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	s = schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id TEXT)"))
+	s.Add(exec("INSERT INTO test VALUES (1)"))
+	_, err := s.Ensure(db)
+	if expected, actual := schema.ErrChecksumMismatch, errors.Cause(err); expected != actual {
+		t.Fatalf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := "update 1 applied by version 0.1 has checksum", err.Error(); !strings.Contains(actual, expected) {
+		t.Errorf("expected %q to contain %q", actual, expected)
+	}
+	if expected, actual := 1, len(selectChecksums(t, db)); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+
+	// Accepting the checksums records the new ones and applies the updates.
+	s.AppVersion("0.2")
+	s.AcceptChecksums(true)
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	sums := schema.Checksums(sqlite, []schema.Update{
+		exec("CREATE TABLE test (id TEXT)"),
+		exec("INSERT INTO test VALUES (1)"),
+	}, nil)
+	expected := []string{sums[0] + " 0.2", sums[1] + " 0.2"}
+	if actual := selectChecksums(t, db); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// A schema table created before checksums were recorded gets the columns
+// added, and the checksums of the applied updates recorded.
+func TestSchemaEnsureWithSchemaTableWithoutChecksums(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	if err := query.Transaction(context.Background(), db, func(tx database.Tx) error {
+		_, err := tx.Exec(`
+CREATE TABLE schema (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    version    INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL,
+    UNIQUE (version)
+);
+CREATE TABLE test (id INTEGER);
+INSERT INTO schema (version, updated_at) VALUES (1, strftime("%s"));
+`)
+		return err
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id INTEGER)"))
+	s.AppVersion("0.2")
+	current, err := s.Ensure(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, current; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+
+	sums := schema.Checksums(sqlite, []schema.Update{exec("CREATE TABLE test (id INTEGER)")}, nil)
+	expected := []string{sums[0] + " <nil>"}
+	if actual := selectChecksums(t, db); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// The versions inserted by the fresh schema get their checksums recorded.
+func TestSchemaEnsureWithFreshSchemaRecordsChecksums(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id INTEGER)"))
+	s.Add(exec("INSERT INTO test VALUES (1)"))
+	s.Fresh(`
+CREATE TABLE test (id INTEGER);
+INSERT INTO schema (version, updated_at) VALUES (2, strftime("%s"))
+`)
+	s.AppVersion("0.1")
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	sums := schema.Checksums(sqlite, []schema.Update{
+		exec("CREATE TABLE test (id INTEGER)"),
+		exec("INSERT INTO test VALUES (1)"),
+	}, nil)
+	expected := []string{sums[1] + " 0.1"}
+	if actual := selectChecksums(t, db); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// Return an update executing the given statement.
+func exec(stmt string) schema.Update {
+	return func(tx database.Tx) error {
+		_, err := tx.Exec(stmt)
+		return err
+	}
+}
+
+// Open a scratch SQLite database.
+func openSQLite(t *testing.T) database.DB {
+	db, err := sql.Open(database.SQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	return database.NewShimDB(db, sqlite)
+}
+
+// Return the checksum and the application version of every version in the
+// schema table, separated by a space.
+func selectChecksums(t *testing.T, db database.DB) []string {
+	var checksums []string
+	if err := query.Transaction(context.Background(), db, func(tx database.Tx) error {
+		var rows []struct {
+			checksum, appVersion sql.NullString
+		}
+		dest := func(i int) []interface{} {
+			rows = append(rows, struct {
+				checksum, appVersion sql.NullString
+			}{})
+			return []interface{}{&rows[i].checksum, &rows[i].appVersion}
+		}
+		if err := query.SelectObjects(context.Background(), tx, dest, "SELECT checksum, app_version FROM schema ORDER BY version"); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			appVersion := "<nil>"
+			if row.appVersion.Valid {
+				appVersion = row.appVersion.String
+			}
+			checksums = append(checksums, row.checksum.String+" "+appVersion)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	return checksums
+}
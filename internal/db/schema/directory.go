@@ -0,0 +1,166 @@
+package schema
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+// migrationFile matches the name of a SQL file holding a schema update, such
+// as 0001_create_users.up.sql, or the one undoing it, such as
+// 0001_create_users.down.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a schema update loaded from a SQL file, along with the update
+// undoing it.
+type Migration struct {
+	// Version of the schema the update brings it to, as numbered by its file.
+	Version int
+
+	// Name of the update, as given by its file.
+	Name string
+
+	// Up applies the update, executing the statements of its up file one by
+	// one.
+	Up Update
+
+	// Down undoes the update, executing the statements of its down file, or
+	// is nil if the update has no down file.
+	Down Update
+}
+
+// FromDirectory loads the schema updates written as SQL files in the given
+// directory, returning them in order.
+//
+// Each update has a file named after its version and its name, such as
+// 0001_create_users.up.sql, and optionally a down file undoing it, such as
+// 0001_create_users.down.sql. The versions must follow each other without
+// gaps, although they needn't start at 1, so that the updates can follow the
+// ones written in Go, as added by Schema.AddMigrations. Files not ending in
+// .sql are ignored.
+func FromDirectory(fileSystem fsys.FileSystem, dir string) ([]Migration, error) {
+	dir = filepath.Clean(dir)
+
+	migrations := make(map[int]*Migration)
+	err := fileSystem.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Dir(path) != dir || filepath.Ext(path) != ".sql" {
+			return nil
+		}
+
+		name := filepath.Base(path)
+		match := migrationFile.FindStringSubmatch(name)
+		if match == nil {
+			return errors.Errorf("file %q isn't named like 0001_name.up.sql or 0001_name.down.sql", name)
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version < 1 {
+			return errors.Errorf("file %q has an invalid version", name)
+		}
+
+		migration, ok := migrations[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrations[version] = migration
+		}
+		if migration.Name != match[2] {
+			return errors.Errorf("files of version %d have different names: %q and %q", version, migration.Name, match[2])
+		}
+
+		update, err := loadMigrationFile(fileSystem, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if match[3] == "up" {
+			migration.Up = update
+		} else {
+			migration.Down = update
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load updates from %q", dir)
+	}
+
+	versions := make([]int, 0, len(migrations))
+	for version, migration := range migrations {
+		if migration.Up == nil {
+			return nil, errors.Errorf("update %d of %q has no up file", version, dir)
+		}
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	result := make([]Migration, len(versions))
+	for i, version := range versions {
+		result[i] = *migrations[version]
+	}
+	if len(versions) > 0 {
+		if err := checkSchemaVersionsHaveNoHoles(versions); err != nil {
+			return nil, errors.Wrapf(err, "invalid updates in %q", dir)
+		}
+	}
+	return result, nil
+}
+
+// AddMigrations appends the given migrations to the schema, along with the
+// updates undoing them. The first migration must bring the schema to the
+// version following the updates already added, and the others must follow it
+// without gaps. More updates can be added after them with Add.
+func (s *Schema) AddMigrations(migrations []Migration) error {
+	for i, migration := range migrations {
+		if expected := len(s.updates) + 1 + i; migration.Version != expected {
+			return errors.Errorf("update %q has version %d, expected %d", migration.Name, migration.Version, expected)
+		}
+	}
+	for _, migration := range migrations {
+		s.Add(migration.Up)
+		if migration.Down != nil {
+			s.Down(migration.Version, migration.Down)
+		}
+	}
+	return nil
+}
+
+// Return an update executing the statements of the given SQL file one by one.
+func loadMigrationFile(fileSystem fsys.FileSystem, path string) (Update, error) {
+	file, err := fileSystem.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file")
+	}
+	defer file.Close()
+
+	bytes, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file %q", path)
+	}
+	script := string(bytes)
+
+	// Catch a broken file when loading it, rather than when applying it.
+	if _, err := query.SplitStatements(script); err != nil {
+		return nil, errors.Wrapf(err, "invalid file %q", path)
+	}
+
+	name := filepath.Base(path)
+	return func(tx database.Tx) error {
+		err := query.ExecScript(context.Background(), tx, script)
+		return errors.Wrap(err, name)
+	}, nil
+}
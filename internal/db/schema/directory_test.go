@@ -0,0 +1,155 @@
+package schema_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/db/schema"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+)
+
+func TestFromDirectory(t *testing.T) {
+	t.Parallel()
+
+	fileSystem := fsys.NewVirtualFileSystem()
+	writeFile(t, fileSystem, "/updates/0003_add_email.up.sql", "ALTER TABLE users ADD COLUMN email TEXT;\n")
+	writeFile(t, fileSystem, "/updates/0002_create_users.up.sql", "CREATE TABLE users (id INTEGER, name TEXT);\nINSERT INTO users VALUES (1, 'a;b');\n")
+	writeFile(t, fileSystem, "/updates/0002_create_users.down.sql", "DROP TABLE users;\n")
+	writeFile(t, fileSystem, "/updates/README.md", "Schema updates of the test database.\n")
+
+	migrations, err := schema.FromDirectory(fileSystem, "/updates")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 2, len(migrations); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+	for i, name := range []string{"create_users", "add_email"} {
+		if expected, actual := i+2, migrations[i].Version; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := name, migrations[i].Name; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+	}
+	if migrations[1].Down != nil {
+		t.Errorf("expected update without down file to have no down update")
+	}
+
+	// The updates of the files follow the ones written in Go.
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := schema.Empty(fileSystem)
+	s.Add(exec("CREATE TABLE config (key TEXT, value TEXT)"))
+	if err := s.AddMigrations(migrations); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	s.Add(exec("UPDATE users SET email = 'a@example.com'"))
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	var count int
+	if err := query.Transaction(context.Background(), db, func(tx database.Tx) error {
+		count, err = query.Count(context.Background(), tx, "users", query.Eq("name", "a;b"), query.Eq("email", "a@example.com"))
+		return err
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, count; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+
+	// Only the update with a down file can be rolled back.
+	if _, err := s.Rollback(db, 1); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestFromDirectoryWithInvalidFiles(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		files  map[string]string
+		result string
+	}{
+		{
+			name: "gap",
+			files: map[string]string{
+				"/updates/0001_first.up.sql": "SELECT 1",
+				"/updates/0003_third.up.sql": "SELECT 3",
+			},
+			result: "missing updates: 1 -> 3",
+		},
+		{
+			name: "down file only",
+			files: map[string]string{
+				"/updates/0001_first.down.sql": "SELECT 1",
+			},
+			result: "update 1 of \"/updates\" has no up file",
+		},
+		{
+			name: "different names",
+			files: map[string]string{
+				"/updates/0001_first.up.sql":   "SELECT 1",
+				"/updates/0001_other.up.sql":   "SELECT 1",
+				"/updates/0001_first.down.sql": "SELECT 1",
+			},
+			result: "files of version 1 have different names",
+		},
+		{
+			name: "badly named file",
+			files: map[string]string{
+				"/updates/first.sql": "SELECT 1",
+			},
+			result: "file \"first.sql\" isn't named like 0001_name.up.sql",
+		},
+		{
+			name: "unterminated quote",
+			files: map[string]string{
+				"/updates/0001_first.up.sql": "SELECT 1;\nSELECT 'a",
+			},
+			result: "unterminated quote ' at line 2",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fileSystem := fsys.NewVirtualFileSystem()
+			for path, content := range test.files {
+				writeFile(t, fileSystem, path, content)
+			}
+
+			_, err := schema.FromDirectory(fileSystem, "/updates")
+			if err == nil {
+				t.Fatalf("expected err not to be nil")
+			}
+			if expected, actual := test.result, err.Error(); !strings.Contains(actual, expected) {
+				t.Errorf("expected %q to contain %q", actual, expected)
+			}
+		})
+	}
+}
+
+func TestSchemaAddMigrationsWithVersionMismatch(t *testing.T) {
+	t.Parallel()
+
+	fileSystem := fsys.NewVirtualFileSystem()
+	writeFile(t, fileSystem, "/updates/0001_first.up.sql", "SELECT 1")
+
+	migrations, err := schema.FromDirectory(fileSystem, "/updates")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	s := schema.Empty(fileSystem)
+	s.Add(exec("SELECT 0"))
+	err = s.AddMigrations(migrations)
+	if expected, actual := `update "first" has version 1, expected 2`, err.Error(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+	if expected, actual := 1, s.Len(); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
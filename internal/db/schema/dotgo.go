@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DotGo renders the given schema dump as the source of a Go file in the given
+// package, declaring the dump as a constant with the given name. The dump is
+// expected to be generated using the Dump() method.
+func DotGo(dump, pkg, name string) ([]byte, error) {
+	if strings.Contains(dump, "`") {
+		return nil, errors.Errorf("schema dump can not contain backticks")
+	}
+
+	source := fmt.Sprintf(dotGoTemplate, pkg, name, strings.TrimSpace(dump))
+	formatted, err := format.Source([]byte(source))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to format schema source")
+	}
+	return formatted, nil
+}
+
+const dotGoTemplate = "// Code generated by \"bicycolet db schema dump\". DO NOT EDIT.\n\n" +
+	"package %[1]s\n\n" +
+	"// %[2]s is the flattening of all the schema updates into a single\n" +
+	"// statement, which is used to create the schema from scratch.\n" +
+	"const %[2]s = `\n%[3]s\n`\n"
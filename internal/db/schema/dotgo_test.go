@@ -0,0 +1,31 @@
+package schema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/schema"
+)
+
+func TestDotGo(t *testing.T) {
+	source, err := schema.DotGo("\nCREATE TABLE foo (id INTEGER);\n", "foo", "freshSchema")
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	for _, want := range []string{
+		"// Code generated by \"bicycolet db schema dump\". DO NOT EDIT.\n",
+		"package foo\n",
+		"const freshSchema = `\nCREATE TABLE foo (id INTEGER);\n`\n",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("expected %q to contain %q", source, want)
+		}
+	}
+}
+
+func TestDotGoWithBackticks(t *testing.T) {
+	if _, err := schema.DotGo("CREATE TABLE `foo` (id INTEGER)", "foo", "freshSchema"); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
@@ -8,3 +8,7 @@ import "github.com/pkg/errors"
 // Every change performed so by the Check will be committed, although
 // ErrGracefulAbort will be returned.
 var ErrGracefulAbort = errors.Errorf("schema check gracefully aborted")
+
+// ErrChecksumMismatch is returned by Schema.Ensure when an update that was
+// already applied has changed since, as told by its checksum.
+var ErrChecksumMismatch = errors.Errorf("schema checksum mismatch")
@@ -6,5 +6,7 @@ var (
 	QueryCurrentVersion            = queryCurrentVersion
 	EnsureSchemaTableExists        = ensureSchemaTableExists
 	EnsureUpdatesAreApplied        = ensureUpdatesAreApplied
+	EnsureUpdatesAreRolledBack     = ensureUpdatesAreRolledBack
+	Checksums                      = checksums
 	CheckSchemaVersionsHaveNoHoles = checkSchemaVersionsHaveNoHoles
 )
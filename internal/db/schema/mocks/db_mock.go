@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	sql "database/sql"
 	database "github.com/bicycolet/bicycolet/internal/db/database"
 	gomock "github.com/golang/mock/gomock"
@@ -47,6 +48,19 @@ func (mr *MockDBMockRecorder) Begin() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockDB)(nil).Begin))
 }
 
+// BeginTx mocks base method
+func (m *MockDB) BeginTx(arg0 context.Context, arg1 *sql.TxOptions) (database.Tx, error) {
+	ret := m.ctrl.Call(m, "BeginTx", arg0, arg1)
+	ret0, _ := ret[0].(database.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTx indicates an expected call of BeginTx
+func (mr *MockDBMockRecorder) BeginTx(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockDB)(nil).BeginTx), arg0, arg1)
+}
+
 // Close mocks base method
 func (m *MockDB) Close() error {
 	ret := m.ctrl.Call(m, "Close")
@@ -59,6 +73,18 @@ func (mr *MockDBMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDB)(nil).Close))
 }
 
+// Dialect mocks base method
+func (m *MockDB) Dialect() database.Dialect {
+	ret := m.ctrl.Call(m, "Dialect")
+	ret0, _ := ret[0].(database.Dialect)
+	return ret0
+}
+
+// Dialect indicates an expected call of Dialect
+func (mr *MockDBMockRecorder) Dialect() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dialect", reflect.TypeOf((*MockDB)(nil).Dialect))
+}
+
 // Ping mocks base method
 func (m *MockDB) Ping() error {
 	ret := m.ctrl.Call(m, "Ping")
@@ -71,6 +97,31 @@ func (mr *MockDBMockRecorder) Ping() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockDB)(nil).Ping))
 }
 
+// PingContext mocks base method
+func (m *MockDB) PingContext(arg0 context.Context) error {
+	ret := m.ctrl.Call(m, "PingContext", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PingContext indicates an expected call of PingContext
+func (mr *MockDBMockRecorder) PingContext(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PingContext", reflect.TypeOf((*MockDB)(nil).PingContext), arg0)
+}
+
+// PrepareContext mocks base method
+func (m *MockDB) PrepareContext(arg0 context.Context, arg1 string) (database.Stmt, error) {
+	ret := m.ctrl.Call(m, "PrepareContext", arg0, arg1)
+	ret0, _ := ret[0].(database.Stmt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrepareContext indicates an expected call of PrepareContext
+func (mr *MockDBMockRecorder) PrepareContext(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareContext", reflect.TypeOf((*MockDB)(nil).PrepareContext), arg0, arg1)
+}
+
 // MockTx is a mock of Tx interface
 type MockTx struct {
 	ctrl     *gomock.Controller
@@ -106,6 +157,18 @@ func (mr *MockTxMockRecorder) Commit() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockTx)(nil).Commit))
 }
 
+// Dialect mocks base method
+func (m *MockTx) Dialect() database.Dialect {
+	ret := m.ctrl.Call(m, "Dialect")
+	ret0, _ := ret[0].(database.Dialect)
+	return ret0
+}
+
+// Dialect indicates an expected call of Dialect
+func (mr *MockTxMockRecorder) Dialect() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dialect", reflect.TypeOf((*MockTx)(nil).Dialect))
+}
+
 // Exec mocks base method
 func (m *MockTx) Exec(arg0 string, arg1 ...interface{}) (sql.Result, error) {
 	varargs := []interface{}{arg0}
@@ -124,6 +187,24 @@ func (mr *MockTxMockRecorder) Exec(arg0 interface{}, arg1 ...interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockTx)(nil).Exec), varargs...)
 }
 
+// ExecContext mocks base method
+func (m *MockTx) ExecContext(arg0 context.Context, arg1 string, arg2 ...interface{}) (sql.Result, error) {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext
+func (mr *MockTxMockRecorder) ExecContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockTx)(nil).ExecContext), varargs...)
+}
+
 // Query mocks base method
 func (m *MockTx) Query(arg0 string, arg1 ...interface{}) (database.Rows, error) {
 	varargs := []interface{}{arg0}
@@ -142,6 +223,24 @@ func (mr *MockTxMockRecorder) Query(arg0 interface{}, arg1 ...interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockTx)(nil).Query), varargs...)
 }
 
+// QueryContext mocks base method
+func (m *MockTx) QueryContext(arg0 context.Context, arg1 string, arg2 ...interface{}) (database.Rows, error) {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(database.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext
+func (mr *MockTxMockRecorder) QueryContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockTx)(nil).QueryContext), varargs...)
+}
+
 // Rollback mocks base method
 func (m *MockTx) Rollback() error {
 	ret := m.ctrl.Call(m, "Rollback")
@@ -154,6 +253,18 @@ func (mr *MockTxMockRecorder) Rollback() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockTx)(nil).Rollback))
 }
 
+// StmtContext mocks base method
+func (m *MockTx) StmtContext(arg0 context.Context, arg1 database.Stmt) database.Stmt {
+	ret := m.ctrl.Call(m, "StmtContext", arg0, arg1)
+	ret0, _ := ret[0].(database.Stmt)
+	return ret0
+}
+
+// StmtContext indicates an expected call of StmtContext
+func (mr *MockTxMockRecorder) StmtContext(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StmtContext", reflect.TypeOf((*MockTx)(nil).StmtContext), arg0, arg1)
+}
+
 // MockRows is a mock of Rows interface
 type MockRows struct {
 	ctrl     *gomock.Controller
@@ -289,3 +400,28 @@ func (m *MockColumnType) DatabaseTypeName() string {
 func (mr *MockColumnTypeMockRecorder) DatabaseTypeName() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatabaseTypeName", reflect.TypeOf((*MockColumnType)(nil).DatabaseTypeName))
 }
+
+// Name mocks base method
+func (m *MockColumnType) Name() string {
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name
+func (mr *MockColumnTypeMockRecorder) Name() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockColumnType)(nil).Name))
+}
+
+// Nullable mocks base method
+func (m *MockColumnType) Nullable() (bool, bool) {
+	ret := m.ctrl.Call(m, "Nullable")
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Nullable indicates an expected call of Nullable
+func (mr *MockColumnTypeMockRecorder) Nullable() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nullable", reflect.TypeOf((*MockColumnType)(nil).Nullable))
+}
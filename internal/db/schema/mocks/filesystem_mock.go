@@ -7,7 +7,7 @@ package mocks
 import (
 	fsys "github.com/bicycolet/bicycolet/internal/fsys"
 	gomock "github.com/golang/mock/gomock"
-	os "os"
+	fs "io/fs"
 	filepath "path/filepath"
 	reflect "reflect"
 	time "time"
@@ -112,7 +112,7 @@ func (mr *MockFileSystemMockRecorder) Lock(arg0 interface{}) *gomock.Call {
 }
 
 // Mkdir mocks base method
-func (m *MockFileSystem) Mkdir(arg0 string, arg1 os.FileMode) error {
+func (m *MockFileSystem) Mkdir(arg0 string, arg1 fs.FileMode) error {
 	ret := m.ctrl.Call(m, "Mkdir", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
@@ -124,7 +124,7 @@ func (mr *MockFileSystemMockRecorder) Mkdir(arg0, arg1 interface{}) *gomock.Call
 }
 
 // MkdirAll mocks base method
-func (m *MockFileSystem) MkdirAll(arg0 string, arg1 os.FileMode) error {
+func (m *MockFileSystem) MkdirAll(arg0 string, arg1 fs.FileMode) error {
 	ret := m.ctrl.Call(m, "MkdirAll", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
@@ -149,7 +149,7 @@ func (mr *MockFileSystemMockRecorder) Open(arg0 interface{}) *gomock.Call {
 }
 
 // OpenFile mocks base method
-func (m *MockFileSystem) OpenFile(arg0 string, arg1 int, arg2 os.FileMode) (fsys.File, error) {
+func (m *MockFileSystem) OpenFile(arg0 string, arg1 int, arg2 fs.FileMode) (fsys.File, error) {
 	ret := m.ctrl.Call(m, "OpenFile", arg0, arg1, arg2)
 	ret0, _ := ret[0].(fsys.File)
 	ret1, _ := ret[1].(error)
@@ -21,7 +21,7 @@ func InOrder(calls ...*gomock.Call) (last *gomock.Call) {
 
 func expectSchemaTableExists(mockTx *mocks.MockTx, mockRows *mocks.MockRows, value int) *gomock.Call {
 	return InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(mockRows, nil),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(gomock.Any()).SetArg(0, value).Return(nil),
 		mockRows.EXPECT().Close().Return(nil),
@@ -32,7 +32,7 @@ func expectCurrentVersion(ctrl *gomock.Controller, mockTx *mocks.MockTx, mockRow
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	return InOrder(
-		mockTx.EXPECT().Query(schema.StmtSelectSchemaVersions).Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), schema.StmtSelectSchemaVersions).Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -44,3 +44,30 @@ func expectCurrentVersion(ctrl *gomock.Controller, mockTx *mocks.MockTx, mockRow
 		mockRows.EXPECT().Close().Return(nil),
 	)
 }
+
+func expectSchemaColumns(mockTx *mocks.MockTx, mockRows *mocks.MockRows) *gomock.Call {
+	return InOrder(
+		mockTx.EXPECT().Query(schema.StmtSelectSchemaColumns).Return(mockRows, nil),
+		mockRows.EXPECT().Columns().Return([]string{"id", "version", "updated_at", "checksum", "app_version"}, nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+}
+
+// Expect the checksums to be selected, yielding none.
+func expectChecksums(mockTx *mocks.MockTx, mockRows *mocks.MockRows) *gomock.Call {
+	return InOrder(
+		mockTx.EXPECT().QueryContext(gomock.Any(), schema.StmtSelectSchemaChecksums).Return(mockRows, nil),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+	)
+}
+
+// sqlite is the dialect the mocked databases speak.
+var sqlite = func() database.Dialect {
+	dialect, err := database.NewDialect(database.SQLite)
+	if err != nil {
+		panic(err)
+	}
+	return dialect
+}()
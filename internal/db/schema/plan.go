@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/pkg/errors"
+)
+
+// Plan is what Ensure would do to a database, as found out by a dry run.
+type Plan struct {
+	// Current is the version the schema of the database is at.
+	Current int
+
+	// Steps that Ensure would take, in order.
+	Steps []Step
+}
+
+// Step is a single step of a Plan.
+type Step struct {
+	// Version the step brings the schema to, or -1 if the step runs the
+	// extra queries of a file.
+	Version int
+
+	// Path of the file of extra queries the step runs, if it does.
+	Path string
+
+	// Fresh is whether the step creates the schema from the fresh dump,
+	// bringing it straight to the latest version.
+	Fresh bool
+
+	// Statements the step executes, along with their arguments.
+	Statements []string
+}
+
+// Plan does a dry run of Ensure against the given database, returning the
+// steps it would take along with the statements each of them executes.
+//
+// The dry run goes through the same steps as Ensure within a transaction that
+// is always rolled back, so the statements of an update see the changes of
+// the ones before it, while the database remains unchanged. Files of extra
+// queries are neither removed nor renamed.
+//
+// The check and the hook are invoked as they are by Ensure, with a transaction
+// for which IsDryRun returns true, so they can avoid any side effect outside
+// of the database. If the check aborts gracefully, the plan is returned along
+// with ErrGracefulAbort.
+func (s *Schema) Plan(src database.DB) (Plan, error) {
+	realTx, err := src.BeginTx(context.Background(), nil)
+	if err != nil {
+		return Plan{}, errors.WithStack(err)
+	}
+	defer realTx.Rollback()
+
+	tx := &dryRunTx{Tx: realTx}
+	current, _, aborted, err := s.ensure(tx)
+	plan := Plan{
+		Current: current,
+		Steps:   tx.steps,
+	}
+	if err != nil {
+		return plan, errors.WithStack(err)
+	}
+	if aborted {
+		return plan, ErrGracefulAbort
+	}
+	return plan, nil
+}
+
+// IsDryRun returns whether the given transaction is the one of a dry run, as
+// done by Schema.Plan. The changes made through it are rolled back at the end
+// of the dry run.
+func IsDryRun(tx database.Tx) bool {
+	_, ok := tx.(*dryRunTx)
+	return ok
+}
+
+// dryRunTx is a transaction that records the steps of Ensure, along with the
+// statements each of them executes, besides executing them against the
+// transaction it wraps. The statements keeping track of the schema version
+// aren't part of any step, so they aren't recorded. It can't be committed,
+// since the dry run rolls the wrapped transaction back once done.
+type dryRunTx struct {
+	database.Tx
+	recording  bool
+	statements []string
+	steps      []Step
+}
+
+func (t *dryRunTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	t.record(query, args)
+	return t.Tx.Exec(query, args...)
+}
+
+func (t *dryRunTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	t.record(query, args)
+	return t.Tx.ExecContext(ctx, query, args...)
+}
+
+func (t *dryRunTx) Commit() error {
+	return errors.Errorf("cannot commit the transaction of a dry run")
+}
+
+func (t *dryRunTx) Rollback() error {
+	return nil
+}
+
+func (t *dryRunTx) record(query string, args []interface{}) {
+	if t.recording {
+		t.statements = append(t.statements, formatStatement(query, args))
+	}
+}
+
+// Start recording the statements of a step, if the given transaction is the
+// one of a dry run.
+func beginStep(tx database.Tx) {
+	if t, ok := tx.(*dryRunTx); ok {
+		t.recording, t.statements = true, nil
+	}
+}
+
+// Record the given step along with the statements executed since it began, if
+// the given transaction is the one of a dry run.
+func endStep(tx database.Tx, step Step) {
+	if t, ok := tx.(*dryRunTx); ok {
+		step.Statements = t.statements
+		t.steps = append(t.steps, step)
+		t.recording, t.statements = false, nil
+	}
+}
+
+// Return the given statement along with its arguments, if any, for recording
+// it.
+func formatStatement(query string, args []interface{}) string {
+	statement := strings.TrimSpace(query)
+	if len(args) > 0 {
+		statement = fmt.Sprintf("%s %v", statement, args)
+	}
+	return statement
+}
@@ -0,0 +1,172 @@
+package schema_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/schema"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+func TestSchemaPlan(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id INTEGER)"))
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	s.Add(exec("CREATE TABLE other (id INTEGER)"))
+	s.Add(func(tx database.Tx) error {
+		// The update sees the changes of the one before it.
+		_, err := tx.Exec("INSERT INTO other (id) SELECT id FROM test WHERE id > ?", 1)
+		return err
+	})
+
+	var checked, hooked []bool
+	s.Check(func(version int, tx database.Tx) error {
+		checked = append(checked, schema.IsDryRun(tx))
+		return nil
+	})
+	s.Hook(func(version int, tx database.Tx) error {
+		hooked = append(hooked, schema.IsDryRun(tx))
+		return nil
+	})
+
+	plan, err := s.Plan(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	expected := schema.Plan{
+		Current: 1,
+		Steps: []schema.Step{
+			{Version: 2, Statements: []string{"CREATE TABLE other (id INTEGER)"}},
+			{Version: 3, Statements: []string{"INSERT INTO other (id) SELECT id FROM test WHERE id > ? [1]"}},
+		},
+	}
+	if actual := plan; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []bool{true}, checked; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []bool{true, true}, hooked; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	// The database is left alone, so Ensure still has the updates to apply.
+	current, err := s.Ensure(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, current; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := []bool{true, false}, checked; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	// Nothing is left to do.
+	plan, err = s.Plan(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := (schema.Plan{Current: 3}), plan; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSchemaPlanWithFreshSchema(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id INTEGER)"))
+	s.Add(exec("INSERT INTO test VALUES (1)"))
+	s.Fresh("CREATE TABLE test (id INTEGER)")
+
+	plan, err := s.Plan(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	expected := schema.Plan{
+		Steps: []schema.Step{
+			{Version: 2, Fresh: true, Statements: []string{"CREATE TABLE test (id INTEGER)"}},
+		},
+	}
+	if actual := plan; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// The dry run fails as Ensure would if an applied update has changed.
+func TestSchemaPlanWithChangedUpdate(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id INTEGER)"))
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	s = schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec("CREATE TABLE test (id TEXT)"))
+	s.Add(exec("INSERT INTO test VALUES (1)"))
+	plan, err := s.Plan(db)
+	if expected, actual := schema.ErrChecksumMismatch, errors.Cause(err); expected != actual {
+		t.Fatalf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := (schema.Plan{Current: 1}), plan; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// Files of extra queries are run without being removed, or renamed if they
+// fail.
+func TestSchemaPlanWithFiles(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	fileSystem := fsys.NewVirtualFileSystem()
+	writeFile(t, fileSystem, "/patch.sql", "CREATE TABLE test (id INTEGER);\nINSERT INTO test VALUES (1);\n")
+
+	s := schema.Empty(fileSystem)
+	s.File("/patch.sql")
+
+	plan, err := s.Plan(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	expected := schema.Plan{
+		Steps: []schema.Step{
+			{Version: -1, Path: "/patch.sql", Statements: []string{"CREATE TABLE test (id INTEGER)", "INSERT INTO test VALUES (1)"}},
+		},
+	}
+	if actual := plan; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if !fileSystem.Exists("/patch.sql") {
+		t.Errorf("expected %q to exist", "/patch.sql")
+	}
+
+	writeFile(t, fileSystem, "/patch.sql", "INSERT INTO missing VALUES (1);\n")
+	if _, err := s.Plan(db); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+	if !fileSystem.Exists("/patch.sql") {
+		t.Errorf("expected %q to exist", "/patch.sql")
+	}
+}
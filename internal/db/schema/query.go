@@ -1,7 +1,10 @@
 package schema
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
+	"sort"
 
 	"github.com/bicycolet/bicycolet/internal/db/database"
 	"github.com/bicycolet/bicycolet/internal/db/query"
@@ -9,21 +12,24 @@ import (
 	"github.com/pkg/errors"
 )
 
-// StmtSchemaTableExists represents a query for checking if the schema table
-// exists.
-const StmtSchemaTableExists = `
-SELECT COUNT(name) FROM sqlite_master WHERE type = 'table' AND name = 'schema'
-`
+// failedSuffix is appended to the name of a file of extra queries that failed
+// to run.
+const failedSuffix = ".failed"
 
-// StmtCreateTable represents a query for creating a schema table.
-const StmtCreateTable = `
+// StmtCreateTable provides a function for creating the sql statement that
+// creates the schema table.
+var StmtCreateTable = func(dialect database.Dialect) string {
+	return fmt.Sprintf(`
 CREATE TABLE schema (
-    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
-    version    INTEGER NOT NULL,
-    updated_at DATETIME NOT NULL,
+    id          %s,
+    version     INTEGER NOT NULL,
+    updated_at  %s NOT NULL,
+    checksum    TEXT,
+    app_version TEXT,
     UNIQUE (version)
 )
-`
+`, dialect.AutoIncrement(), dialect.DateTime())
+}
 
 // StmtSelectSchemaVersions represents a query to get the version from the
 // schema table
@@ -31,25 +37,52 @@ const StmtSelectSchemaVersions = `
 SELECT version FROM schema ORDER BY version
 `
 
-// StmtSelectTableSQL represents a query to get the sql from a table.
-const StmtSelectTableSQL = `
-SELECT sql FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema' ORDER BY name
+// StmtSelectSchemaChecksums represents a query to get the version along with
+// its checksum and the version of the application that applied it from the
+// schema table.
+const StmtSelectSchemaChecksums = `
+SELECT version, checksum, app_version FROM schema ORDER BY version
+`
+
+// StmtSelectSchemaColumns represents a query yielding no rows, but the columns
+// of the schema table.
+const StmtSelectSchemaColumns = `
+SELECT * FROM schema LIMIT 0
 `
 
-// StmtInsertSchemaVersion represents an insert query for inserting versions
-// into the schema.
-const StmtInsertSchemaVersion = `
-INSERT INTO schema (version, updated_at) VALUES (?, strftime("%s"))
+// StmtInsertSchemaVersion provides a function for creating the sql statement
+// that inserts a version into the schema, along with its checksum and the
+// version of the application that applied it.
+var StmtInsertSchemaVersion = func(dialect database.Dialect) string {
+	return fmt.Sprintf(`
+INSERT INTO schema (version, updated_at, checksum, app_version) VALUES (?, %s, ?, ?)
+`, dialect.Now())
+}
+
+// StmtUpdateSchemaChecksum represents a query to set the checksum of a version
+// in the schema table, along with the version of the application that
+// applied it.
+const StmtUpdateSchemaChecksum = `
+UPDATE schema SET checksum = ?, app_version = ? WHERE version = ?
 `
 
-// StmtDump represents a query to insert a query when performing a dump query.
-const StmtDump = `
-INSERT INTO schema (version, updated_at) VALUES (%d, strftime("%%s"))
+// StmtDeleteSchemaVersion represents a query to remove a version from the
+// schema table.
+const StmtDeleteSchemaVersion = `
+DELETE FROM schema WHERE version = ?
 `
 
+// StmtDump provides a function for creating the sql statement that inserts
+// the given version into the schema when performing a dump query.
+var StmtDump = func(dialect database.Dialect, version int) string {
+	return fmt.Sprintf(`
+INSERT INTO schema (version, updated_at) VALUES (%d, %s)
+`, version, dialect.Now())
+}
+
 // SchemaTableExists return whether the schema table is present in the database.
 func SchemaTableExists(tx database.Tx) (bool, error) {
-	rows, err := tx.Query(StmtSchemaTableExists)
+	rows, err := tx.Query(tx.Dialect().StmtTableExists(), "schema")
 	if err != nil {
 		return false, errors.WithStack(err)
 	}
@@ -67,55 +100,139 @@ func SchemaTableExists(tx database.Tx) (bool, error) {
 
 // Create the schema table.
 func createSchemaTable(tx database.Tx) error {
-	_, err := tx.Exec(StmtCreateTable)
+	_, err := tx.Exec(StmtCreateTable(tx.Dialect()))
 	return errors.WithStack(err)
 }
 
 // Return all versions in the schema table, in increasing order.
 func selectSchemaVersions(tx database.Tx) ([]int, error) {
-	return query.SelectIntegers(tx, StmtSelectSchemaVersions)
+	return query.SelectIntegers(context.Background(), tx, StmtSelectSchemaVersions)
 }
 
 // Return a list of SQL statements that can be used to create all tables in the
-// database.
+// database, except the schema one, sorted by table name.
 func selectTablesSQL(tx database.Tx) ([]string, error) {
-	return query.SelectStrings(tx, StmtSelectTableSQL)
+	var tables []table
+	dest := func(i int) []interface{} {
+		tables = append(tables, table{})
+		return []interface{}{&tables[i].name, &tables[i].sql}
+	}
+	if err := query.SelectObjects(context.Background(), tx, dest, tx.Dialect().StmtTables()); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].name < tables[j].name
+	})
+
+	var statements []string
+	for _, t := range tables {
+		if t.name == "schema" {
+			continue
+		}
+		statements = append(statements, t.sql)
+	}
+	return statements, nil
+}
+
+type table struct {
+	name, sql string
 }
 
 // Insert a new version into the schema table.
-func insertSchemaVersion(tx database.Tx, new int) error {
-	_, err := tx.Exec(StmtInsertSchemaVersion, new)
+func insertSchemaVersion(tx database.Tx, new int, checksum, appVersion string) error {
+	_, err := tx.Exec(StmtInsertSchemaVersion(tx.Dialect()), new, checksum, nullable(appVersion))
 	return errors.WithStack(err)
 }
 
-// Read the given file (if it exists) and executes all queries it contains.
-func execFromFile(fileSystem fsys.FileSystem, tx database.Tx, path string, hook Hook) error {
+// Add the columns recording the checksums to a schema table created before
+// they were introduced.
+func ensureSchemaColumnsExist(tx database.Tx) error {
+	rows, err := tx.Query(StmtSelectSchemaColumns)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	columns, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, column := range []string{"checksum", "app_version"} {
+		if contains(columns, column) {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE schema ADD COLUMN %s TEXT", column)); err != nil {
+			return errors.Wrapf(err, "failed to add %s column", column)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove a version from the schema table.
+func deleteSchemaVersion(tx database.Tx, version int) error {
+	_, err := tx.Exec(StmtDeleteSchemaVersion, version)
+	return errors.WithStack(err)
+}
+
+// Read the given file (if it exists) and execute the statements it contains
+// one by one, returning whether it exists.
+//
+// If any of the statements fails, the file is renamed by appending the
+// failedSuffix to its name, so that it's kept around for fixing it while not
+// being run again. It's left alone during a dry run.
+func execFromFile(fileSystem fsys.FileSystem, tx database.Tx, path string, hook Hook) (bool, error) {
 	if !fileSystem.Exists(path) {
-		return nil
+		return false, nil
 	}
 
 	file, err := fileSystem.Open(path)
 	if err != nil {
-		return errors.Wrap(err, "failed to open file")
+		return false, errors.Wrap(err, "failed to open file")
 	}
 	defer file.Close()
 
 	bytes, err := ioutil.ReadAll(file)
 	if err != nil {
-		return errors.Wrap(err, "failed to read file")
+		return false, errors.Wrap(err, "failed to read file")
 	}
 
+	beginStep(tx)
 	if hook != nil {
 		err := hook(-1, tx)
 		if err != nil {
-			return errors.Wrap(err, "failed to execute hook")
+			return false, errors.Wrap(err, "failed to execute hook")
 		}
 	}
 
-	if _, err := tx.Exec(string(bytes)); err != nil {
-		return err
+	if err := query.ExecScript(context.Background(), tx, string(bytes)); err != nil {
+		if IsDryRun(tx) {
+			return false, errors.WithStack(err)
+		}
+		failed := path + failedSuffix
+		if renameErr := fileSystem.Rename(path, failed); renameErr != nil {
+			return false, errors.Wrapf(err, "failed to rename file (%v)", renameErr)
+		}
+		return false, errors.Wrapf(err, "file moved to %q", failed)
 	}
+	endStep(tx, Step{Version: -1, Path: path})
+	return true, nil
+}
 
-	err = fileSystem.Remove(path)
-	return errors.Wrap(err, "failed to remove file")
+// Remove the given files, whose queries have been committed.
+func removeFiles(fileSystem fsys.FileSystem, paths []string) error {
+	for _, path := range paths {
+		if err := fileSystem.Remove(path); err != nil {
+			return errors.Wrapf(err, "failed to remove file %q", path)
+		}
+	}
+	return nil
 }
@@ -16,10 +16,11 @@ func TestSchemaTableExists(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(mockRows, nil),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(gomock.Any()).SetArg(0, 1).Return(nil),
 		mockRows.EXPECT().Close().Return(nil),
@@ -39,9 +40,10 @@ func TestSchemaTableExistsWithNoTableFound(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(nil, errors.New("bad")),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(nil, errors.New("bad")),
 	)
 
 	ok, err := schema.SchemaTableExists(mockTx)
@@ -58,10 +60,11 @@ func TestSchemaTableExistsWithCountNotEqualToOne(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(mockRows, nil),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(gomock.Any()).SetArg(0, 0).Return(nil),
 		mockRows.EXPECT().Close().Return(nil),
@@ -81,10 +84,11 @@ func TestSchemaTableExistsWithScanError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(mockRows, nil),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(gomock.Any()).SetArg(0, 1).Return(errors.New("bad")),
 		mockRows.EXPECT().Close().Return(nil),
@@ -104,10 +108,11 @@ func TestSchemaTableExistsWithNoRows(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(mockRows, nil),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(false),
 		mockRows.EXPECT().Close().Return(nil),
 	)
@@ -132,8 +137,7 @@ func TestExecFromFile(t *testing.T) {
 	gomock.InOrder(
 		mockFileSystem.EXPECT().Exists("/path/to/a/db").Return(true),
 		mockFileSystem.EXPECT().Open("/path/to/a/db").Return(mockFile, nil),
-		mockTx.EXPECT().Exec("SELECT * FROM schema;").Return(nil, nil),
-		mockFileSystem.EXPECT().Remove("/path/to/a/db").Return(nil),
+		mockTx.EXPECT().ExecContext(gomock.Any(), "SELECT * FROM schema").Return(nil, nil),
 	)
 
 	var version int
@@ -142,10 +146,13 @@ func TestExecFromFile(t *testing.T) {
 		return nil
 	}
 
-	err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
+	exists, err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
+	if expected, actual := true, exists; expected != actual {
+		t.Errorf("expected: %t, actual: %t", expected, actual)
+	}
 	if expected, actual := -1, version; expected != actual {
 		t.Errorf("expected: %d, actual: %d", expected, actual)
 	}
@@ -168,7 +175,7 @@ func TestExecFromFileWithOpenFailure(t *testing.T) {
 		return nil
 	}
 
-	err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
+	_, err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -192,7 +199,7 @@ func TestExecFromFileWithReadFailure(t *testing.T) {
 		return nil
 	}
 
-	err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
+	_, err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -215,7 +222,7 @@ func TestExecFromFileWithHookFailure(t *testing.T) {
 		return errors.New("bad")
 	}
 
-	err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
+	_, err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -232,14 +239,15 @@ func TestExecFromFileWithExecFailure(t *testing.T) {
 	gomock.InOrder(
 		mockFileSystem.EXPECT().Exists("/path/to/a/db").Return(true),
 		mockFileSystem.EXPECT().Open("/path/to/a/db").Return(mockFile, nil),
-		mockTx.EXPECT().Exec("SELECT * FROM schema;").Return(nil, errors.New("bad")),
+		mockTx.EXPECT().ExecContext(gomock.Any(), "SELECT * FROM schema").Return(nil, errors.New("bad")),
+		mockFileSystem.EXPECT().Rename("/path/to/a/db", "/path/to/a/db.failed").Return(nil),
 	)
 
 	hook := func(v int, tx database.Tx) error {
 		return nil
 	}
 
-	err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
+	_, err := schema.ExecFromFile(mockFileSystem, mockTx, "/path/to/a/db", hook)
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
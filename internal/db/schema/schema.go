@@ -1,7 +1,7 @@
 package schema
 
 import (
-	"fmt"
+	"context"
 	"strings"
 
 	"github.com/bicycolet/bicycolet/internal/db/database"
@@ -13,12 +13,16 @@ import (
 // Schema captures the schema of a database in terms of a series of ordered
 // updates.
 type Schema struct {
-	fileSystem fsys.FileSystem
-	updates    []Update // Ordered series of updates making up the schema
-	hook       Hook     // Optional hook to execute whenever a update gets applied
-	fresh      string   // Optional SQL statement used to create schema from scratch
-	check      Check    // Optional callback invoked before doing any update
-	path       string   // Optional path to a file containing extra queries to run
+	fileSystem      fsys.FileSystem
+	updates         []Update       // Ordered series of updates making up the schema
+	downs           map[int]Update // Optional updates undoing the update of a version
+	hook            Hook           // Optional hook to execute whenever a update gets applied
+	fresh           string         // Optional SQL statement used to create schema from scratch
+	check           Check          // Optional callback invoked before doing any update
+	paths           []string       // Optional paths to files containing extra queries to run
+	identifiers     map[int]string // Optional identifiers the updates are checksummed with
+	appVersion      string         // Optional version of the application applying the updates
+	acceptChecksums bool           // Whether to replace mismatching checksums instead of failing
 }
 
 // Update applies a specific schema change to a database, and returns an error
@@ -55,6 +59,40 @@ func (s *Schema) Add(update Update) {
 	s.updates = append(s.updates, update)
 }
 
+// Down sets the update that undoes the update of the given version, that is
+// the one bringing the schema from the given version back to the previous
+// one. Updates without a down update can't be rolled back.
+func (s *Schema) Down(version int, down Update) {
+	if s.downs == nil {
+		s.downs = make(map[int]Update)
+	}
+	s.downs[version] = down
+}
+
+// Identify declares the identifier that the update of the given version is
+// checksummed with, instead of the SQL it executes. It's meant for updates
+// whose statements depend on the data they query, and it should be changed
+// whenever the update is.
+func (s *Schema) Identify(version int, identifier string) {
+	if s.identifiers == nil {
+		s.identifiers = make(map[int]string)
+	}
+	s.identifiers[version] = identifier
+}
+
+// AppVersion sets the version of the application applying the updates, which
+// is recorded along with them.
+func (s *Schema) AppVersion(version string) {
+	s.appVersion = version
+}
+
+// AcceptChecksums instructs Ensure to replace the checksums of the applied
+// updates that have changed with the ones of the current updates, instead of
+// failing with ErrChecksumMismatch.
+func (s *Schema) AcceptChecksums(accept bool) {
+	s.acceptChecksums = accept
+}
+
 // Len returns the number of total updates in the schema.
 func (s *Schema) Len() int {
 	return len(s.updates)
@@ -86,12 +124,16 @@ func (s *Schema) Fresh(statement string) {
 
 // File extra queries from a file. If the file is exists, all SQL queries in it
 // will be executed transactionally at the very start of Ensure(), before
-// anything else is done.
+// anything else is done. The file is removed once the transaction is
+// committed, or renamed with a ".failed" suffix if any of its queries fails.
+//
+// Files added by calling File more than once are run in the order they were
+// added.
 //
-//If a schema hook was set with Hook(), it will be run before running the
-//queries in the file and it will be passed a patch version equals to -1.
+// If a schema hook was set with Hook(), it will be run before running the
+// queries in the file and it will be passed a patch version equals to -1.
 func (s *Schema) File(path string) {
-	s.path = path
+	s.paths = append(s.paths, path)
 }
 
 // Trim the schema updates to the given version (included). Updates with higher
@@ -101,6 +143,16 @@ func (s *Schema) File(path string) {
 func (s *Schema) Trim(version int) []Update {
 	trimmed := s.updates[version:]
 	s.updates = s.updates[:version]
+	for v := range s.downs {
+		if v > version {
+			delete(s.downs, v)
+		}
+	}
+	for v := range s.identifiers {
+		if v > version {
+			delete(s.identifiers, v)
+		}
+	}
 	s.fresh = ""
 	return trimmed
 }
@@ -115,56 +167,117 @@ func (s *Schema) Trim(version int) []Update {
 // updates are tracked in the a 'schema' table, which gets automatically
 // created).
 //
+// The checksum of every update is recorded along with it, and Ensure fails
+// with ErrChecksumMismatch if an update that was already applied has changed
+// since, unless AcceptChecksums was set.
+//
 // If no error occurs, the integer returned by this method is the
 // initial version that the schema has been upgraded from.
 func (s *Schema) Ensure(src database.DB) (int, error) {
 	var (
 		current int
+		files   []string
 		aborted bool
 	)
-	err := query.Transaction(src, func(tx database.Tx) error {
-		if err := execFromFile(s.fileSystem, tx, s.path, s.hook); err != nil {
-			return errors.Wrapf(err, "failed to execute queries from %q", s.path)
-		}
-
-		if err := ensureSchemaTableExists(tx); err != nil {
-			return errors.WithStack(err)
-		}
+	err := query.Transaction(context.Background(), src, func(tx database.Tx) error {
 		var err error
-		current, err = queryCurrentVersion(tx)
+		current, files, aborted, err = s.ensure(tx)
+		return errors.WithStack(err)
+	})
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+	if err := removeFiles(s.fileSystem, files); err != nil {
+		return -1, errors.WithStack(err)
+	}
+	if aborted {
+		return current, ErrGracefulAbort
+	}
+	return current, nil
+}
+
+// Apply the files of extra queries and the missing updates within the given
+// transaction, which is the one of a dry run when called by Plan. Return the
+// version the schema was at, the files whose queries were executed and
+// whether the check aborted gracefully, in which case what was done so far is
+// meant to be committed.
+func (s *Schema) ensure(tx database.Tx) (current int, files []string, aborted bool, err error) {
+	for _, path := range s.paths {
+		exists, err := execFromFile(s.fileSystem, tx, path, s.hook)
 		if err != nil {
-			return errors.WithStack(err)
+			return -1, nil, false, errors.Wrapf(err, "failed to execute queries from %q", path)
+		}
+		if exists {
+			files = append(files, path)
 		}
+	}
 
-		if s.check != nil {
-			if err := s.check(current, tx); err == ErrGracefulAbort {
-				// Abort the update gracefully, committing what we've done so
-				// far.
-				aborted = true
-				return nil
-			} else if err != nil {
-				return errors.WithStack(err)
-			}
+	if err := ensureSchemaTableExists(tx); err != nil {
+		return -1, nil, false, errors.WithStack(err)
+	}
+	current, err = queryCurrentVersion(tx)
+	if err != nil {
+		return -1, nil, false, errors.WithStack(err)
+	}
+
+	if s.check != nil {
+		if err := s.check(current, tx); err == ErrGracefulAbort {
+			// Abort the update gracefully, committing what we've done so
+			// far.
+			return current, files, true, nil
+		} else if err != nil {
+			return current, nil, false, errors.WithStack(err)
 		}
+	}
 
-		// When creating the schema from scratch, use the fresh dump if
-		// available. Otherwise just apply all relevant updates.
-		if current == 0 && s.fresh != "" {
-			if _, err := tx.Exec(s.fresh); err != nil {
-				return errors.Wrap(err, "cannot apply fresh schema")
-			}
-		} else {
-			err = ensureUpdatesAreApplied(tx, current, s.updates, s.hook)
+	sums := checksums(tx.Dialect(), s.updates, s.identifiers)
+	if err := verifyChecksums(tx, sums, s.appVersion, s.acceptChecksums); err != nil {
+		return current, nil, false, errors.WithStack(err)
+	}
+
+	// When creating the schema from scratch, use the fresh dump if
+	// available. Otherwise just apply all relevant updates.
+	if current == 0 && s.fresh != "" {
+		beginStep(tx)
+		if _, err := tx.Exec(s.fresh); err != nil {
+			return current, nil, false, errors.Wrap(err, "cannot apply fresh schema")
+		}
+		endStep(tx, Step{Version: len(s.updates), Fresh: true})
+		err = recordFreshChecksums(tx, sums, s.appVersion)
+		return current, files, false, errors.WithStack(err)
+	}
+	err = ensureUpdatesAreApplied(tx, current, s.updates, sums, s.appVersion, s.hook)
+	return current, files, false, errors.WithStack(err)
+}
+
+// Rollback undoes the updates applied to the given database, from the current
+// version down to the given version (excluded), by running their down updates
+// in reverse order and removing their versions from the schema table.
+//
+// All down updates are run transactionally. In case any error occurs, or if
+// any of the updates to undo has no down update, the transaction will be
+// rolled back and the database will remain unchanged.
+//
+// If a schema hook was set with Hook(), it will be run before every down
+// update and it will be passed the version that the update rolls back from.
+//
+// If no error occurs, the integer returned by this method is the initial
+// version that the schema has been rolled back from.
+func (s *Schema) Rollback(src database.DB, version int) (int, error) {
+	var current int
+	err := query.Transaction(context.Background(), src, func(tx database.Tx) error {
+		var err error
+		current, err = queryCurrentVersion(tx)
+		if err != nil {
 			return errors.WithStack(err)
 		}
-		return nil
+		sums := checksums(tx.Dialect(), s.updates, s.identifiers)
+		err = ensureUpdatesAreRolledBack(tx, current, version, s.updates, s.downs, sums, s.appVersion, s.hook)
+		return errors.WithStack(err)
 	})
 	if err != nil {
 		return -1, errors.WithStack(err)
 	}
-	if aborted {
-		return current, ErrGracefulAbort
-	}
 	return current, nil
 }
 
@@ -176,7 +289,8 @@ func (s *Schema) Ensure(src database.DB) (int, error) {
 // error will be returned.
 func (s *Schema) Dump(src database.DB) (string, error) {
 	var statements []string
-	if err := query.Transaction(src, func(tx database.Tx) error {
+	dialect := src.Dialect()
+	if err := query.Transaction(context.Background(), src, func(tx database.Tx) error {
 		err := checkAllUpdatesAreApplied(tx, s.updates)
 		if err != nil {
 			return errors.WithStack(err)
@@ -193,7 +307,7 @@ func (s *Schema) Dump(src database.DB) (string, error) {
 	// Add a statement for inserting the current schema version row.
 	statements = append(
 		statements,
-		fmt.Sprintf(StmtDump, len(s.updates)))
+		StmtDump(dialect, len(s.updates)))
 	return strings.Join(statements, ";\n"), nil
 }
 
@@ -207,8 +321,10 @@ func ensureSchemaTableExists(tx database.Tx) error {
 		if err := createSchemaTable(tx); err != nil {
 			return errors.Wrap(err, "failed to create schema table")
 		}
+		return nil
 	}
-	return nil
+	err = ensureSchemaColumnsExist(tx)
+	return errors.Wrap(err, "failed to upgrade schema table")
 }
 
 // Return the highest update version currently applied. Zero means that no
@@ -243,7 +359,7 @@ func checkSchemaVersionsHaveNoHoles(versions []int) error {
 }
 
 // Apply any pending update that was not yet applied.
-func ensureUpdatesAreApplied(tx database.Tx, current int, updates []Update, hook Hook) error {
+func ensureUpdatesAreApplied(tx database.Tx, current int, updates []Update, sums []string, appVersion string, hook Hook) error {
 	if current > len(updates) {
 		return errors.Errorf(
 			"schema version '%d' is more recent than expected '%d'",
@@ -257,6 +373,7 @@ func ensureUpdatesAreApplied(tx database.Tx, current int, updates []Update, hook
 
 	// Apply missing updates.
 	for _, update := range updates[current:] {
+		beginStep(tx)
 		if hook != nil {
 			if err := hook(current, tx); err != nil {
 				return errors.Wrapf(err, "failed to execute hook (version %d)", current)
@@ -267,7 +384,8 @@ func ensureUpdatesAreApplied(tx database.Tx, current int, updates []Update, hook
 			return errors.Wrapf(err, "failed to apply update %d", current)
 		}
 		current++
-		if err := insertSchemaVersion(tx, current); err != nil {
+		endStep(tx, Step{Version: current})
+		if err := insertSchemaVersion(tx, current, sums[current-1], appVersion); err != nil {
 			return errors.Errorf("failed to insert version %d", current)
 		}
 	}
@@ -275,6 +393,58 @@ func ensureUpdatesAreApplied(tx database.Tx, current int, updates []Update, hook
 	return nil
 }
 
+// Undo the applied updates down to the given version.
+func ensureUpdatesAreRolledBack(tx database.Tx, current, version int, updates []Update, downs map[int]Update, sums []string, appVersion string, hook Hook) error {
+	if current > len(updates) {
+		return errors.Errorf(
+			"schema version '%d' is more recent than expected '%d'",
+			current, len(updates))
+	}
+	if version < 0 || version > current {
+		return errors.Errorf(
+			"cannot roll back schema version '%d' to version '%d'",
+			current, version)
+	}
+
+	// Make sure every update can be undone before undoing any of them.
+	for v := current; v > version; v-- {
+		if downs[v] == nil {
+			return errors.Errorf("update %d cannot be rolled back", v)
+		}
+	}
+
+	for ; current > version; current-- {
+		if hook != nil {
+			if err := hook(current, tx); err != nil {
+				return errors.Wrapf(err, "failed to execute hook (version %d)", current)
+			}
+		}
+
+		if err := downs[current](tx); err != nil {
+			return errors.Wrapf(err, "failed to roll back update %d", current)
+		}
+		if err := deleteSchemaVersion(tx, current); err != nil {
+			return errors.Wrapf(err, "failed to delete version %d", current)
+		}
+	}
+
+	// A schema created from a fresh dump only records the version of the
+	// dump, in which case the version rolled back to needs to be recorded.
+	if version > 0 {
+		versions, err := selectSchemaVersions(tx)
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch update versions")
+		}
+		if len(versions) == 0 {
+			if err := insertSchemaVersion(tx, version, sums[version-1], appVersion); err != nil {
+				return errors.Wrapf(err, "failed to insert version %d", version)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Check that all the given updates are applied.
 func checkAllUpdatesAreApplied(tx database.Tx, updates []Update) error {
 	versions, err := selectSchemaVersions(tx)
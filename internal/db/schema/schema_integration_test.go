@@ -3,6 +3,7 @@
 package schema_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -118,7 +119,7 @@ func TestSchemaEnsure_ZeroUpdates(t *testing.T) {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	versions, err := query.SelectIntegers(tx, "SELECT version FROM SCHEMA")
+	versions, err := query.SelectIntegers(context.Background(), tx, "SELECT version FROM SCHEMA")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -149,7 +150,7 @@ func TestSchemaEnsure_ApplyAllUpdates(t *testing.T) {
 	}
 
 	// THe update version is recorded.
-	versions, err := query.SelectIntegers(tx, "SELECT version FROM SCHEMA")
+	versions, err := query.SelectIntegers(context.Background(), tx, "SELECT version FROM SCHEMA")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -158,7 +159,7 @@ func TestSchemaEnsure_ApplyAllUpdates(t *testing.T) {
 	}
 
 	// The two updates have been applied in order.
-	ids, err := query.SelectIntegers(tx, "SELECT id FROM test")
+	ids, err := query.SelectIntegers(context.Background(), tx, "SELECT id FROM test")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -167,6 +168,80 @@ func TestSchemaEnsure_ApplyAllUpdates(t *testing.T) {
 	}
 }
 
+// Rolling back runs the down updates in reverse order and removes their
+// versions from the schema table.
+func TestSchemaRollback_UndoUpdates(t *testing.T) {
+	schema, db := newSchemaAndDB(t)
+	schema.Add(updateCreateTable)
+	schema.Add(updateInsertValue)
+	schema.Down(1, downDropTable)
+	schema.Down(2, downDeleteValue)
+
+	if _, err := schema.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	current, err := schema.Rollback(db, 1)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 2, current; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer tx.Rollback()
+
+	versions, err := query.SelectIntegers(context.Background(), tx, "SELECT version FROM schema")
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []int{1}, versions; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	ids, err := query.SelectIntegers(context.Background(), tx, "SELECT id FROM test")
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 0, len(ids); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// If any of the updates to undo has no down update, nothing is rolled back.
+func TestSchemaRollback_IrreversibleUpdate(t *testing.T) {
+	schema, db := newSchemaAndDB(t)
+	schema.Add(updateCreateTable)
+	schema.Add(updateInsertValue)
+	schema.Down(2, downDeleteValue)
+
+	if _, err := schema.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	_, err := schema.Rollback(db, 0)
+	if expected, actual := "update 1 cannot be rolled back", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer tx.Rollback()
+
+	versions, err := query.SelectIntegers(context.Background(), tx, "SELECT version FROM schema")
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []int{1, 2}, versions; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
 // If the schema schema has been created using a dump, the schema table will
 // contain just one row with the update level associated with the dump. It's
 // possible to apply further updates from there, and only these new ones will
@@ -204,7 +279,7 @@ func TestSchemaEnsure_ApplyAfterInitialDumpCreation(t *testing.T) {
 	}
 
 	// Only updates starting from the initial dump are recorded.
-	versions, err := query.SelectIntegers(tx, "SELECT version FROM schema")
+	versions, err := query.SelectIntegers(context.Background(), tx, "SELECT version FROM schema")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -238,7 +313,7 @@ func TestSchemaEnsure_OnlyApplyMissing(t *testing.T) {
 	}
 
 	// All update versions are recorded.
-	versions, err := query.SelectIntegers(tx, "SELECT version FROM SCHEMA")
+	versions, err := query.SelectIntegers(context.Background(), tx, "SELECT version FROM SCHEMA")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -247,7 +322,7 @@ func TestSchemaEnsure_OnlyApplyMissing(t *testing.T) {
 	}
 
 	// The two updates have been applied in order.
-	ids, err := query.SelectIntegers(tx, "SELECT id FROM test")
+	ids, err := query.SelectIntegers(context.Background(), tx, "SELECT id FROM test")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -273,7 +348,7 @@ func TestSchemaEnsure_FailingUpdate(t *testing.T) {
 	}
 
 	// Not update was applied.
-	tables, err := query.SelectStrings(tx, "SELECT name FROM sqlite_master WHERE type = 'table'")
+	tables, err := query.SelectStrings(context.Background(), tx, "SELECT name FROM sqlite_master WHERE type = 'table'")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -302,7 +377,7 @@ func TestSchemaEnsure_FailingHook(t *testing.T) {
 	}
 
 	// Not update was applied.
-	tables, err := query.SelectStrings(tx, "SELECT name FROM sqlite_master WHERE type = 'table'")
+	tables, err := query.SelectStrings(context.Background(), tx, "SELECT name FROM sqlite_master WHERE type = 'table'")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -341,7 +416,7 @@ func TestSchemaEnsure_CheckGracefulAbort(t *testing.T) {
 
 	// The table created by the check function still got committed.
 	// to insert the row was not.
-	ids, err := query.SelectIntegers(tx, "SELECT n FROM test")
+	ids, err := query.SelectIntegers(context.Background(), tx, "SELECT n FROM test")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -380,7 +455,7 @@ func TestSchemaDump(t *testing.T) {
 	}
 
 	// All update versions are in place.
-	versions, err := query.SelectIntegers(tx, "SELECT version FROM schema")
+	versions, err := query.SelectIntegers(context.Background(), tx, "SELECT version FROM schema")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -449,7 +524,7 @@ func TestSchema_File(t *testing.T) {
 		t.Errorf("expected err to be nil: %v", err)
 	}
 
-	ids, err := query.SelectIntegers(tx, "SELECT id FROM test ORDER BY id")
+	ids, err := query.SelectIntegers(context.Background(), tx, "SELECT id FROM test ORDER BY id")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -501,7 +576,7 @@ func TestSchema_File_Hook(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
-	ids, err := query.SelectIntegers(tx, "SELECT id FROM test ORDER BY id")
+	ids, err := query.SelectIntegers(context.Background(), tx, "SELECT id FROM test ORDER BY id")
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
@@ -517,11 +592,11 @@ func newFileSystem(t *testing.T) fsys.FileSystem {
 
 // Return a new in-memory SQLite database.
 func newDB(t *testing.T) database.DB {
-	db, err := sql.Open(database.DriverName(), connectionInfo())
+	db, err := sql.Open(database.Postgres, connectionInfo())
 	if err != nil {
 		t.Errorf("expected err to be nil: %v", err)
 	}
-	return database.NewShimDB(db)
+	return database.NewShimDB(db, postgres)
 }
 
 // Return both an empty schema and a test database.
@@ -553,6 +628,18 @@ func updateAddColumn(tx database.Tx) error {
 	return err
 }
 
+// A down update that drops the test table.
+func downDropTable(tx database.Tx) error {
+	_, err := tx.Exec("DROP TABLE test")
+	return err
+}
+
+// A down update that deletes the value inserted into the test table.
+func downDeleteValue(tx database.Tx) error {
+	_, err := tx.Exec("DELETE FROM test WHERE id = 1")
+	return err
+}
+
 // An update that unconditionally fails with an error.
 func updateBoom(tx database.Tx) error {
 	return errors.Errorf("boom")
@@ -567,6 +654,15 @@ func contains(a []string, b string) bool {
 	return false
 }
 
+// postgres is the dialect of the database the tests run against.
+var postgres = func() database.Dialect {
+	dialect, err := database.NewDialect(database.Postgres)
+	if err != nil {
+		panic(err)
+	}
+	return dialect
+}()
+
 func connectionInfo() string {
 	info := database.ConnectionInfo{
 		Host:     "localhost",
@@ -1,12 +1,16 @@
 package schema_test
 
 import (
+	"context"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
 	"github.com/bicycolet/bicycolet/internal/db/schema"
 	"github.com/bicycolet/bicycolet/internal/db/schema/mocks"
+	"github.com/bicycolet/bicycolet/internal/fsys"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 )
@@ -112,14 +116,17 @@ func TestSchemaEnsure(t *testing.T) {
 
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockFileSystem.EXPECT().Exists("/path/to/a/file").Return(false),
 		expectSchemaTableExists(mockTx, mockRows, 1),
+		expectSchemaColumns(mockTx, mockRows),
 		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
+		expectChecksums(mockTx, mockRows),
 		mockTx.EXPECT().Commit().Return(nil),
 	)
 
@@ -146,15 +153,17 @@ func TestSchemaEnsureCreatesNewSchemaTable(t *testing.T) {
 
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockFileSystem.EXPECT().Exists("/path/to/a/file").Return(false),
 		expectSchemaTableExists(mockTx, mockRows, 0),
-		mockTx.EXPECT().Exec(schema.StmtCreateTable).Return(nil, nil),
+		mockTx.EXPECT().Exec(schema.StmtCreateTable(sqlite)).Return(nil, nil),
 		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
+		expectChecksums(mockTx, mockRows),
 		mockTx.EXPECT().Commit().Return(nil),
 	)
 
@@ -181,14 +190,17 @@ func TestSchemaEnsureCallsCheck(t *testing.T) {
 
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockFileSystem.EXPECT().Exists("/path/to/a/file").Return(false),
 		expectSchemaTableExists(mockTx, mockRows, 1),
+		expectSchemaColumns(mockTx, mockRows),
 		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
+		expectChecksums(mockTx, mockRows),
 		mockTx.EXPECT().Commit().Return(nil),
 	)
 
@@ -223,13 +235,15 @@ func TestSchemaEnsureCallsCheckWithFailure(t *testing.T) {
 
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockFileSystem.EXPECT().Exists("/path/to/a/file").Return(false),
 		expectSchemaTableExists(mockTx, mockRows, 1),
+		expectSchemaColumns(mockTx, mockRows),
 		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
 		mockTx.EXPECT().Rollback().Return(nil),
 	)
@@ -260,13 +274,15 @@ func TestSchemaEnsureCallsCheckWithAbortedFailure(t *testing.T) {
 
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockFileSystem.EXPECT().Exists("/path/to/a/file").Return(false),
 		expectSchemaTableExists(mockTx, mockRows, 1),
+		expectSchemaColumns(mockTx, mockRows),
 		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
 		mockTx.EXPECT().Commit().Return(nil),
 	)
@@ -298,16 +314,20 @@ func TestSchemaEnsureCallsFresh(t *testing.T) {
 
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockFileSystem.EXPECT().Exists("/path/to/a/file").Return(false),
 		expectSchemaTableExists(mockTx, mockRows, 0),
-		mockTx.EXPECT().Exec(schema.StmtCreateTable).Return(nil, nil),
+		mockTx.EXPECT().Exec(schema.StmtCreateTable(sqlite)).Return(nil, nil),
 		expectCurrentVersion(ctrl, mockTx, mockRows, 0),
+		expectChecksums(mockTx, mockRows),
 		mockTx.EXPECT().Exec("SELECT * FROM nodes").Return(nil, nil),
+		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
+		mockTx.EXPECT().Exec(schema.StmtUpdateSchemaChecksum, gomock.Any(), nil, 1).Return(nil, nil),
 		mockTx.EXPECT().Commit().Return(nil),
 	)
 
@@ -335,17 +355,19 @@ func TestSchemaEnsureCallsHook(t *testing.T) {
 
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockFileSystem.EXPECT().Exists("/path/to/a/file").Return(false),
 		expectSchemaTableExists(mockTx, mockRows, 0),
-		mockTx.EXPECT().Exec(schema.StmtCreateTable).Return(nil, nil),
+		mockTx.EXPECT().Exec(schema.StmtCreateTable(sqlite)).Return(nil, nil),
 		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
-		mockTx.EXPECT().Exec(schema.StmtInsertSchemaVersion, 2).Return(nil, nil),
+		expectChecksums(mockTx, mockRows),
+		mockTx.EXPECT().Exec(schema.StmtInsertSchemaVersion(sqlite), 2, gomock.Any(), nil).Return(nil, nil),
 		mockTx.EXPECT().Commit().Return(nil),
 	)
 
@@ -383,15 +405,17 @@ func TestSchemaEnsureCallsHookFailure(t *testing.T) {
 
 	mockDB := mocks.NewMockDB(ctrl)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 	mockFileSystem := mocks.NewMockFileSystem(ctrl)
 
 	gomock.InOrder(
-		mockDB.EXPECT().Begin().Return(mockTx, nil),
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
 		mockFileSystem.EXPECT().Exists("/path/to/a/file").Return(false),
 		expectSchemaTableExists(mockTx, mockRows, 0),
-		mockTx.EXPECT().Exec(schema.StmtCreateTable).Return(nil, nil),
+		mockTx.EXPECT().Exec(schema.StmtCreateTable(sqlite)).Return(nil, nil),
 		expectCurrentVersion(ctrl, mockTx, mockRows, 0),
+		expectChecksums(mockTx, mockRows),
 		mockTx.EXPECT().Rollback().Return(nil),
 	)
 
@@ -424,7 +448,7 @@ func TestQueryCurrentVersion(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSelectSchemaVersions).Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), schema.StmtSelectSchemaVersions).Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -460,7 +484,7 @@ func TestQueryCurrentVersionWithVersionHole(t *testing.T) {
 	mockColumnType := mocks.NewMockColumnType(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSelectSchemaVersions).Return(mockRows, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), schema.StmtSelectSchemaVersions).Return(mockRows, nil),
 		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
 			mockColumnType,
 		}, nil),
@@ -490,7 +514,7 @@ func TestQueryCurrentVersionWithSelectVersionError(t *testing.T) {
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSelectSchemaVersions).Return(mockRows, errors.New("bad")),
+		mockTx.EXPECT().QueryContext(gomock.Any(), schema.StmtSelectSchemaVersions).Return(mockRows, errors.New("bad")),
 	)
 
 	_, err := schema.QueryCurrentVersion(mockTx)
@@ -506,9 +530,10 @@ func TestEnsureUpdatesAreApplied(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec(schema.StmtInsertSchemaVersion, 1).Return(nil, nil),
+		mockTx.EXPECT().Exec(schema.StmtInsertSchemaVersion(sqlite), 1, "1a2b", "0.1").Return(nil, nil),
 	)
 
 	var version int
@@ -523,7 +548,7 @@ func TestEnsureUpdatesAreApplied(t *testing.T) {
 			called = true
 			return nil
 		},
-	}, hook)
+	}, []string{"1a2b"}, "0.1", hook)
 	if err != nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -552,7 +577,7 @@ func TestEnsureUpdatesAreAppliedWithCurrent(t *testing.T) {
 		func(database.Tx) error {
 			return nil
 		},
-	}, hook)
+	}, []string{"1a2b"}, "0.1", hook)
 	if err == nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -571,7 +596,7 @@ func TestEnsureUpdatesAreAppliedWithNoUpdates(t *testing.T) {
 		return nil
 	}
 
-	err := schema.EnsureUpdatesAreApplied(mockTx, 0, []schema.Update{}, hook)
+	err := schema.EnsureUpdatesAreApplied(mockTx, 0, []schema.Update{}, nil, "0.1", hook)
 	if err != nil {
 		t.Errorf("expected err to be nil")
 	}
@@ -593,7 +618,7 @@ func TestEnsureUpdatesAreAppliedWithUpdateFailure(t *testing.T) {
 		func(database.Tx) error {
 			return errors.New("bad")
 		},
-	}, hook)
+	}, []string{"1a2b"}, "0.1", hook)
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -606,9 +631,10 @@ func TestEnsureUpdatesAreAppliedWithInsertFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 
 	gomock.InOrder(
-		mockTx.EXPECT().Exec(schema.StmtInsertSchemaVersion, 1).Return(nil, errors.New("bad")),
+		mockTx.EXPECT().Exec(schema.StmtInsertSchemaVersion(sqlite), 1, "1a2b", "0.1").Return(nil, errors.New("bad")),
 	)
 
 	hook := func(v int, tx database.Tx) error {
@@ -619,7 +645,210 @@ func TestEnsureUpdatesAreAppliedWithInsertFailure(t *testing.T) {
 		func(database.Tx) error {
 			return nil
 		},
-	}, hook)
+	}, []string{"1a2b"}, "0.1", hook)
+	if err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestSchemaRollback(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mocks.NewMockDB(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+	mockFileSystem := mocks.NewMockFileSystem(ctrl)
+
+	gomock.InOrder(
+		mockDB.EXPECT().BeginTx(gomock.Any(), nil).Return(mockTx, nil),
+		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
+		mockTx.EXPECT().Exec(schema.StmtDeleteSchemaVersion, 1).Return(nil, nil),
+		mockTx.EXPECT().Commit().Return(nil),
+	)
+
+	var called bool
+	s := schema.New(mockFileSystem, []schema.Update{
+		func(database.Tx) error {
+			return nil
+		},
+	})
+	s.Down(1, func(database.Tx) error {
+		called = true
+		return nil
+	})
+	current, err := s.Rollback(mockDB, 0)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, current; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := true, called; expected != actual {
+		t.Errorf("expected: %t, actual: %t", expected, actual)
+	}
+}
+
+func TestEnsureUpdatesAreRolledBack(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockRows := mocks.NewMockRows(ctrl)
+
+	var record []int
+	define := func(v int) schema.Update {
+		return func(database.Tx) error {
+			record = append(record, v)
+			return nil
+		}
+	}
+
+	gomock.InOrder(
+		mockTx.EXPECT().Exec(schema.StmtDeleteSchemaVersion, 3).Return(nil, nil),
+		mockTx.EXPECT().Exec(schema.StmtDeleteSchemaVersion, 2).Return(nil, nil),
+		expectCurrentVersion(ctrl, mockTx, mockRows, 1),
+	)
+
+	var versions []int
+	hook := func(v int, tx database.Tx) error {
+		versions = append(versions, v)
+		return nil
+	}
+
+	err := schema.EnsureUpdatesAreRolledBack(mockTx, 3, 1, []schema.Update{
+		define(1), define(2), define(3),
+	}, map[int]schema.Update{
+		2: define(-2),
+		3: define(-3),
+	}, []string{"1a2b", "3c4d", "5e6f"}, "0.1", hook)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := []int{-3, -2}, record; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []int{3, 2}, versions; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// A schema created from a fresh dump only has the version of the dump
+// recorded, so the version rolled back to gets inserted.
+func TestEnsureUpdatesAreRolledBackWithFreshSchema(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
+	mockRows := mocks.NewMockRows(ctrl)
+	mockColumnType := mocks.NewMockColumnType(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().Exec(schema.StmtDeleteSchemaVersion, 2).Return(nil, nil),
+		mockTx.EXPECT().QueryContext(gomock.Any(), schema.StmtSelectSchemaVersions).Return(mockRows, nil),
+		mockRows.EXPECT().ColumnTypes().Return([]database.ColumnType{
+			mockColumnType,
+		}, nil),
+		mockColumnType.EXPECT().DatabaseTypeName().Return("INTEGER"),
+		mockRows.EXPECT().Next().Return(false),
+		mockRows.EXPECT().Err().Return(nil),
+		mockRows.EXPECT().Close().Return(nil),
+		mockTx.EXPECT().Exec(schema.StmtInsertSchemaVersion(sqlite), 1, "1a2b", "0.1").Return(nil, nil),
+	)
+
+	noop := func(database.Tx) error {
+		return nil
+	}
+	err := schema.EnsureUpdatesAreRolledBack(mockTx, 2, 1, []schema.Update{
+		noop, noop,
+	}, map[int]schema.Update{
+		2: noop,
+	}, []string{"1a2b", "3c4d", "5e6f"}, "0.1", nil)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
+
+func TestEnsureUpdatesAreRolledBackWithInvalidVersion(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+
+	for _, version := range []int{-1, 2} {
+		err := schema.EnsureUpdatesAreRolledBack(mockTx, 1, version, []schema.Update{
+			func(database.Tx) error {
+				return nil
+			},
+		}, map[int]schema.Update{
+			1: func(database.Tx) error {
+				t.Fail()
+				return nil
+			},
+		}, []string{"1a2b", "3c4d", "5e6f"}, "0.1", nil)
+		if err == nil {
+			t.Errorf("expected err not to be nil")
+		}
+	}
+}
+
+func TestEnsureUpdatesAreRolledBackWithIrreversibleUpdate(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+
+	err := schema.EnsureUpdatesAreRolledBack(mockTx, 2, 0, []schema.Update{
+		func(database.Tx) error {
+			return nil
+		},
+		func(database.Tx) error {
+			return nil
+		},
+	}, map[int]schema.Update{
+		2: func(database.Tx) error {
+			t.Fail()
+			return nil
+		},
+	}, []string{"1a2b", "3c4d", "5e6f"}, "0.1", nil)
+	if expected, actual := "update 1 cannot be rolled back", errors.Cause(err).Error(); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestEnsureUpdatesAreRolledBackWithDeleteFailure(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+
+	gomock.InOrder(
+		mockTx.EXPECT().Exec(schema.StmtDeleteSchemaVersion, 1).Return(nil, errors.New("bad")),
+	)
+
+	err := schema.EnsureUpdatesAreRolledBack(mockTx, 1, 0, []schema.Update{
+		func(database.Tx) error {
+			return nil
+		},
+	}, map[int]schema.Update{
+		1: func(database.Tx) error {
+			return nil
+		},
+	}, []string{"1a2b", "3c4d", "5e6f"}, "0.1", nil)
 	if err == nil {
 		t.Errorf("expected err not to be nil")
 	}
@@ -632,13 +861,15 @@ func TestEnsureSchemaTableExists(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(mockRows, nil),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(gomock.Any()).SetArg(0, 1).Return(nil),
 		mockRows.EXPECT().Close().Return(nil),
+		expectSchemaColumns(mockTx, mockRows),
 	)
 
 	err := schema.EnsureSchemaTableExists(mockTx)
@@ -654,9 +885,10 @@ func TestEnsureSchemaTableExistsWithSelectFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(nil, errors.New("bad")),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(nil, errors.New("bad")),
 	)
 
 	err := schema.EnsureSchemaTableExists(mockTx)
@@ -672,14 +904,15 @@ func TestEnsureSchemaTableExistsWithCreate(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(mockRows, nil),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(gomock.Any()).SetArg(0, 0).Return(nil),
 		mockRows.EXPECT().Close().Return(nil),
-		mockTx.EXPECT().Exec(schema.StmtCreateTable).Return(nil, nil),
+		mockTx.EXPECT().Exec(schema.StmtCreateTable(sqlite)).Return(nil, nil),
 	)
 
 	err := schema.EnsureSchemaTableExists(mockTx)
@@ -695,14 +928,15 @@ func TestEnsureSchemaTableExistsWithCreateFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Dialect().Return(sqlite).AnyTimes()
 	mockRows := mocks.NewMockRows(ctrl)
 
 	gomock.InOrder(
-		mockTx.EXPECT().Query(schema.StmtSchemaTableExists).Return(mockRows, nil),
+		mockTx.EXPECT().Query(sqlite.StmtTableExists(), "schema").Return(mockRows, nil),
 		mockRows.EXPECT().Next().Return(true),
 		mockRows.EXPECT().Scan(gomock.Any()).SetArg(0, 0).Return(nil),
 		mockRows.EXPECT().Close().Return(nil),
-		mockTx.EXPECT().Exec(schema.StmtCreateTable).Return(nil, errors.New("bad")),
+		mockTx.EXPECT().Exec(schema.StmtCreateTable(sqlite)).Return(nil, errors.New("bad")),
 	)
 
 	err := schema.EnsureSchemaTableExists(mockTx)
@@ -710,3 +944,66 @@ func TestEnsureSchemaTableExistsWithCreateFailure(t *testing.T) {
 		t.Errorf("expected err not to be nil")
 	}
 }
+
+// The statements of the files are run one by one, and a failing file is kept
+// under a new name while the changes of all files are rolled back.
+func TestSchemaEnsureWithFiles(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	fileSystem := fsys.NewVirtualFileSystem()
+	writeFile(t, fileSystem, "/global.sql", "CREATE TABLE test (id INTEGER, name TEXT);\nINSERT INTO test VALUES (1, 'a;b');\n")
+	writeFile(t, fileSystem, "/local.sql", "-- Fix up the data\nINSERT INTO test VALUES (2, 'c');\n\nINSERT INTO missing VALUES (3);\n")
+
+	s := schema.Empty(fileSystem)
+	s.File("/global.sql")
+	s.File("/local.sql")
+	_, err := s.Ensure(db)
+	if err == nil {
+		t.Fatalf("expected err not to be nil")
+	}
+	if expected, actual := "failed to execute statement at line 4", err.Error(); !strings.Contains(actual, expected) {
+		t.Errorf("expected %q to contain %q", actual, expected)
+	}
+	for path, exists := range map[string]bool{
+		"/global.sql":       true,
+		"/local.sql":        false,
+		"/local.sql.failed": true,
+	} {
+		if expected, actual := exists, fileSystem.Exists(path); expected != actual {
+			t.Errorf("expected %q to exist: %t", path, expected)
+		}
+	}
+
+	// Once the failed file is out of the way the other one is run, and
+	// removed.
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if fileSystem.Exists("/global.sql") {
+		t.Errorf("expected %q to be removed", "/global.sql")
+	}
+	var count int
+	if err := query.Transaction(context.Background(), db, func(tx database.Tx) error {
+		count, err = query.Count(context.Background(), tx, "test", query.Eq("name", "a;b"))
+		return err
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, count; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func writeFile(t *testing.T, fileSystem fsys.FileSystem, path, content string) {
+	file, err := fileSystem.Create(path)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write([]byte(content)); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/pkg/errors"
+)
+
+// scratchSchema is the name of the temporary schema the expected objects are
+// created in, when verifying a database that supports schemas.
+const scratchSchema = "schema_verify"
+
+// Drift is a difference between the objects of a database and the ones
+// expected from the updates applied to it.
+type Drift struct {
+	// Kind of the object, either "table" or "index".
+	Kind string
+
+	// Name of the object.
+	Name string
+
+	// Change the object went through, either "missing", "extra" or "changed".
+	Change string
+
+	// Details of a changed object, listing the definitions it's missing,
+	// prefixed by "-", and the extra ones, prefixed by "+".
+	Details []string
+}
+
+func (d Drift) String() string {
+	s := fmt.Sprintf("%s %s %s", d.Change, d.Kind, d.Name)
+	if len(d.Details) > 0 {
+		s += ": " + strings.Join(d.Details, ", ")
+	}
+	return s
+}
+
+// Verify compares the tables and indexes of the given database, including
+// their columns and constraints, with the ones expected from applying the
+// updates up to its current version. It returns every object that's missing,
+// extra or changed, sorted by kind and name.
+//
+// The expected objects are created in a scratch database: a new in-memory
+// database for SQLite, a temporary schema of the given database otherwise,
+// which goes away along with the transaction it's created in. The given
+// database is never changed.
+func (s *Schema) Verify(src database.DB) ([]Drift, error) {
+	tx, err := src.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer tx.Rollback()
+
+	exists, err := SchemaTableExists(tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check if schema table is there")
+	}
+	if !exists {
+		return nil, errors.Errorf("schema table doesn't exist")
+	}
+	current, err := queryCurrentVersion(tx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if current > len(s.updates) {
+		return nil, errors.Errorf(
+			"schema version '%d' is more recent than expected '%d'",
+			current, len(s.updates))
+	}
+
+	actual, err := selectObjects(tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch objects")
+	}
+	expected, err := expectedObjects(tx, s.updates[:current])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create expected objects")
+	}
+	return diffObjects(expected, actual), nil
+}
+
+// object is a table or an index of a database, along with the statement that
+// creates it.
+type object struct {
+	kind, name, sql string
+}
+
+// Return the objects created by applying the given updates to a scratch
+// database of the same dialect of the given transaction.
+func expectedObjects(tx database.Tx, updates []Update) ([]object, error) {
+	dialect := tx.Dialect()
+	switch dialect.DriverName() {
+	case database.SQLite:
+		db, err := sql.Open(database.SQLite, ":memory:")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open scratch database")
+		}
+		defer db.Close()
+
+		// Every connection to an in-memory database is a new database, so
+		// make sure that only one is ever used.
+		db.SetMaxOpenConns(1)
+
+		scratch, err := database.NewShimDB(db, dialect).BeginTx(context.Background(), nil)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		defer scratch.Rollback()
+		tx = scratch
+	case database.Postgres:
+		if _, err := tx.Exec("CREATE SCHEMA " + scratchSchema); err != nil {
+			return nil, errors.Wrap(err, "failed to create scratch schema")
+		}
+		if _, err := tx.Exec("SET LOCAL search_path TO " + scratchSchema); err != nil {
+			return nil, errors.Wrap(err, "failed to switch to scratch schema")
+		}
+	default:
+		return nil, errors.Errorf("unsupported database %q", dialect.DriverName())
+	}
+
+	if err := createSchemaTable(tx); err != nil {
+		return nil, errors.Wrap(err, "failed to create schema table")
+	}
+	if err := ensureUpdatesAreApplied(tx, 0, updates, make([]string, len(updates)), "", nil); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return selectObjects(tx)
+}
+
+// Return all the tables, except the schema one, and all the indexes of the
+// database.
+func selectObjects(tx database.Tx) ([]object, error) {
+	var objects []object
+	for kind, stmt := range map[string]string{
+		"table": tx.Dialect().StmtTables(),
+		"index": tx.Dialect().StmtIndexes(),
+	} {
+		var selected []object
+		dest := func(i int) []interface{} {
+			selected = append(selected, object{kind: kind})
+			return []interface{}{&selected[i].name, &selected[i].sql}
+		}
+		if err := query.SelectObjects(context.Background(), tx, dest, stmt); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, o := range selected {
+			if o.kind == "table" && o.name == "schema" {
+				continue
+			}
+			objects = append(objects, o)
+		}
+	}
+	return objects, nil
+}
+
+// Return the drifts of the actual objects from the expected ones, sorted by
+// kind and name.
+func diffObjects(expected, actual []object) []Drift {
+	key := func(o object) string {
+		return o.kind + " " + o.name
+	}
+	actualByKey := make(map[string]object, len(actual))
+	for _, o := range actual {
+		actualByKey[key(o)] = o
+	}
+
+	var drifts []Drift
+	for _, e := range expected {
+		a, ok := actualByKey[key(e)]
+		if !ok {
+			drifts = append(drifts, Drift{Kind: e.kind, Name: e.name, Change: "missing"})
+			continue
+		}
+		delete(actualByKey, key(e))
+
+		if details := diffDefinitions(definitions(e), definitions(a)); len(details) > 0 {
+			drifts = append(drifts, Drift{Kind: e.kind, Name: e.name, Change: "changed", Details: details})
+		}
+	}
+	for _, a := range actualByKey {
+		drifts = append(drifts, Drift{Kind: a.kind, Name: a.name, Change: "extra"})
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Kind != drifts[j].Kind {
+			return drifts[i].Kind > drifts[j].Kind // Tables before indexes
+		}
+		return drifts[i].Name < drifts[j].Name
+	})
+	return drifts
+}
+
+// Return the definitions that are missing from the actual ones, prefixed by
+// "-", followed by the extra ones, prefixed by "+".
+func diffDefinitions(expected, actual []string) []string {
+	var details []string
+	for _, e := range expected {
+		if !contains(actual, e) {
+			details = append(details, "-"+e)
+		}
+	}
+	for _, a := range actual {
+		if !contains(expected, a) {
+			details = append(details, "+"+a)
+		}
+	}
+	return details
+}
+
+// Return the definitions making up the given object, with their whitespace
+// collapsed: the column definitions and the constraints of a table, or the
+// whole statement of an index.
+func definitions(o object) []string {
+	collapse := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+
+	start, end := strings.Index(o.sql, "("), strings.LastIndex(o.sql, ")")
+	if o.kind != "table" || start < 0 || end < start {
+		return []string{collapse(o.sql)}
+	}
+
+	var (
+		defs   []string
+		body   = o.sql[start+1 : end]
+		depth  int
+		quoted rune
+		last   int
+	)
+	for i, r := range body {
+		switch {
+		case quoted != 0:
+			if r == quoted {
+				quoted = 0
+			}
+		case r == '\'' || r == '"':
+			quoted = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			defs = append(defs, collapse(body[last:i]))
+			last = i + 1
+		}
+	}
+	return append(defs, collapse(body[last:]))
+}
@@ -0,0 +1,121 @@
+package schema_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/query"
+	"github.com/bicycolet/bicycolet/internal/db/schema"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+)
+
+func TestSchemaVerify(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := verifySchema(t, db)
+
+	drifts, err := s.Verify(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 0, len(drifts); expected != actual {
+		t.Errorf("expected: %d, actual: %d (%v)", expected, actual, drifts)
+	}
+}
+
+// Objects that were created, dropped or altered outside of the updates are
+// reported.
+func TestSchemaVerifyWithDrift(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := verifySchema(t, db)
+
+	if err := query.Transaction(context.Background(), db, func(tx database.Tx) error {
+		_, err := tx.Exec(`
+DROP INDEX users_name_idx;
+CREATE INDEX users_email_idx ON users (email);
+ALTER TABLE users ADD COLUMN age INTEGER;
+DROP TABLE groups;
+CREATE TABLE hotfix (id INTEGER);
+`)
+		return err
+	}); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	drifts, err := s.Verify(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	expected := []string{
+		"missing table groups",
+		"extra table hotfix",
+		"changed table users: +age INTEGER",
+		"extra index users_email_idx",
+		"missing index users_name_idx",
+	}
+	var actual []string
+	for _, drift := range drifts {
+		actual = append(actual, drift.String())
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// Only the updates applied to the database are taken into account.
+func TestSchemaVerifyWithPendingUpdates(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := verifySchema(t, db)
+	s.Add(exec("CREATE TABLE pending (id INTEGER)"))
+
+	drifts, err := s.Verify(db)
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 0, len(drifts); expected != actual {
+		t.Errorf("expected: %d, actual: %d (%v)", expected, actual, drifts)
+	}
+}
+
+func TestSchemaVerifyWithoutSchemaTable(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	defer db.Close()
+
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	if _, err := s.Verify(db); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+// Return a schema whose updates were applied to the given database.
+func verifySchema(t *testing.T, db database.DB) *schema.Schema {
+	s := schema.Empty(fsys.NewVirtualFileSystem())
+	s.Add(exec(`
+CREATE TABLE users (
+    id    INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name  TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    UNIQUE (email)
+)`))
+	s.Add(exec("CREATE INDEX users_name_idx ON users (name)"))
+	s.Add(exec("CREATE TABLE groups (id INTEGER, name TEXT)"))
+	if _, err := s.Ensure(db); err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+	return s
+}
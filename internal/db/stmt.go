@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/db/node"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/pkg/errors"
+)
+
+// RegisteredStmt is a SQL statement registered with RegisterStmt.
+type RegisteredStmt struct {
+	// Code the statement is looked up by.
+	Code int
+
+	// Name of the statement, which is unique.
+	Name string
+
+	// SQL of the statement.
+	SQL string
+}
+
+// The statements registered with RegisterStmt, in the order they were
+// registered, which is also the one of their codes.
+var stmts []RegisteredStmt
+
+// RegisterStmt registers the given SQL statement under the given name,
+// returning the code it can be looked up by. It's meant to be called when
+// initializing package variables, before any database is opened, so that
+// the statement gets prepared along with all the others.
+func RegisterStmt(name, sql string) int {
+	for _, stmt := range stmts {
+		if stmt.Name == name {
+			panic(fmt.Sprintf("statement %q registered twice", name))
+		}
+	}
+	code := len(stmts)
+	stmts = append(stmts, RegisteredStmt{
+		Code: code,
+		Name: name,
+		SQL:  sql,
+	})
+	return code
+}
+
+// Stmts returns all the registered statements, in the order they were
+// registered.
+func Stmts() []RegisteredStmt {
+	return append([]RegisteredStmt{}, stmts...)
+}
+
+// PrepareStmts prepares all the registered statements against the given
+// database, returning them indexed by their code.
+//
+// Preparing a statement fails if it doesn't match the schema of the
+// database, in which case the statements prepared so far are closed.
+func PrepareStmts(ctx context.Context, db database.DB) (map[int]database.Stmt, error) {
+	prepared := make(map[int]database.Stmt, len(stmts))
+	for _, stmt := range stmts {
+		p, err := db.PrepareContext(ctx, stmt.SQL)
+		if err != nil {
+			closeStmts(prepared)
+			return nil, errors.Wrapf(err, "failed to prepare statement %q", stmt.Name)
+		}
+		prepared[stmt.Code] = p
+	}
+	return prepared, nil
+}
+
+// CheckStmts prepares all the registered statements against a scratch
+// database with the current schema, reporting every statement that doesn't
+// match it.
+func CheckStmts(fileSystem fsys.FileSystem) error {
+	n := node.New(fileSystem)
+	if err := n.Open("", database.ConnectionInfo{
+		Driver: database.SQLite,
+		Memory: true,
+	}); err != nil {
+		return errors.Wrap(err, "failed to open scratch database")
+	}
+	defer n.Close()
+
+	if _, err := n.EnsureSchema(nil); err != nil {
+		return errors.Wrap(err, "failed to apply schema")
+	}
+
+	var failures []string
+	for _, stmt := range stmts {
+		prepared, err := n.DB().PrepareContext(context.Background(), stmt.SQL)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", stmt.Name, errors.Cause(err)))
+			continue
+		}
+		prepared.Close()
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("statements don't match the schema:\n%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// Close all the given prepared statements, returning the first error.
+func closeStmts(prepared map[int]database.Stmt) error {
+	var err error
+	for _, stmt := range prepared {
+		if closeErr := stmt.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return errors.WithStack(err)
+}
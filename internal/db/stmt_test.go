@@ -0,0 +1,54 @@
+package db_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/db"
+	"github.com/bicycolet/bicycolet/internal/db/mocks"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/golang/mock/gomock"
+)
+
+func TestStmts(t *testing.T) {
+	stmts := db.Stmts()
+	if len(stmts) == 0 {
+		t.Fatalf("expected statements to be registered")
+	}
+
+	names := make(map[string]bool)
+	for i, stmt := range stmts {
+		if expected, actual := i, stmt.Code; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if names[stmt.Name] {
+			t.Errorf("expected statement %q to be registered once", stmt.Name)
+		}
+		names[stmt.Name] = true
+	}
+}
+
+// Every registered statement must be valid against the current schema.
+func TestCheckStmts(t *testing.T) {
+	if err := db.CheckStmts(fsys.NewVirtualFileSystem()); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
+
+// Transactions that aren't created by a node have no prepared statements.
+func TestNodeTxWithoutPreparedStmts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+
+	tx := db.NewNodeTx(context.Background(), mockTx)
+	_, err := tx.GetConfig(db.ConfigFilter{})
+	if err == nil {
+		t.Fatalf("expected err not to be nil")
+	}
+	if expected, actual := "no statement prepared with code", err.Error(); !strings.Contains(actual, expected) {
+		t.Errorf("expected %q to contain %q", actual, expected)
+	}
+}
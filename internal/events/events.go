@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TypeLogging represents log messages from the daemon
+	TypeLogging = "logging"
+
+	// TypeOperation represents changes to background operations
+	TypeOperation = "operation"
+
+	// TypeLifecycle represents changes to the state of the daemon
+	TypeLifecycle = "lifecycle"
+)
+
+// Types returns all the event types that can be subscribed to
+func Types() []string {
+	return []string{
+		TypeLogging,
+		TypeOperation,
+		TypeLifecycle,
+	}
+}
+
+// ValidType checks to see if the event type is known
+func ValidType(eventType string) bool {
+	return contains(Types(), eventType)
+}
+
+// Event represents a message that is sent to all the listeners that are
+// subscribed to the event type.
+type Event struct {
+	Type      string      `json:"type" yaml:"type"`
+	Timestamp time.Time   `json:"timestamp" yaml:"timestamp"`
+	Metadata  interface{} `json:"metadata" yaml:"metadata"`
+}
+
+// Lifecycle represents the metadata of a lifecycle event
+type Lifecycle struct {
+	Action  string                 `json:"action" yaml:"action"`
+	Source  string                 `json:"source" yaml:"source"`
+	Context map[string]interface{} `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// Logging represents the metadata of a logging event
+type Logging struct {
+	Level   string            `json:"level" yaml:"level"`
+	Message string            `json:"message" yaml:"message"`
+	Context map[string]string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// Dispatcher dispatches events to any listeners
+type Dispatcher interface {
+
+	// Dispatch an event to all the listeners of the event type.
+	Dispatch(string, interface{}) error
+}
+
+// Broadcaster allows messages to be dispatched to any listeners.
+type Broadcaster struct {
+	listeners map[string]*Listener
+	mutex     sync.Mutex
+	logger    log.Logger
+}
+
+// New creates a new Broadcaster
+func New(options ...Option) *Broadcaster {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	return &Broadcaster{
+		listeners: make(map[string]*Listener),
+		logger:    opts.logger,
+	}
+}
+
+// Listen creates a Listener that will be sent all the events of the given
+// types.
+func (b *Broadcaster) Listen(types []string) *Listener {
+	listener := newListener(types)
+
+	b.mutex.Lock()
+	b.listeners[listener.ID()] = listener
+	b.mutex.Unlock()
+
+	level.Debug(b.logger).Log("msg", "added listener", "id", listener.ID(), "types", listener.Types())
+
+	return listener
+}
+
+// Forget removes the Listener, so that it will no longer be sent events. The
+// listener is closed as part of removal. Consumers of a listener are expected
+// to call Forget once they're done with the listener.
+func (b *Broadcaster) Forget(listener *Listener) {
+	b.mutex.Lock()
+	delete(b.listeners, listener.ID())
+	b.mutex.Unlock()
+
+	listener.Close()
+	listener.markReleased()
+
+	level.Debug(b.logger).Log("msg", "removed listener", "id", listener.ID())
+}
+
+// Dispatch an event to all the listeners of the event type. Listeners that
+// can't keep up with the events are closed and removed.
+func (b *Broadcaster) Dispatch(eventType string, metadata interface{}) error {
+	if !ValidType(eventType) {
+		return errors.Errorf("invalid event type %q", eventType)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.listeners) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(Event{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Metadata:  metadata,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for id, listener := range b.listeners {
+		if !contains(listener.Types(), eventType) {
+			continue
+		}
+		if !listener.send(body) {
+			delete(b.listeners, id)
+			listener.Close()
+		}
+	}
+	return nil
+}
+
+// Close all the listeners, removing them from the broadcaster. Close then
+// waits for the consumers of the listeners to forget them, so that any
+// pending events can be flushed, or until the context is done.
+func (b *Broadcaster) Close(ctx context.Context) error {
+	b.mutex.Lock()
+	listeners := make([]*Listener, 0, len(b.listeners))
+	for id, listener := range b.listeners {
+		delete(b.listeners, id)
+		listener.Close()
+		listeners = append(listeners, listener)
+	}
+	b.mutex.Unlock()
+
+	for _, listener := range listeners {
+		select {
+		case <-listener.released:
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		}
+	}
+	return nil
+}
+
+func contains(a []string, b string) bool {
+	for _, v := range a {
+		if v == b {
+			return true
+		}
+	}
+	return false
+}
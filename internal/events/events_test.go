@@ -0,0 +1,119 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/events"
+)
+
+func TestBroadcasterDispatch(t *testing.T) {
+	broadcaster := events.New()
+	listener := broadcaster.Listen([]string{events.TypeLifecycle})
+
+	err := broadcaster.Dispatch(events.TypeLifecycle, events.Lifecycle{
+		Action: "daemon-started",
+		Source: "/1.0",
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	var event struct {
+		Type     string           `json:"type"`
+		Metadata events.Lifecycle `json:"metadata"`
+	}
+	if err := json.Unmarshal(<-listener.Messages(), &event); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := events.TypeLifecycle, event.Type; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := "daemon-started", event.Metadata.Action; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestBroadcasterDispatchWithFilteredType(t *testing.T) {
+	broadcaster := events.New()
+	listener := broadcaster.Listen([]string{events.TypeOperation})
+
+	if err := broadcaster.Dispatch(events.TypeLifecycle, nil); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	select {
+	case <-listener.Messages():
+		t.Errorf("expected no messages")
+	default:
+	}
+}
+
+func TestBroadcasterDispatchWithInvalidType(t *testing.T) {
+	broadcaster := events.New()
+
+	if err := broadcaster.Dispatch("bad", nil); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestBroadcasterDispatchWithSlowListener(t *testing.T) {
+	broadcaster := events.New()
+	listener := broadcaster.Listen([]string{events.TypeLogging})
+
+	for i := 0; i < 1000; i++ {
+		if err := broadcaster.Dispatch(events.TypeLogging, nil); err != nil {
+			t.Errorf("expected err to be nil: %v", err)
+		}
+	}
+
+	select {
+	case <-listener.Done():
+	default:
+		t.Errorf("expected listener to be closed")
+	}
+}
+
+func TestBroadcasterForget(t *testing.T) {
+	broadcaster := events.New()
+	listener := broadcaster.Listen([]string{events.TypeLogging})
+	broadcaster.Forget(listener)
+
+	if err := broadcaster.Dispatch(events.TypeLogging, nil); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	select {
+	case <-listener.Messages():
+		t.Errorf("expected no messages")
+	case <-listener.Done():
+	}
+}
+
+func TestBroadcasterClose(t *testing.T) {
+	broadcaster := events.New()
+	listener := broadcaster.Listen([]string{events.TypeLogging})
+
+	go func() {
+		<-listener.Done()
+		broadcaster.Forget(listener)
+	}()
+
+	if err := broadcaster.Close(context.Background()); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+}
+
+func TestBroadcasterCloseWithTimeout(t *testing.T) {
+	broadcaster := events.New()
+	broadcaster.Listen([]string{events.TypeLogging})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	if err := broadcaster.Close(ctx); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/pborman/uuid"
+)
+
+// listenerBacklog is the number of events that can be queued for a listener
+// before the listener is considered too slow and is closed.
+const listenerBacklog = 128
+
+// Listener receives the events of the types it subscribed to.
+type Listener struct {
+	id       string
+	types    []string
+	messages chan []byte
+	done     chan struct{}
+	released chan struct{}
+	once     sync.Once
+	release  sync.Once
+}
+
+func newListener(types []string) *Listener {
+	return &Listener{
+		id:       uuid.NewRandom().String(),
+		types:    types,
+		messages: make(chan []byte, listenerBacklog),
+		done:     make(chan struct{}),
+		released: make(chan struct{}),
+	}
+}
+
+// ID returns the unique ID for the listener
+func (l *Listener) ID() string {
+	return l.id
+}
+
+// Types returns the underlying types the listener subscribes to
+func (l *Listener) Types() []string {
+	return l.types
+}
+
+// Messages returns a channel of serialised events
+func (l *Listener) Messages() <-chan []byte {
+	return l.messages
+}
+
+// Done returns a channel that is closed once the listener is closed.
+func (l *Listener) Done() <-chan struct{} {
+	return l.done
+}
+
+// Close the listener. It is safe to call Close multiple times.
+func (l *Listener) Close() {
+	l.once.Do(func() {
+		close(l.done)
+	})
+}
+
+// markReleased marks that the consumer of the listener has finished with it.
+func (l *Listener) markReleased() {
+	l.release.Do(func() {
+		close(l.released)
+	})
+}
+
+// send attempts to queue the message, without blocking. It returns false if
+// the message couldn't be queued.
+func (l *Listener) send(body []byte) bool {
+	select {
+	case <-l.done:
+		return false
+	default:
+	}
+
+	select {
+	case l.messages <- body:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+type logger struct {
+	logger     log.Logger
+	dispatcher Dispatcher
+}
+
+// NewLogger creates a log.Logger that dispatches every log line as a logging
+// event, before passing it on to the underlying logger.
+func NewLogger(l log.Logger, dispatcher Dispatcher) log.Logger {
+	return logger{
+		logger:     l,
+		dispatcher: dispatcher,
+	}
+}
+
+func (l logger) Log(keyvals ...interface{}) error {
+	event := Logging{
+		Context: make(map[string]string),
+	}
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		value := fmt.Sprint(log.ErrMissingValue)
+		if i+1 < len(keyvals) {
+			value = fmt.Sprint(keyvals[i+1])
+		}
+
+		switch {
+		case keyvals[i] == level.Key():
+			event.Level = value
+		case key == "msg":
+			event.Message = value
+		default:
+			event.Context[key] = value
+		}
+	}
+
+	// Failing to dispatch the log line should never prevent logging.
+	l.dispatcher.Dispatch(TypeLogging, event)
+
+	return l.logger.Log(keyvals...)
+}
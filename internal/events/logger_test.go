@@ -0,0 +1,36 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/events"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+func TestLogger(t *testing.T) {
+	broadcaster := events.New()
+	listener := broadcaster.Listen([]string{events.TypeLogging})
+
+	logger := events.NewLogger(log.NewNopLogger(), broadcaster)
+	if err := level.Info(logger).Log("msg", "hello", "name", "bicycolet"); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	var event struct {
+		Metadata events.Logging `json:"metadata"`
+	}
+	if err := json.Unmarshal(<-listener.Messages(), &event); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := "info", event.Metadata.Level; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := "hello", event.Metadata.Message; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := "bicycolet", event.Metadata.Context["name"]; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
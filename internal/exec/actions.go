@@ -1,6 +1,7 @@
 package exec
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -37,8 +38,23 @@ func interrupt(cancel <-chan struct{}) error {
 	signal.Notify(c, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 	select {
 	case sig := <-c:
-		return errors.Errorf("received signal %s", sig)
+		return errInterrupt{sig}
 	case <-cancel:
 		return errors.New("canceled")
 	}
 }
+
+type errInterrupt struct {
+	sig os.Signal
+}
+
+func (e errInterrupt) Error() string {
+	return fmt.Sprintf("received signal %s", e.sig)
+}
+
+// ErrInterrupt checks if the error was because of a interrupt or terminate
+// os signal being received.
+func ErrInterrupt(err error) bool {
+	_, ok := errors.Cause(err).(errInterrupt)
+	return ok
+}
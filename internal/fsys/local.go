@@ -118,8 +118,10 @@ func (LocalFileSystem) Walk(root string, walkFn filepath.WalkFunc) error {
 // Lock attempts to create a locking file for a given path.
 func (LocalFileSystem) Lock(path string) (r Releaser, existed bool, err error) {
 	r, existed, err = lock.New(path)
-	r = deletingReleaser{path, r}
-	return r, existed, errors.WithStack(err)
+	if err != nil {
+		return nil, existed, errors.WithStack(err)
+	}
+	return deletingReleaser{path, r}, existed, nil
 }
 
 // CopyFile copies a directory recursively, overwriting the target if it
@@ -65,6 +65,10 @@ func (fs *VirtualFileSystem) Open(path string) (File, error) {
 // OpenFile takes a path, opens a potential file and then returns a File if
 // that file exists, otherwise it returns an error if the file wasn't found.
 func (fs *VirtualFileSystem) OpenFile(path string, flag int, perm os.FileMode) (File, error) {
+	// Truncating and creating is the same as os.Create, minus the perms.
+	if flag&(os.O_CREATE|os.O_TRUNC) == os.O_CREATE|os.O_TRUNC {
+		return fs.Create(path)
+	}
 	return fs.Open(path)
 }
 
@@ -0,0 +1,88 @@
+package net
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultPort defines the default port used when an address doesn't specify
+// one.
+const DefaultPort = "8080"
+
+// CanonicalNetworkAddress parses the given network address and returns a
+// string of the form "host:port", possibly filling it with the default port if
+// it's missing.
+func CanonicalNetworkAddress(address string) string {
+	_, _, err := net.SplitHostPort(address)
+	if err != nil {
+		ip := net.ParseIP(address)
+		if ip != nil && ip.To4() == nil {
+			address = fmt.Sprintf("[%s]:%s", address, DefaultPort)
+		} else {
+			address = fmt.Sprintf("%s:%s", address, DefaultPort)
+		}
+	}
+	return address
+}
+
+// ListenAddresses returns a list of host:port combinations at which
+// this machine can be reached
+func ListenAddresses(value string) ([]string, error) {
+	addresses := make([]string, 0)
+
+	if value == "" {
+		return addresses, nil
+	}
+
+	localHost, localPort, err := net.SplitHostPort(value)
+	if err != nil {
+		localHost = value
+		localPort = DefaultPort
+	}
+
+	if localHost == "" || localHost == "0.0.0.0" || localHost == "::" || localHost == "[::]" {
+		ifaces, err := net.Interfaces()
+		if err != nil {
+			return addresses, err
+		}
+
+		for _, i := range ifaces {
+			addrs, err := i.Addrs()
+			if err != nil {
+				continue
+			}
+
+			for _, addr := range addrs {
+				var ip net.IP
+				switch v := addr.(type) {
+				case *net.IPNet:
+					ip = v.IP
+				case *net.IPAddr:
+					ip = v.IP
+				}
+
+				if !ip.IsGlobalUnicast() {
+					continue
+				}
+
+				if ip.To4() == nil {
+					if localHost == "0.0.0.0" {
+						continue
+					}
+					addresses = append(addresses, fmt.Sprintf("[%s]:%s", ip, localPort))
+				} else {
+					addresses = append(addresses, fmt.Sprintf("%s:%s", ip, localPort))
+				}
+			}
+		}
+	} else {
+		if strings.Contains(localHost, ":") {
+			addresses = append(addresses, fmt.Sprintf("[%s]:%s", localHost, localPort))
+		} else {
+			addresses = append(addresses, fmt.Sprintf("%s:%s", localHost, localPort))
+		}
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,43 @@
+package net_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/net"
+)
+
+func TestCanonicalNetworkAddress(t *testing.T) {
+	for input, expected := range map[string]string{
+		"127.0.0.1":      "127.0.0.1:8080",
+		"127.0.0.1:9000": "127.0.0.1:9000",
+		"foo.bar":        "foo.bar:8080",
+		"::1":            "[::1]:8080",
+		"[::1]:9000":     "[::1]:9000",
+	} {
+		t.Run(input, func(t *testing.T) {
+			if actual := net.CanonicalNetworkAddress(input); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestListenAddresses(t *testing.T) {
+	for input, expected := range map[string][]string{
+		"":               {},
+		"127.0.0.1":      {"127.0.0.1:8080"},
+		"127.0.0.1:9000": {"127.0.0.1:9000"},
+		"[::1]:9000":     {"[::1]:9000"},
+	} {
+		t.Run(input, func(t *testing.T) {
+			actual, err := net.ListenAddresses(input)
+			if err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		})
+	}
+}
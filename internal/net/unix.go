@@ -0,0 +1,34 @@
+package net
+
+import (
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// UnixListener returns a listener on the unix socket at the given path, with
+// the socket file permissions set to the given mode. A stale socket file,
+// left behind by a previous process, is removed before listening. If another
+// process is still serving on the socket, an error is returned.
+func UnixListener(path string, mode os.FileMode) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.Errorf("unix socket %q is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, errors.Wrap(err, "cannot remove stale unix socket")
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot listen on unix socket")
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, errors.Wrap(err, "cannot set unix socket permissions")
+	}
+	return listener, nil
+}
@@ -0,0 +1,76 @@
+package net_test
+
+import (
+	"io/ioutil"
+	stdnet "net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/net"
+)
+
+func TestUnixListener(t *testing.T) {
+	t.Run("listen", func(t *testing.T) {
+		path, cleanup := unixSocketPath(t)
+		defer cleanup()
+
+		listener, err := net.UnixListener(path, 0660)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := os.FileMode(0660), info.Mode().Perm(); expected != actual {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("stale socket", func(t *testing.T) {
+		path, cleanup := unixSocketPath(t)
+		defer cleanup()
+
+		// Leave a socket file behind, without anything serving on it.
+		stale, err := stdnet.ListenUnix("unix", &stdnet.UnixAddr{Name: path, Net: "unix"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale.SetUnlinkOnClose(false)
+		stale.Close()
+
+		listener, err := net.UnixListener(path, 0660)
+		if err != nil {
+			t.Fatal(err)
+		}
+		listener.Close()
+	})
+
+	t.Run("in use", func(t *testing.T) {
+		path, cleanup := unixSocketPath(t)
+		defer cleanup()
+
+		listener, err := net.UnixListener(path, 0660)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+
+		if _, err := net.UnixListener(path, 0660); err == nil {
+			t.Errorf("expected err not to be nil")
+		}
+	})
+}
+
+func unixSocketPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "unix.socket"), func() {
+		os.RemoveAll(dir)
+	}
+}
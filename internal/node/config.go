@@ -0,0 +1,145 @@
+package node
+
+import (
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/config"
+	"github.com/pkg/errors"
+)
+
+// Tx models a single interaction with a node-local database.
+type Tx interface {
+
+	// Config fetches all node-level config keys.
+	Config() (map[string]string, error)
+
+	// UpdateConfig updates the given node-level configuration keys in the
+	// config table. Config keys set to empty values will be deleted.
+	UpdateConfig(map[string]string) error
+}
+
+// ConfigSchema returns the available node configuration keys. A new schema is
+// returned on every call, so that change callbacks can be attached to it.
+func ConfigSchema() config.Schema {
+	return config.Schema{
+		// Network address for the debug server, which serves the pprof
+		// endpoints. The debug server is disabled when empty.
+		"core.debug_address": {Type: config.Address},
+
+		// How long finished operations are kept around, so that clients have
+		// a chance to read the final state.
+		"operations.retention": {
+			Type:      config.Duration,
+			Default:   "5m",
+			Validator: positiveDuration,
+		},
+	}
+}
+
+// Config holds node-local configuration values for a certain instance
+type Config struct {
+	tx        Tx         // DB transaction the values in this config are bound to
+	schema    config.Schema
+	configMap config.Map // Low-level map holding the config values.
+}
+
+// ConfigLoad loads a new Config object with the current node-local
+// configuration values fetched from the database. Any values for keys that
+// are not in the schema are ignored.
+func ConfigLoad(tx Tx, schema config.Schema) (*Config, error) {
+	// Load current raw values from the database, any error is fatal.
+	values, err := tx.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot fetch node config from database")
+	}
+
+	known := make(map[string]string, len(values))
+	for key, value := range values {
+		if _, ok := schema[key]; ok {
+			known[key] = value
+		}
+	}
+
+	m, err := config.New(schema, known)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load node config")
+	}
+
+	return &Config{
+		tx:        tx,
+		schema:    schema,
+		configMap: m,
+	}, nil
+}
+
+// DebugAddress returns the address and port to setup the pprof listener on
+func (c *Config) DebugAddress() (string, error) {
+	return c.configMap.GetAddress("core.debug_address")
+}
+
+// OperationsRetention returns how long finished operations are kept around.
+func (c *Config) OperationsRetention() (time.Duration, error) {
+	return c.configMap.GetDuration("operations.retention")
+}
+
+// Raw returns the value of the given key, regardless of its type. The
+// default is returned if the key isn't set.
+func (c *Config) Raw(name string) (string, error) {
+	return c.configMap.GetRaw(name)
+}
+
+// Dump current configuration keys and their values. Keys with values matching
+// their defaults are omitted, and hidden keys are masked.
+func (c *Config) Dump() (map[string]interface{}, error) {
+	return c.configMap.Dump()
+}
+
+// Replace the current configuration with the given values.
+func (c *Config) Replace(values map[string]interface{}) (map[string]string, error) {
+	return c.update(values)
+}
+
+// Patch changes only the configuration keys in the given map.
+func (c *Config) Patch(patch map[string]interface{}) (map[string]string, error) {
+	values, err := c.Dump() // Use current values as defaults
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for name, value := range patch {
+		values[name] = value
+	}
+	return c.update(values)
+}
+
+func (c *Config) update(values map[string]interface{}) (map[string]string, error) {
+	changed, err := c.configMap.Change(values)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Values that are reverted to their default are removed from the
+	// database, rather than persisting the default.
+	persist := make(map[string]string, len(changed))
+	for name, value := range changed {
+		if value == c.schema[name].Default {
+			value = ""
+		}
+		persist[name] = value
+	}
+
+	if err := c.tx.UpdateConfig(persist); err != nil {
+		return nil, errors.Wrap(err, "cannot persist local configuration changes")
+	}
+	return changed, nil
+}
+
+func positiveDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if d <= 0 {
+		return errors.Errorf("expected a positive duration")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package node_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/node"
+	"github.com/bicycolet/bicycolet/internal/node/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+)
+
+func TestConfigLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Config().Return(map[string]string{
+		"operations.retention": "1h",
+		"unknown.key":          "ignored",
+	}, nil)
+
+	config, err := node.ConfigLoad(mockTx, node.ConfigSchema())
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	retention, err := config.OperationsRetention()
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := time.Hour, retention; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	address, err := config.DebugAddress()
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := "", address; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+
+	dump, err := config.Dump()
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	want := map[string]interface{}{"operations.retention": "1h"}
+	if expected, actual := want, dump; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestConfigLoadWithFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Config().Return(nil, errors.New("boom"))
+
+	if _, err := node.ConfigLoad(mockTx, node.ConfigSchema()); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestConfigPatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	gomock.InOrder(
+		mockTx.EXPECT().Config().Return(map[string]string{
+			"operations.retention": "1h",
+		}, nil),
+		mockTx.EXPECT().UpdateConfig(map[string]string{
+			"core.debug_address": "127.0.0.1:8444",
+		}).Return(nil),
+	)
+
+	config, err := node.ConfigLoad(mockTx, node.ConfigSchema())
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	changed, err := config.Patch(map[string]interface{}{
+		"core.debug_address": "127.0.0.1:8444",
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	want := map[string]string{"core.debug_address": "127.0.0.1:8444"}
+	if expected, actual := want, changed; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestConfigReplace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	gomock.InOrder(
+		mockTx.EXPECT().Config().Return(map[string]string{
+			"operations.retention": "1h",
+		}, nil),
+		// Keys reverted to their default are removed.
+		mockTx.EXPECT().UpdateConfig(map[string]string{
+			"core.debug_address":   "127.0.0.1:8444",
+			"operations.retention": "",
+		}).Return(nil),
+	)
+
+	config, err := node.ConfigLoad(mockTx, node.ConfigSchema())
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	changed, err := config.Replace(map[string]interface{}{
+		"core.debug_address": "127.0.0.1:8444",
+	})
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	want := map[string]string{
+		"core.debug_address":   "127.0.0.1:8444",
+		"operations.retention": "5m",
+	}
+	if expected, actual := want, changed; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestConfigPatchWithInvalidValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := mocks.NewMockTx(ctrl)
+	mockTx.EXPECT().Config().Return(map[string]string{}, nil)
+
+	config, err := node.ConfigLoad(mockTx, node.ConfigSchema())
+	if err != nil {
+		t.Fatalf("expected err to be nil: %v", err)
+	}
+
+	for _, values := range []map[string]interface{}{
+		{"operations.retention": "-1m"},
+		{"operations.retention": "forever"},
+		{"unknown.key": "value"},
+	} {
+		if _, err := config.Patch(values); err == nil {
+			t.Errorf("expected err not to be nil for %v", values)
+		}
+	}
+}
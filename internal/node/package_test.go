@@ -0,0 +1,3 @@
+package node_test
+
+//go:generate mockgen -package mocks -destination mocks/node_mock.go github.com/bicycolet/bicycolet/internal/node Tx
@@ -0,0 +1,260 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// Op defines a very lightweight, read only view of an operation
+type Op struct {
+	ID          string
+	Description string
+	Status      string
+	StatusCode  Status
+	URL         string
+	Metadata    map[string]interface{}
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Err         string
+}
+
+// Changer notifies other listeners whom might be listening for
+// operational changes
+type Changer interface {
+	// Change is called every time the operation changes
+	Change(Op)
+}
+
+// Operation defines a operation that can be run in the background
+type Operation struct {
+	id          string
+	description string
+	status      Status
+	err         string
+	metadata    map[string]interface{}
+	createdAt   time.Time
+	updatedAt   time.Time
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	chanDone chan struct{}
+	mutex    sync.RWMutex
+
+	hookRun func(context.Context, *Operation) error
+
+	changer Changer
+	logger  log.Logger
+}
+
+// NewOperation creates an operation with sane defaults. The hook is called
+// when the operation is run and the context passed to it is cancelled when
+// the operation is cancelled.
+func NewOperation(description string, hook func(context.Context, *Operation) error, options ...Option) *Operation {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now().UTC()
+	return &Operation{
+		id:          uuid.NewRandom().String(),
+		description: description,
+		status:      Pending,
+		metadata:    make(map[string]interface{}),
+		createdAt:   now,
+		updatedAt:   now,
+		ctx:         ctx,
+		cancel:      cancel,
+		chanDone:    make(chan struct{}),
+		hookRun:     hook,
+		changer:     opts.changer,
+		logger:      opts.logger,
+	}
+}
+
+// ID returns the unique identifier of the operation
+func (o *Operation) ID() string {
+	return o.id
+}
+
+// Run the operation hook in the background
+func (o *Operation) Run() error {
+	o.mutex.Lock()
+
+	if o.status != Pending {
+		o.mutex.Unlock()
+		return errors.Errorf("only pending operations can be started")
+	}
+
+	o.setStatus(Running)
+
+	if o.hookRun == nil {
+		o.setStatus(Success)
+		o.done()
+	} else {
+		go o.runHook(o.hookRun)
+	}
+
+	o.mutex.Unlock()
+	o.notify()
+	return nil
+}
+
+// Cancel the operation. Pending operations are cancelled straight away,
+// where as running operations have their context cancelled and will be
+// marked as cancelled once the hook returns.
+func (o *Operation) Cancel() error {
+	o.mutex.Lock()
+
+	switch o.status {
+	case Pending:
+		o.setStatus(Cancelled)
+		o.done()
+	case Running:
+		o.setStatus(Cancelling)
+		o.cancel()
+	case Cancelling:
+		o.mutex.Unlock()
+		return nil
+	default:
+		o.mutex.Unlock()
+		return errors.Errorf("only pending or running operations can be cancelled")
+	}
+
+	o.mutex.Unlock()
+	o.notify()
+	return nil
+}
+
+// Wait for an operation to be completed. A negative timeout waits until
+// either the operation is completed or the context is done. The boolean
+// return value reports whether the operation completed.
+func (o *Operation) Wait(ctx context.Context, timeout time.Duration) (bool, error) {
+	if o.IsFinal() {
+		return true, nil
+	}
+
+	var timer <-chan time.Time
+	if timeout >= 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case <-o.chanDone:
+		return true, nil
+	case <-timer:
+		return false, nil
+	case <-ctx.Done():
+		return false, errors.WithStack(ctx.Err())
+	}
+}
+
+// UpdateMetadata merges the metadata into the operation metadata, which can
+// be used to report progress to any listeners.
+func (o *Operation) UpdateMetadata(metadata map[string]interface{}) error {
+	o.mutex.Lock()
+
+	if o.status.IsFinal() {
+		o.mutex.Unlock()
+		return errors.Errorf("operations can not be updated once finished")
+	}
+
+	for k, v := range metadata {
+		o.metadata[k] = v
+	}
+	o.updatedAt = time.Now().UTC()
+
+	o.mutex.Unlock()
+	o.notify()
+	return nil
+}
+
+// IsFinal returns the state of the operation
+func (o *Operation) IsFinal() bool {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	return o.status.IsFinal()
+}
+
+// Render the operation as a readonly Op
+func (o *Operation) Render() Op {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	return o.render()
+}
+
+// render expects the mutex to be held.
+func (o *Operation) render() Op {
+	metadata := make(map[string]interface{}, len(o.metadata))
+	for k, v := range o.metadata {
+		metadata[k] = v
+	}
+
+	return Op{
+		ID:          o.id,
+		Description: o.description,
+		Status:      o.status.String(),
+		StatusCode:  o.status,
+		URL:         fmt.Sprintf("/operations/%s", o.id),
+		Metadata:    metadata,
+		CreatedAt:   o.createdAt,
+		UpdatedAt:   o.updatedAt,
+		Err:         o.err,
+	}
+}
+
+// setStatus expects the mutex to be held.
+func (o *Operation) setStatus(status Status) {
+	o.status = status
+	o.updatedAt = time.Now().UTC()
+}
+
+// done expects the mutex to be held.
+func (o *Operation) done() {
+	o.hookRun = nil
+	o.cancel()
+	close(o.chanDone)
+}
+
+// notify the changer of the current state, expects the mutex not to be held.
+func (o *Operation) notify() {
+	o.mutex.RLock()
+	changer, op := o.changer, o.render()
+	o.mutex.RUnlock()
+
+	changer.Change(op)
+}
+
+func (o *Operation) runHook(hook func(context.Context, *Operation) error) {
+	err := hook(o.ctx, o)
+
+	o.mutex.Lock()
+	defer o.notify()
+	defer o.mutex.Unlock()
+
+	switch {
+	case o.status == Cancelling:
+		level.Debug(o.logger).Log("msg", "operation cancelled", "id", o.id)
+		o.setStatus(Cancelled)
+	case err != nil:
+		level.Error(o.logger).Log("msg", "operation failure", "id", o.id, "err", err)
+		o.err = err.Error()
+		o.setStatus(Failure)
+	default:
+		level.Debug(o.logger).Log("msg", "operation success", "id", o.id)
+		o.setStatus(Success)
+	}
+	o.done()
+}
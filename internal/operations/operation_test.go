@@ -0,0 +1,115 @@
+package operations_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/operations"
+	"github.com/pkg/errors"
+)
+
+func TestOperationRun(t *testing.T) {
+	op := operations.NewOperation("test", func(ctx context.Context, op *operations.Operation) error {
+		return op.UpdateMetadata(map[string]interface{}{
+			"progress": 100,
+		})
+	})
+	if expected, actual := operations.Pending, op.Render().StatusCode; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	if err := op.Run(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	done, err := op.Wait(context.Background(), -1)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if !done {
+		t.Errorf("expected operation to be done")
+	}
+
+	rendered := op.Render()
+	if expected, actual := operations.Success, rendered.StatusCode; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := 100, rendered.Metadata["progress"]; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestOperationRunWithFailure(t *testing.T) {
+	op := operations.NewOperation("test", func(ctx context.Context, op *operations.Operation) error {
+		return errors.New("bad")
+	})
+	if err := op.Run(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if _, err := op.Wait(context.Background(), -1); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	rendered := op.Render()
+	if expected, actual := operations.Failure, rendered.StatusCode; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := "bad", rendered.Err; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestOperationRunTwice(t *testing.T) {
+	op := operations.NewOperation("test", nil)
+	if err := op.Run(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if err := op.Run(); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestOperationCancel(t *testing.T) {
+	started := make(chan struct{})
+	op := operations.NewOperation("test", func(ctx context.Context, op *operations.Operation) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := op.Run(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	<-started
+
+	if err := op.Cancel(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if _, err := op.Wait(context.Background(), -1); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := operations.Cancelled, op.Render().StatusCode; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if err := op.Cancel(); err == nil {
+		t.Errorf("expected err not to be nil")
+	}
+}
+
+func TestOperationWaitWithTimeout(t *testing.T) {
+	op := operations.NewOperation("test", func(ctx context.Context, op *operations.Operation) error {
+		<-ctx.Done()
+		return nil
+	})
+	if err := op.Run(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	defer op.Cancel()
+
+	done, err := op.Wait(context.Background(), time.Millisecond)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if done {
+		t.Errorf("expected operation not to be done")
+	}
+}
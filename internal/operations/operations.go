@@ -0,0 +1,156 @@
+package operations
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned when an operation can not be located.
+var ErrNotFound = errors.New("operation not found")
+
+// Operations represents a operational collection of things that want to be
+// run
+type Operations struct {
+	operations map[string]*Operation
+	mutex      sync.Mutex
+	changer    Changer
+	logger     log.Logger
+}
+
+// New creates a series of operations to be worked on
+func New(options ...Option) *Operations {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	return &Operations{
+		operations: make(map[string]*Operation),
+		changer:    opts.changer,
+		logger:     opts.logger,
+	}
+}
+
+// Add an operation to the collection
+func (o *Operations) Add(op *Operation) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if _, ok := o.operations[op.id]; ok {
+		return errors.Errorf("operation %q already exists", op.id)
+	}
+
+	op.mutex.Lock()
+	if _, ok := op.changer.(nopChanger); ok {
+		op.changer = o.changer
+	}
+	op.mutex.Unlock()
+
+	o.operations[op.id] = op
+	return nil
+}
+
+// GetOp retrieves an op of the operation from the collection by id. As long
+// as the id is a unique prefix of an operation id, then that operation is
+// returned.
+func (o *Operations) GetOp(id string) (Op, error) {
+	op, err := o.get(id)
+	if err != nil {
+		return Op{}, errors.WithStack(err)
+	}
+	return op.Render(), nil
+}
+
+// DeleteOp attempts to cancel an operation by the id
+func (o *Operations) DeleteOp(id string) error {
+	op, err := o.get(id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return op.Cancel()
+}
+
+// WaitOp for an operation to be completed
+func (o *Operations) WaitOp(ctx context.Context, id string, timeout time.Duration) (bool, error) {
+	op, err := o.get(id)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return op.Wait(ctx, timeout)
+}
+
+// Walk over the operations in a predictable manor
+func (o *Operations) Walk(fn func(Op) error) error {
+	o.mutex.Lock()
+	sorted := make([]Op, 0, len(o.operations))
+	for _, v := range o.operations {
+		sorted = append(sorted, v.Render())
+	}
+	o.mutex.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	for _, v := range sorted {
+		if err := fn(v); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// Prune removes any finished operations that haven't been updated within
+// the given age, returning the number of operations removed.
+func (o *Operations) Prune(age time.Duration) int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	cutoff := time.Now().UTC().Add(-age)
+
+	var removed int
+	for k, v := range o.operations {
+		op := v.Render()
+		if op.StatusCode.IsFinal() && !op.UpdatedAt.After(cutoff) {
+			delete(o.operations, k)
+			removed++
+		}
+	}
+
+	level.Debug(o.logger).Log("msg", "pruned operations", "removed", removed)
+	return removed
+}
+
+func (o *Operations) get(id string) (*Operation, error) {
+	if id == "" {
+		return nil, errors.Errorf("expected id")
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if op, ok := o.operations[id]; ok {
+		return op, nil
+	}
+
+	var results []*Operation
+	for k, v := range o.operations {
+		if strings.HasPrefix(k, id) {
+			results = append(results, v)
+		}
+	}
+
+	if num := len(results); num == 0 {
+		return nil, errors.Wrapf(ErrNotFound, "%q", id)
+	} else if num > 1 {
+		return nil, errors.Errorf("ambiguous operation %q, too many matches", id)
+	}
+	return results[0], nil
+}
@@ -0,0 +1,131 @@
+package operations_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/bicycolet/bicycolet/internal/operations"
+	"github.com/pkg/errors"
+)
+
+func TestOperationsGetOp(t *testing.T) {
+	ops := operations.New()
+	op := operations.NewOperation("test", nil)
+	if err := ops.Add(op); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	result, err := ops.GetOp(op.ID()[:8])
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := op.ID(), result.ID; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestOperationsGetOpWithNotFound(t *testing.T) {
+	ops := operations.New()
+
+	_, err := ops.GetOp("missing")
+	if expected, actual := operations.ErrNotFound, errors.Cause(err); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestOperationsDeleteOp(t *testing.T) {
+	ops := operations.New()
+	op := operations.NewOperation("test", nil)
+	if err := ops.Add(op); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	if err := ops.DeleteOp(op.ID()); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	done, err := ops.WaitOp(context.Background(), op.ID(), 0)
+	if err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if !done {
+		t.Errorf("expected operation to be done")
+	}
+}
+
+func TestOperationsWalkAndPrune(t *testing.T) {
+	ops := operations.New()
+	for i := 0; i < 3; i++ {
+		op := operations.NewOperation("test", nil)
+		if err := ops.Add(op); err != nil {
+			t.Errorf("expected err to be nil: %v", err)
+		}
+		if i > 0 {
+			if err := op.Run(); err != nil {
+				t.Errorf("expected err to be nil: %v", err)
+			}
+		}
+	}
+
+	if expected, actual := 2, ops.Prune(0); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	var ids []string
+	if err := ops.Walk(func(op operations.Op) error {
+		ids = append(ids, op.ID)
+		return nil
+	}); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if expected, actual := 1, len(ids); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestOperationsChanger(t *testing.T) {
+	changer := &recordingChanger{}
+	ops := operations.New(operations.WithChanger(changer))
+	op := operations.NewOperation("test", nil)
+	if err := ops.Add(op); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+	if err := op.Run(); err != nil {
+		t.Errorf("expected err to be nil: %v", err)
+	}
+
+	if expected, actual := []string{"success"}, changer.Statuses(); !equal(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+type recordingChanger struct {
+	mutex    sync.Mutex
+	statuses []string
+}
+
+func (c *recordingChanger) Change(op operations.Op) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.statuses = append(c.statuses, op.Status)
+}
+
+func (c *recordingChanger) Statuses() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.statuses
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
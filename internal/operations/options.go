@@ -0,0 +1,40 @@
+package operations
+
+import "github.com/go-kit/kit/log"
+
+// Option to be passed to New or NewOperation to customize the resulting
+// instance.
+type Option func(*options)
+
+type options struct {
+	changer Changer
+	logger  log.Logger
+}
+
+// WithChanger sets the changer on the option, which is notified every time
+// an operation changes. Operations added to a collection without a changer,
+// inherit the changer of the collection.
+func WithChanger(changer Changer) Option {
+	return func(options *options) {
+		options.changer = changer
+	}
+}
+
+// WithLogger sets the logger on the option
+func WithLogger(logger log.Logger) Option {
+	return func(options *options) {
+		options.logger = logger
+	}
+}
+
+// Create a options instance with default values.
+func newOptions() *options {
+	return &options{
+		changer: nopChanger{},
+		logger:  log.NewNopLogger(),
+	}
+}
+
+type nopChanger struct{}
+
+func (nopChanger) Change(Op) {}
@@ -0,0 +1,69 @@
+package retrier
+
+import (
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/resilience/clock"
+)
+
+// Backoff computes the durations to sleep for before each retry, given the
+// amount of retries and the initial duration.
+type Backoff func(amount int, duration time.Duration) []time.Duration
+
+// The backoffs a Retrier can use.
+var (
+	// Linear sleeps for the same duration before every retry.
+	Linear Backoff = linear
+
+	// Exponential doubles the duration to sleep for after every retry.
+	Exponential Backoff = exponential
+)
+
+// Option to be passed to New to customize the resulting instance.
+type Option func(*options)
+
+type options struct {
+	backoff    Backoff
+	jitter     bool
+	clock      clock.Clock
+	maxElapsed time.Duration
+}
+
+// WithBackoff sets the backoff on the option
+func WithBackoff(backoff Backoff) Option {
+	return func(options *options) {
+		options.backoff = backoff
+	}
+}
+
+// WithJitter randomizes every duration to sleep for between half of it and
+// all of it, so that concurrent retries spread out.
+func WithJitter() Option {
+	return func(options *options) {
+		options.jitter = true
+	}
+}
+
+// WithClock sets the clock on the option
+func WithClock(clock clock.Clock) Option {
+	return func(options *options) {
+		options.clock = clock
+	}
+}
+
+// WithMaxElapsedTime sets the time after which no more retries are attempted,
+// counting from the first run of the function. Zero means that there's no
+// limit.
+func WithMaxElapsedTime(maxElapsed time.Duration) Option {
+	return func(options *options) {
+		options.maxElapsed = maxElapsed
+	}
+}
+
+// Create a options instance with default values.
+func newOptions() *options {
+	return &options{
+		backoff: Linear,
+		clock:   clock.New(),
+	}
+}
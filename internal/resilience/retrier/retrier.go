@@ -1,6 +1,7 @@
 package retrier
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/bicycolet/bicycolet/internal/resilience/clock"
@@ -8,31 +9,50 @@ import (
 
 // Retrier encapsulates the mechanism around retrying function
 type Retrier struct {
-	sleeper clock.Sleeper
-	backoff []time.Duration
+	sleeper    clock.Sleeper
+	clock      clock.Clock
+	backoff    []time.Duration
+	jitter     bool
+	maxElapsed time.Duration
 }
 
 // New creates a Retrier with sane defaults
-func New(sleeper clock.Sleeper, amount int, duration time.Duration) *Retrier {
+func New(sleeper clock.Sleeper, amount int, duration time.Duration, options ...Option) *Retrier {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
 	return &Retrier{
-		sleeper: sleeper,
-		backoff: linear(amount, duration),
+		sleeper:    sleeper,
+		clock:      opts.clock,
+		backoff:    opts.backoff(amount, duration),
+		jitter:     opts.jitter,
+		maxElapsed: opts.maxElapsed,
 	}
 }
 
 // Run executes the given function
 func (r *Retrier) Run(fn func() error) error {
+	start := r.clock.Now()
+
 	var retries int
 	for {
 		err := fn()
 		if err == nil {
 			return nil
 		}
-
 		if retries >= len(r.backoff) {
 			return errRetry{err}
 		}
-		r.sleeper.Sleep(r.backoff[retries])
+		backoff := r.backoff[retries]
+		if r.jitter && backoff > 1 {
+			backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+		}
+		if r.maxElapsed > 0 && r.clock.Now().Sub(start)+backoff > r.maxElapsed {
+			return errRetry{err}
+		}
+		r.sleeper.Sleep(backoff)
 		retries++
 	}
 }
@@ -2,10 +2,13 @@ package retrier_test
 
 import (
 	"fmt"
+	"reflect"
+	"testing"
 	"time"
 
 	"github.com/bicycolet/bicycolet/internal/resilience/clock"
 	"github.com/bicycolet/bicycolet/internal/resilience/retrier"
+	"github.com/pkg/errors"
 )
 
 func Example() {
@@ -26,3 +29,82 @@ func Example() {
 	// Output:
 	// success!
 }
+
+func TestRunWithExponentialBackoff(t *testing.T) {
+	fake := &fakeClock{}
+	retry := retrier.New(fake, 3, time.Second,
+		retrier.WithBackoff(retrier.Exponential),
+		retrier.WithClock(fake),
+	)
+	err := retry.Run(func() error {
+		return errors.New("bad")
+	})
+	if !retrier.ErrRetry(err) {
+		t.Errorf("expected err to be a retry error: %v", err)
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	if actual := fake.slept; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestRunWithJitter(t *testing.T) {
+	fake := &fakeClock{}
+	retry := retrier.New(fake, 10, time.Second,
+		retrier.WithJitter(),
+		retrier.WithClock(fake),
+	)
+	retry.Run(func() error {
+		return errors.New("bad")
+	})
+	for _, d := range fake.slept {
+		if d < time.Second/2 || d >= time.Second {
+			t.Errorf("expected %v to be within [500ms, 1s)", d)
+		}
+	}
+}
+
+func TestRunWithMaxElapsedTime(t *testing.T) {
+	fake := &fakeClock{}
+	retry := retrier.New(fake, 10, time.Second,
+		retrier.WithClock(fake),
+		retrier.WithMaxElapsedTime(3*time.Second),
+	)
+	var calls int
+	err := retry.Run(func() error {
+		calls++
+		return errors.New("bad")
+	})
+	if !retrier.ErrRetry(err) {
+		t.Errorf("expected err to be a retry error: %v", err)
+	}
+	if expected, actual := 4, calls; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+// fakeClock is a clock that only moves forward when sleeping.
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) UTC() time.Time {
+	return c.now.UTC()
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.Sleep(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.slept = append(c.slept, d)
+}
@@ -0,0 +1,62 @@
+package backups
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bicycolet/bicycolet/internal/operations"
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+// API defines a backups API
+type API struct {
+	api.DefaultService
+	logger log.Logger
+}
+
+// NewAPI creates a API with sane defaults
+func NewAPI(options ...Option) *API {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	return &API{
+		logger: opts.logger,
+	}
+}
+
+// Name returns the API name
+func (a *API) Name() string {
+	return "backups"
+}
+
+// Post defines a service for calling "POST" method and returns a response.
+// The node database is backed up in the background, by an operation whose
+// metadata holds the schema version the backup is named after once it's
+// done.
+func (a *API) Post(ctx context.Context, req *http.Request) api.Response {
+	defer req.Body.Close()
+
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	op := operations.NewOperation("Backing up the node database", func(ctx context.Context, op *operations.Operation) error {
+		version, err := d.Node().Backup(ctx)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return op.UpdateMetadata(map[string]interface{}{
+			"version": version,
+		})
+	}, operations.WithLogger(a.logger))
+	if err := d.Operations().Add(op); err != nil {
+		return api.InternalError(err)
+	}
+
+	return api.OperationResponse(op)
+}
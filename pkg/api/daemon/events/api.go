@@ -0,0 +1,149 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/events"
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// writeTimeout is the amount of time allowed to write a single event to the
+// websocket.
+const writeTimeout = 10 * time.Second
+
+// API defines a events API
+type API struct {
+	api.DefaultService
+	wsUpgrader websocket.Upgrader
+	logger     log.Logger
+}
+
+// NewAPI creates a API with sane defaults
+func NewAPI(options ...Option) *API {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	return &API{
+		wsUpgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+		logger: opts.logger,
+	}
+}
+
+// Name returns the API name
+func (a *API) Name() string {
+	return "events"
+}
+
+// Get defines a service for calling "GET" method and returns a response.
+// The request is upgraded to a websocket, which is then sent all the events
+// that match the requested types, until either side closes the connection.
+func (a *API) Get(ctx context.Context, req *http.Request) api.Response {
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	types := events.Types()
+	if typeStr := req.FormValue("type"); typeStr != "" {
+		types = strings.Split(typeStr, ",")
+	}
+	for _, eventType := range types {
+		if !events.ValidType(eventType) {
+			return api.BadRequest(errors.Errorf("invalid event type %q", eventType))
+		}
+	}
+
+	return &service{
+		events:     d.Events(),
+		types:      types,
+		req:        req,
+		wsUpgrader: a.wsUpgrader,
+		logger:     a.logger,
+	}
+}
+
+type service struct {
+	events     api.Events
+	types      []string
+	req        *http.Request
+	wsUpgrader websocket.Upgrader
+	logger     log.Logger
+}
+
+// Render will consume a http.ResponseWriter and return an error in a vistor
+// pattern scenario.
+func (s *service) Render(w http.ResponseWriter) error {
+	conn, err := s.wsUpgrader.Upgrade(w, s.req, nil)
+	if err != nil {
+		// The upgrader has already replied to the request, so there is
+		// nothing left to render.
+		level.Debug(s.logger).Log("msg", "failed to upgrade to websocket", "err", err)
+		return nil
+	}
+	defer conn.Close()
+
+	listener := s.events.Listen(s.types)
+	defer s.events.Forget(listener)
+
+	// The client isn't expected to send anything, but reading is required to
+	// notice when the client has gone away.
+	go func() {
+		defer s.events.Forget(listener)
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	level.Debug(s.logger).Log("msg", "event listener connected", "id", listener.ID(), "types", strings.Join(s.types, ","))
+
+	for {
+		select {
+		case body := <-listener.Messages():
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, body); err != nil {
+				level.Debug(s.logger).Log("msg", "event listener disconnected", "id", listener.ID(), "err", err)
+				return nil
+			}
+		case <-listener.Done():
+			// Flush any events that were dispatched before the listener was
+			// closed, before closing the websocket.
+			s.flush(conn, listener)
+
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
+			level.Debug(s.logger).Log("msg", "event listener disconnected", "id", listener.ID())
+			return nil
+		}
+	}
+}
+
+func (s *service) flush(conn *websocket.Conn, listener *events.Listener) {
+	for {
+		select {
+		case body := <-listener.Messages():
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, body); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package operations
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bicycolet/bicycolet/internal/operations"
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/bicycolet/bicycolet/pkg/client"
+	"github.com/go-kit/kit/log"
+)
+
+// API defines a operations API
+type API struct {
+	api.DefaultService
+	logger log.Logger
+}
+
+// NewAPI creates a API with sane defaults
+func NewAPI(options ...Option) *API {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	return &API{
+		logger: opts.logger,
+	}
+}
+
+// Name returns the API name
+func (a *API) Name() string {
+	return "operations"
+}
+
+// Get defines a service for calling "GET" method and returns a response.
+func (a *API) Get(ctx context.Context, req *http.Request) api.Response {
+	defer req.Body.Close()
+
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	result := make([]client.Operation, 0)
+	if err := d.Operations().Walk(func(op operations.Op) error {
+		result = append(result, api.RenderOp(op))
+		return nil
+	}); err != nil {
+		return api.SmartError(err)
+	}
+
+	return api.SyncResponse(true, result)
+}
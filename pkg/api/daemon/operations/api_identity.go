@@ -0,0 +1,82 @@
+package operations
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// IdentityAPI defines a operations/{id} API
+type IdentityAPI struct {
+	api.DefaultService
+	logger log.Logger
+}
+
+// NewIdentityAPI creates a API with sane defaults
+func NewIdentityAPI(options ...Option) *IdentityAPI {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	return &IdentityAPI{
+		logger: opts.logger,
+	}
+}
+
+// Name returns the IdentityAPI name
+func (a *IdentityAPI) Name() string {
+	return "operations/{id}"
+}
+
+// Get defines a service for calling "GET" method and returns a response.
+func (a *IdentityAPI) Get(ctx context.Context, req *http.Request) api.Response {
+	defer req.Body.Close()
+
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	id, ok := mux.Vars(req)["id"]
+	if !ok {
+		return api.BadRequest(errors.Errorf("expected id"))
+	}
+
+	op, err := d.Operations().GetOp(id)
+	if err != nil {
+		return api.SmartError(err)
+	}
+
+	return api.SyncResponse(true, api.RenderOp(op))
+}
+
+// Delete defines a service for calling "DELETE" method and returns a response.
+// Deleting an operation will attempt to cancel it.
+func (a *IdentityAPI) Delete(ctx context.Context, req *http.Request) api.Response {
+	defer req.Body.Close()
+
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	id, ok := mux.Vars(req)["id"]
+	if !ok {
+		return api.BadRequest(errors.Errorf("expected id"))
+	}
+
+	op, err := d.Operations().GetOp(id)
+	if err != nil {
+		return api.SmartError(err)
+	}
+	if err := d.Operations().DeleteOp(op.ID); err != nil {
+		return api.BadRequest(err)
+	}
+
+	return api.EmptySyncResponse()
+}
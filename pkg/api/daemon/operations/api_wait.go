@@ -0,0 +1,81 @@
+package operations
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// WaitAPI defines a operations/{id}/wait API
+type WaitAPI struct {
+	api.DefaultService
+	logger log.Logger
+}
+
+// NewWaitAPI creates a API with sane defaults
+func NewWaitAPI(options ...Option) *WaitAPI {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	return &WaitAPI{
+		logger: opts.logger,
+	}
+}
+
+// Name returns the WaitAPI name
+func (a *WaitAPI) Name() string {
+	return "operations/{id}/wait"
+}
+
+// Get defines a service for calling "GET" method and returns a response.
+// The request blocks until the operation is completed or the timeout is
+// reached, after which the current state of the operation is returned.
+func (a *WaitAPI) Get(ctx context.Context, req *http.Request) api.Response {
+	defer req.Body.Close()
+
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	id, ok := mux.Vars(req)["id"]
+	if !ok {
+		return api.BadRequest(errors.Errorf("expected id"))
+	}
+	timeout, err := parseTimeoutDefault(req.FormValue("timeout"), -1)
+	if err != nil {
+		return api.BadRequest(err)
+	}
+
+	op, err := d.Operations().GetOp(id)
+	if err != nil {
+		return api.SmartError(err)
+	}
+	if _, err := d.Operations().WaitOp(ctx, op.ID, timeout); err != nil {
+		return api.InternalError(err)
+	}
+
+	// Get the operation again, so that the final state is returned.
+	op, err = d.Operations().GetOp(op.ID)
+	if err != nil {
+		return api.SmartError(err)
+	}
+
+	return api.SyncResponse(true, api.RenderOp(op))
+}
+
+func parseTimeoutDefault(v string, d time.Duration) (time.Duration, error) {
+	if v == "" {
+		return d, nil
+	}
+
+	dur, err := time.ParseDuration(v)
+	return dur, errors.WithStack(err)
+}
@@ -1,6 +1,117 @@
 package root
 
-// Server represents the structure for the server
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/bicycolet/bicycolet/internal/json"
+	"github.com/bicycolet/bicycolet/internal/net"
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/go-kit/kit/log"
+)
+
+// API defines a root API
+type API struct {
+	api.DefaultService
+	logger log.Logger
+}
+
+// NewAPI creates a API with sane defaults
+func NewAPI(options ...Option) *API {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	return &API{
+		logger: opts.logger,
+	}
+}
+
+// Name returns the API name
+func (a *API) Name() string {
+	return ""
+}
+
+// Get defines a service for calling "GET" method and returns a response.
+func (a *API) Get(ctx context.Context, req *http.Request) api.Response {
+	defer req.Body.Close()
+
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	addresses, err := net.ListenAddresses(d.NetworkAddress())
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	serverName, err := os.Hostname()
+	if err != nil {
+		return api.SmartError(err)
+	}
+
+	config, err := readConfig(ctx, d.Node(), d.NodeConfigSchema())
+	if err != nil {
+		return api.SmartError(err)
+	}
+
+	server := Server{
+		Environment: Environment{
+			Addresses:     addresses,
+			Server:        "bicycolet",
+			ServerPid:     os.Getpid(),
+			ServerVersion: d.Version(),
+			ServerName:    serverName,
+		},
+		Config: config,
+	}
+	return api.SyncResponseETag(true, server, server.Config)
+}
+
+// Put defines a service for calling "PUT" method and returns a response.
+// The server config is replaced with the requested config, any keys that
+// are missing are removed.
+func (a *API) Put(ctx context.Context, req *http.Request) api.Response {
+	defer req.Body.Close()
+
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	var info ServerUpdate
+	if err := json.Read(req.Body, &info); err != nil {
+		return api.BadRequest(err)
+	}
+	return update(ctx, d, req, info, false)
+}
+
+// Patch defines a service for calling "PATCH" method and returns a response.
+// The requested config is merged into the server config, a key with an
+// empty value is removed.
+func (a *API) Patch(ctx context.Context, req *http.Request) api.Response {
+	defer req.Body.Close()
+
+	d, err := api.GetDaemon(ctx)
+	if err != nil {
+		return api.InternalError(err)
+	}
+
+	var info ServerUpdate
+	if err := json.Read(req.Body, &info); err != nil {
+		return api.BadRequest(err)
+	}
+	if info.Config == nil {
+		return api.EmptySyncResponse()
+	}
+	return update(ctx, d, req, info, true)
+}
+
+// Server represents the structure for the server. Any secret config values
+// are masked.
 type Server struct {
 	Environment Environment            `json:"environment" yaml:"environment"`
 	Config      map[string]interface{} `json:"config" yaml:"config"`
@@ -14,3 +125,9 @@ type Environment struct {
 	ServerVersion string   `json:"server_version" yaml:"server_version"`
 	ServerName    string   `json:"server_name" yaml:"server_name"`
 }
+
+// ServerUpdate represents what can be changed when updating the server
+// information
+type ServerUpdate struct {
+	Config map[string]interface{} `json:"config" yaml:"config"`
+}
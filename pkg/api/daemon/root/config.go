@@ -0,0 +1,81 @@
+package root
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bicycolet/bicycolet/internal/config"
+	"github.com/bicycolet/bicycolet/internal/db"
+	"github.com/bicycolet/bicycolet/internal/etag"
+	"github.com/bicycolet/bicycolet/internal/node"
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/pkg/errors"
+)
+
+func readConfig(ctx context.Context, n api.Node, schema config.Schema) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := n.Transaction(ctx, func(tx *db.NodeTx) error {
+		nodeConfig, err := node.ConfigLoad(tx, schema)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		result, err = nodeConfig.Dump()
+		return errors.WithStack(err)
+	}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return result, nil
+}
+
+// update the config of the node, with the ETag of the request checked
+// against the current config within the same transaction, so that
+// concurrent updates can't be lost. Once the changes are persisted, the
+// change callbacks of the schema are triggered.
+func update(ctx context.Context, d api.Daemon, req *http.Request, info ServerUpdate, patch bool) api.Response {
+	schema := d.NodeConfigSchema()
+
+	var changed map[string]string
+	if err := d.Node().Transaction(ctx, func(tx *db.NodeTx) error {
+		nodeConfig, err := node.ConfigLoad(tx, schema)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		current, err := nodeConfig.Dump()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := etag.Check(req, current); err != nil {
+			return errPreconditionFailed{err: err}
+		}
+
+		if patch {
+			changed, err = nodeConfig.Patch(info.Config)
+		} else {
+			changed, err = nodeConfig.Replace(info.Config)
+		}
+		return errors.WithStack(err)
+	}); err != nil {
+		switch errors.Cause(err).(type) {
+		case errPreconditionFailed:
+			return api.PreconditionFailed(err)
+		case config.ErrorList:
+			return api.BadRequest(err)
+		default:
+			return api.SmartError(err)
+		}
+	}
+
+	if err := schema.Trigger(changed); err != nil {
+		return api.SmartError(err)
+	}
+	return api.EmptySyncResponse()
+}
+
+type errPreconditionFailed struct {
+	err error
+}
+
+func (e errPreconditionFailed) Error() string {
+	return e.err.Error()
+}
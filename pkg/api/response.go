@@ -0,0 +1,259 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/bicycolet/bicycolet/internal/etag"
+	"github.com/bicycolet/bicycolet/internal/json"
+	"github.com/bicycolet/bicycolet/internal/operations"
+	"github.com/bicycolet/bicycolet/pkg/client"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+// Response defines a return value from a http request. The response then can
+// be rendered.
+type Response interface {
+
+	// Render the response with a response writer.
+	Render(http.ResponseWriter) error
+}
+
+// SyncResponse defines a response that is synchronous
+func SyncResponse(success bool, metadata interface{}) Response {
+	return &syncResponse{
+		success:  success,
+		metadata: metadata,
+	}
+}
+
+// SyncResponseETag defines a response that can add ETag as additional
+// information
+func SyncResponseETag(success bool, metadata interface{}, eTag interface{}) Response {
+	return &syncResponse{
+		success:  success,
+		metadata: metadata,
+		eTag:     eTag,
+	}
+}
+
+// EmptySyncResponse defines an empty successful response
+func EmptySyncResponse() Response {
+	return &syncResponse{
+		success:  true,
+		metadata: make(map[string]interface{}),
+	}
+}
+
+// Sync response
+type syncResponse struct {
+	success  bool
+	eTag     interface{}
+	metadata interface{}
+	logger   log.Logger
+}
+
+// Render will consume a http.ResponseWriter and return an error in a vistor
+// pattern scenario.
+func (r *syncResponse) Render(w http.ResponseWriter) error {
+	// Set an appropriate ETag header
+	if r.eTag != nil {
+		if eTag, err := etag.Hash(r.eTag); err == nil {
+			w.Header().Set("ETag", eTag)
+		}
+	}
+
+	status := http.StatusOK
+	if !r.success {
+		status = http.StatusBadRequest
+	}
+	w.WriteHeader(status)
+
+	return json.Write(w, client.ResponseRaw{
+		Type:       client.SyncResponse,
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Metadata:   r.metadata,
+	}, false, r.logger)
+}
+
+// OperationResponse defines a response that is asynchronous. The operation
+// is started when the response is rendered, so the operation is expected to
+// be already added to the daemon operations.
+func OperationResponse(op *operations.Operation) Response {
+	return &operationResponse{
+		op:     op,
+		logger: log.NewNopLogger(),
+	}
+}
+
+// Operation response
+type operationResponse struct {
+	op     *operations.Operation
+	logger log.Logger
+}
+
+// Render will consume a http.ResponseWriter and return an error in a vistor
+// pattern scenario.
+func (r *operationResponse) Render(w http.ResponseWriter) error {
+	if err := r.op.Run(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	op := RenderOp(r.op.Render())
+
+	w.Header().Set("Location", op.URL)
+	w.WriteHeader(http.StatusAccepted)
+
+	return json.Write(w, client.ResponseRaw{
+		Type:       client.AsyncResponse,
+		Status:     http.StatusText(http.StatusAccepted),
+		StatusCode: http.StatusAccepted,
+		Operation:  op.URL,
+		Metadata:   op,
+	}, false, r.logger)
+}
+
+// Error response
+type errorResponse struct {
+	code   int
+	msg    string
+	logger log.Logger
+}
+
+func (r *errorResponse) String() string {
+	return r.msg
+}
+
+// Render will consume a http.ResponseWriter and return an error in a vistor
+// pattern scenario.
+func (r *errorResponse) Render(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	w.WriteHeader(r.code)
+
+	return json.Write(w, client.ResponseRaw{
+		Type:  client.ErrorResponse,
+		Error: r.msg,
+		Code:  r.code,
+	}, false, r.logger)
+}
+
+// NotImplemented takes an error and returns a Response of not implemented.
+func NotImplemented(err error) Response {
+	message := "not implemented"
+	if err != nil {
+		message = err.Error()
+	}
+	return &errorResponse{
+		code: http.StatusNotImplemented,
+		msg:  message,
+	}
+}
+
+// NotFound takes an error and returns a Response of not found.
+func NotFound(err error) Response {
+	message := "not found"
+	if err != nil {
+		message = err.Error()
+	}
+	return &errorResponse{
+		code: http.StatusNotFound,
+		msg:  message,
+	}
+}
+
+// Forbidden takes an error and returns a Response of forbidden error.
+func Forbidden(err error) Response {
+	message := "not authorized"
+	if err != nil {
+		message = err.Error()
+	}
+	return &errorResponse{
+		code: http.StatusForbidden,
+		msg:  message,
+	}
+}
+
+// Conflict takes an error and returns a Response of conflict error.
+func Conflict(err error) Response {
+	message := "already exists"
+	if err != nil {
+		message = err.Error()
+	}
+	return &errorResponse{
+		code: http.StatusConflict,
+		msg:  message,
+	}
+}
+
+// Unavailable takes an error and returns a Response of unavailable error.
+func Unavailable(err error) Response {
+	message := "unavailable"
+	if err != nil {
+		message = err.Error()
+	}
+	return &errorResponse{
+		code: http.StatusServiceUnavailable,
+		msg:  message,
+	}
+}
+
+// BadRequest takes an error and returns a Response of badrequest error.
+func BadRequest(err error) Response {
+	return &errorResponse{
+		code: http.StatusBadRequest,
+		msg:  err.Error(),
+	}
+}
+
+// InternalError takes an error and returns a Response of internal server error.
+func InternalError(err error) Response {
+	return &errorResponse{
+		code: http.StatusInternalServerError,
+		msg:  err.Error(),
+	}
+}
+
+// PreconditionFailed takes an error and returns a Response of precondition
+// failed error.
+func PreconditionFailed(err error) Response {
+	return &errorResponse{
+		code: http.StatusPreconditionFailed,
+		msg:  err.Error(),
+	}
+}
+
+// SmartError returns the right error message based on err.
+func SmartError(err error) Response {
+	switch errors.Cause(err) {
+	case nil:
+		return EmptySyncResponse()
+	case os.ErrNotExist, sql.ErrNoRows, operations.ErrNotFound:
+		return NotFound(nil)
+	case os.ErrPermission:
+		return Forbidden(nil)
+	default:
+		return InternalError(err)
+	}
+}
+
+// RenderOp renders an operations.Op into a serialisable operation that can be
+// sent to a client.
+func RenderOp(op operations.Op) client.Operation {
+	return client.Operation{
+		ID:          op.ID,
+		URL:         fmt.Sprintf("/1.0%s", op.URL),
+		Description: op.Description,
+		Status:      op.Status,
+		StatusCode:  op.StatusCode.Raw(),
+		Metadata:    op.Metadata,
+		CreatedAt:   op.CreatedAt,
+		UpdatedAt:   op.UpdatedAt,
+		Err:         op.Err,
+	}
+}
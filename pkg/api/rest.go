@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/config"
+	"github.com/bicycolet/bicycolet/internal/db"
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/events"
+	"github.com/bicycolet/bicycolet/internal/operations"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/mux"
+)
+
+// Node mediates access to the data stored locally
+type Node interface {
+	db.NodeTransactioner
+
+	// DB returns the low level database handle to the node-local database.
+	DB() database.DB
+
+	// Dir returns the directory of the underlying database file.
+	Dir() string
+
+	// Backup dumps the node-local database into a new backup, returning the
+	// schema version it's named after.
+	Backup(context.Context) (int, error)
+
+	// Close the database facade.
+	Close() error
+}
+
+// Operations defines an interface for interacting with a series of operations
+type Operations interface {
+
+	// Add an operation to the collection
+	Add(*operations.Operation) error
+
+	// GetOp retrieves an op of the operation from the collection by id
+	GetOp(string) (operations.Op, error)
+
+	// DeleteOp attempts to cancel an operation by the id
+	DeleteOp(string) error
+
+	// WaitOp for an operation to be completed
+	WaitOp(context.Context, string, time.Duration) (bool, error)
+
+	// Walk over a collection of operations
+	Walk(func(operations.Op) error) error
+}
+
+// Events defines an interface for interacting with the event stream
+type Events interface {
+	events.Dispatcher
+
+	// Listen creates a listener for the given event types
+	Listen([]string) *events.Listener
+
+	// Forget removes the listener, so that no more events are sent
+	Forget(*events.Listener)
+}
+
+// Daemon can respond to requests from a shared client.
+type Daemon interface {
+
+	// SetupChan returns a channel that blocks until setup has happened from
+	// the Daemon
+	SetupChan() <-chan struct{}
+
+	// Node returns the underlying Node associated with the daemon
+	Node() Node
+
+	// NodeConfigSchema returns the daemon schema for the local Node
+	NodeConfigSchema() config.Schema
+
+	// Operations return the underlying operational tasks associated with the
+	// current daemon
+	Operations() Operations
+
+	// Events returns the event stream associated with the current daemon
+	Events() Events
+
+	// Version returns the current version of the daemon
+	Version() string
+
+	// NetworkAddress returns the address the daemon is serving the API on.
+	NetworkAddress() string
+}
+
+// RestServer creates a http.Handler that serves all the given services under
+// the "/1.0" prefix.
+func RestServer(d Daemon, services []Service, options ...Option) http.Handler {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	mux := mux.NewRouter()
+	mux.StrictSlash(false)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		SyncResponse(true, []string{"/1.0"}).Render(w)
+	})
+	mux.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		level.Info(opts.logger).Log("msg", "Sending top level 404", "url", r.URL)
+		w.Header().Set("Content-Type", "application/json")
+		NotFound(nil).Render(w)
+	})
+
+	router := &ServiceRouter{
+		daemon: d,
+		mux:    mux,
+		logger: opts.logger,
+	}
+	for _, service := range services {
+		router.Add("1.0", service)
+	}
+	return router
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// Service represents a endpoint that can perform http actions upon
+type Service interface {
+
+	// Get handles GET requests
+	Get(context.Context, *http.Request) Response
+
+	// Put handles PUT requests
+	Put(context.Context, *http.Request) Response
+
+	// Post handles POST requests
+	Post(context.Context, *http.Request) Response
+
+	// Delete handles DELETE requests
+	Delete(context.Context, *http.Request) Response
+
+	// Patch handles PATCH requests
+	Patch(context.Context, *http.Request) Response
+
+	// Name returns the serialisable service name.
+	// The name has to conform to RFC 3986
+	Name() string
+}
+
+// DefaultService creates a default service that just returns not
+// implemented errors
+type DefaultService struct{}
+
+// Get handles GET requests, but always returns NotImplemented
+func (DefaultService) Get(ctx context.Context, req *http.Request) Response {
+	return NotImplemented(nil)
+}
+
+// Put handles PUT requests, but always returns NotImplemented
+func (DefaultService) Put(ctx context.Context, req *http.Request) Response {
+	return NotImplemented(nil)
+}
+
+// Post handles POST requests, but always returns NotImplemented
+func (DefaultService) Post(ctx context.Context, req *http.Request) Response {
+	return NotImplemented(nil)
+}
+
+// Delete handles DELETE requests, but always returns NotImplemented
+func (DefaultService) Delete(ctx context.Context, req *http.Request) Response {
+	return NotImplemented(nil)
+}
+
+// Patch handles PATCH requests, but always returns NotImplemented
+func (DefaultService) Patch(ctx context.Context, req *http.Request) Response {
+	return NotImplemented(nil)
+}
+
+// ContextKey defines a key that can be used to identify values within a
+// context value.
+type ContextKey string
+
+const (
+	// DaemonKey represents a way to identify a daemon in a context
+	DaemonKey ContextKey = "daemon"
+)
+
+// GetDaemon returns the Daemon from the context or return an error
+func GetDaemon(ctx context.Context) (Daemon, error) {
+	d, ok := ctx.Value(DaemonKey).(Daemon)
+	if !ok {
+		return nil, errors.Errorf("daemon not found")
+	}
+	return d, nil
+}
+
+// ServiceRouter creates a wrapper of a underlying router, then allows
+// services to be added and handled.
+type ServiceRouter struct {
+	daemon Daemon
+	mux    *mux.Router
+	logger log.Logger
+}
+
+// Add a Service to the ServiceRouter with a prefix (1.0)
+func (s *ServiceRouter) Add(prefix string, service Service) {
+	uri := serviceURI(prefix, service)
+
+	level.Debug(s.logger).Log("msg", "Registering service", "uri", uri, "name", service.Name())
+
+	s.mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Block API requests until we're done with basic initialization
+		// tasks, such setting up the database.
+		select {
+		case <-s.daemon.SetupChan():
+		default:
+			response := Unavailable(errors.Errorf("daemon setup in progress"))
+			response.Render(w)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), DaemonKey, s.daemon)
+
+		var resp Response
+		switch r.Method {
+		case "GET":
+			resp = service.Get(ctx, r)
+		case "PUT":
+			resp = service.Put(ctx, r)
+		case "POST":
+			resp = service.Post(ctx, r)
+		case "DELETE":
+			resp = service.Delete(ctx, r)
+		case "PATCH":
+			resp = service.Patch(ctx, r)
+		default:
+			resp = NotFound(errors.Errorf("method %q not found for %q", r.Method, uri))
+		}
+
+		if err := resp.Render(w); err != nil {
+			if internalErr := InternalError(err).Render(w); internalErr != nil {
+				level.Error(s.logger).Log("msg", "failed writing error for error, giving up", "method", r.Method, "uri", uri)
+			}
+		}
+	})
+}
+
+func (s *ServiceRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func serviceURI(version string, s Service) string {
+	if name := s.Name(); name != "" {
+		return fmt.Sprintf("/%s/%s", version, name)
+	}
+	return fmt.Sprintf("/%s", version)
+}
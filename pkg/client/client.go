@@ -2,12 +2,15 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 
+	"github.com/bicycolet/bicycolet/internal/cert"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/gorilla/websocket"
@@ -21,16 +24,47 @@ type Client struct {
 	httpProtocol  string
 	httpUserAgent string
 
+	// dial is used for establishing the websocket connections, if it's nil
+	// then the default dialer is used.
+	dial func(network, addr string) (net.Conn, error)
+
 	logger log.Logger
 }
 
-// New lets you connect to a remote daemon over HTTP.
+// New lets you connect to a remote daemon over HTTP or HTTPS, or to a local
+// daemon over a unix socket.
+//
+// The url scheme decides the protocol, if no scheme is given then HTTPS is
+// used when any of the TLS options are set, otherwise HTTP is used. A url of
+// the form unix:///path/to/socket dials the unix socket, in which case the
+// TLS options are ignored, as access is controlled by the socket file
+// permissions.
+//
+// A client certificate (TLSClientCert) and key (TLSClientKey) must be
+// provided for the daemon to trust the client. Unless the remote server is
+// trusted by the CA bundle (TLSCA) or the system CA, the remote certificate
+// (TLSServerCert) or its fingerprint (TLSServerFingerprint) must be provided.
 func New(url string, options ...Option) (*Client, error) {
 	opts := newOptions()
 	for _, option := range options {
 		option(opts)
 	}
 
+	if strings.HasPrefix(url, "unix://") {
+		return newUnix(strings.TrimPrefix(url, "unix://"), opts)
+	}
+
+	protocol, host := "http", url
+	switch {
+	case strings.HasPrefix(url, "https://"):
+		protocol, host = "https", strings.TrimPrefix(url, "https://")
+	case strings.HasPrefix(url, "http://"):
+		host = strings.TrimPrefix(url, "http://")
+	case opts.tlsClientCert != "" || opts.tlsCA != "" ||
+		opts.tlsServerCert != "" || opts.tlsServerFingerprint != "":
+		protocol = "https"
+	}
+
 	// Setup the HTTP client
 	httpClient, err := tlsHTTPClient(opts)
 	if err != nil {
@@ -39,10 +73,37 @@ func New(url string, options ...Option) (*Client, error) {
 
 	// Initialize the client struct
 	return &Client{
-		httpHost:      url,
-		httpProtocol:  "http",
+		httpHost:      fmt.Sprintf("%s://%s", protocol, host),
+		httpProtocol:  protocol,
+		httpUserAgent: opts.userAgent,
+		http:          httpClient,
+		logger:        opts.logger,
+	}, nil
+}
+
+func newUnix(path string, opts *options) (*Client, error) {
+	if path == "" {
+		return nil, errors.New("expected unix socket path")
+	}
+
+	dial := func(network, addr string) (net.Conn, error) {
+		return net.Dial("unix", path)
+	}
+
+	// Setup the HTTP client
+	httpClient, err := unixHTTPClient(opts, dial)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Initialize the client struct, the host is never resolved, as every
+	// connection is dialed to the unix socket.
+	return &Client{
+		httpHost:      "http://unix.socket",
+		httpProtocol:  "unix",
 		httpUserAgent: opts.userAgent,
 		http:          httpClient,
+		dial:          dial,
 		logger:        opts.logger,
 	}, nil
 }
@@ -167,7 +228,7 @@ func (c *Client) rawWebsocket(url string) (*websocket.Conn, error) {
 
 	// Setup a new websocket dialer based on it
 	dialer := websocket.Dialer{
-		NetDial:         httpTransport.Dial,
+		NetDial:         c.dial,
 		TLSClientConfig: httpTransport.TLSClientConfig,
 		Proxy:           httpTransport.Proxy,
 	}
@@ -191,8 +252,23 @@ func (c *Client) rawWebsocket(url string) (*websocket.Conn, error) {
 }
 
 func tlsHTTPClient(opts *options) (*http.Client, error) {
+	// Get the TLS configuration
+	tlsConfig, err := cert.ClientTLSConfig(
+		opts.tlsClientCert,
+		opts.tlsClientKey,
+		opts.tlsCA,
+		opts.tlsServerCert,
+		opts.tlsServerFingerprint,
+		opts.insecureSkipVerify,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	// Define the http transport
-	transport := &http.Transport{}
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
 
 	// Allow overriding the proxy
 	proxy := opts.proxy
@@ -217,6 +293,31 @@ func tlsHTTPClient(opts *options) (*http.Client, error) {
 	return client, nil
 }
 
+func unixHTTPClient(opts *options, dial func(network, addr string) (net.Conn, error)) (*http.Client, error) {
+	// Define the http transport
+	transport := &http.Transport{
+		DialContext: func(_ context.Context, network, addr string) (net.Conn, error) {
+			return dial(network, addr)
+		},
+	}
+
+	// Define the http client
+	client := opts.httpClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	client.Transport = transport
+
+	// Setup redirect policy
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// Replicate the headers
+		req.Header = via[len(via)-1].Header
+		return nil
+	}
+
+	return client, nil
+}
+
 func parseResponse(resp *http.Response) (*Response, string, error) {
 	// Get the ETag
 	etag := resp.Header.Get("ETag")
@@ -0,0 +1,16 @@
+package client
+
+import "time"
+
+// Operation represents a background operation on the daemon
+type Operation struct {
+	ID          string                 `json:"id" yaml:"id"`
+	URL         string                 `json:"url" yaml:"url"`
+	Description string                 `json:"description" yaml:"description"`
+	Status      string                 `json:"status" yaml:"status"`
+	StatusCode  int                    `json:"status_code" yaml:"status_code"`
+	Metadata    map[string]interface{} `json:"metadata" yaml:"metadata"`
+	CreatedAt   time.Time              `json:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" yaml:"updated_at"`
+	Err         string                 `json:"err" yaml:"err"`
+}
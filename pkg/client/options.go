@@ -11,6 +11,25 @@ import (
 type Option func(*options)
 
 type options struct {
+	// TLS certificate of the remote server. If not specified, the system CA is
+	// used.
+	tlsServerCert string
+
+	// TLS fingerprint of the remote server certificate.
+	tlsServerFingerprint string
+
+	// TLS certificate to use for client authentication.
+	tlsClientCert string
+
+	// TLS key to use for client authentication.
+	tlsClientKey string
+
+	// TLS CA bundle to validate the remote server against.
+	tlsCA string
+
+	// Skip the verification of the remote server certificate.
+	insecureSkipVerify bool
+
 	// User agent string
 	userAgent string
 
@@ -24,6 +43,48 @@ type options struct {
 	logger log.Logger
 }
 
+// WithTLSServerCert sets the PEM encoded server cert on the option
+func WithTLSServerCert(serverCert string) Option {
+	return func(options *options) {
+		options.tlsServerCert = serverCert
+	}
+}
+
+// WithTLSServerFingerprint sets the server cert fingerprint on the option
+func WithTLSServerFingerprint(fingerprint string) Option {
+	return func(options *options) {
+		options.tlsServerFingerprint = fingerprint
+	}
+}
+
+// WithTLSClientCert sets the PEM encoded client cert on the option
+func WithTLSClientCert(clientCert string) Option {
+	return func(options *options) {
+		options.tlsClientCert = clientCert
+	}
+}
+
+// WithTLSClientKey sets the PEM encoded client key on the option
+func WithTLSClientKey(clientKey string) Option {
+	return func(options *options) {
+		options.tlsClientKey = clientKey
+	}
+}
+
+// WithTLSCA sets the PEM encoded CA bundle on the option
+func WithTLSCA(ca string) Option {
+	return func(options *options) {
+		options.tlsCA = ca
+	}
+}
+
+// WithInsecureSkipVerify sets the insecureSkipVerify on the option
+func WithInsecureSkipVerify(insecureSkipVerify bool) Option {
+	return func(options *options) {
+		options.insecureSkipVerify = insecureSkipVerify
+	}
+}
+
 // WithUserAgent sets the userAgent on the option
 func WithUserAgent(userAgent string) Option {
 	return func(options *options) {
@@ -0,0 +1,474 @@
+package daemon
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/cert"
+	"github.com/bicycolet/bicycolet/internal/config"
+	"github.com/bicycolet/bicycolet/internal/db"
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/events"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	inet "github.com/bicycolet/bicycolet/internal/net"
+	"github.com/bicycolet/bicycolet/internal/node"
+	"github.com/bicycolet/bicycolet/internal/operations"
+	"github.com/bicycolet/bicycolet/pkg/api"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// Node mediates access to the data stored in the node-local database.
+type Node interface {
+	api.Node
+
+	// Open the node-local database and ensure that the schema is up to date.
+	Open(string, database.ConnectionInfo, func(*db.Node) error) error
+
+	// EnsureBackgroundPatches runs the pending patches of the node-local
+	// database that are meant to run in background.
+	EnsureBackgroundPatches(context.Context) ([]string, error)
+}
+
+const (
+	// operationsPruneInterval is the interval between removing any finished
+	// operations.
+	operationsPruneInterval = time.Minute
+
+	// trustedDir is the directory within the data directory that holds the
+	// trusted client certificates.
+	trustedDir = "trusted"
+
+	// unixSocket is the name of the unix socket within the data directory
+	// that serves the API to local clients.
+	unixSocket = "unix.socket"
+
+	// unixSocketMode is the file mode of the unix socket, access to the socket
+	// grants full access to the API.
+	unixSocketMode = 0660
+)
+
+// Daemon can respond to requests from a shared client.
+type Daemon struct {
+	version        string
+	networkAddress string
+	dataDir        string
+	connectionInfo database.ConnectionInfo
+	db             Node
+	operations     *operations.Operations
+	events         *events.Broadcaster
+	apiServices    []api.Service
+	configSchema   config.Schema
+
+	// operationsRetention is how long finished operations are kept around,
+	// it's updated when the "operations.retention" config changes.
+	operationsRetention int64
+
+	debugMutex  sync.Mutex
+	debugServer *http.Server
+
+	serverCert   *cert.Info
+	server       *http.Server
+	listener     net.Listener
+	unixServer   *http.Server
+	unixListener net.Listener
+
+	setupChan    chan struct{}
+	shutdownChan chan struct{}
+	shutdownOnce sync.Once
+	ctx          context.Context
+	cancel       context.CancelFunc
+
+	// patchesGroup tracks the background patches, which must be done before
+	// the database is closed.
+	patchesGroup sync.WaitGroup
+
+	fileSystem      fsys.FileSystem
+	shutdownTimeout time.Duration
+	logger          log.Logger
+}
+
+// New creates a Daemon with sane defaults
+func New(
+	version string,
+	networkAddress string,
+	dataDir string,
+	connectionInfo database.ConnectionInfo,
+	apiServices []api.Service,
+	options ...Option,
+) *Daemon {
+	opts := newOptions()
+	for _, option := range options {
+		option(opts)
+	}
+
+	nodeDB := db.NewNode(opts.fileSystem,
+		database.WithMetrics(opts.databaseMetrics),
+		database.WithLogger(log.WithPrefix(opts.logger, "component", "database")),
+		database.WithSlowQueryThreshold(opts.slowQueryThreshold),
+	)
+	nodeDB.AppVersion(version)
+	nodeDB.AcceptChecksums(opts.acceptSchemaChecksums)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Daemon{
+		version:        version,
+		networkAddress: networkAddress,
+		dataDir:        dataDir,
+		connectionInfo: connectionInfo,
+		db:             nodeDB,
+		operations: operations.New(
+			operations.WithChanger(operationsChanger{events: opts.events}),
+			operations.WithLogger(log.WithPrefix(opts.logger, "component", "operations")),
+		),
+		events:          opts.events,
+		apiServices:     apiServices,
+		setupChan:       make(chan struct{}),
+		shutdownChan:    make(chan struct{}),
+		ctx:             ctx,
+		cancel:          cancel,
+		fileSystem:      opts.fileSystem,
+		shutdownTimeout: opts.shutdownTimeout,
+		logger:          opts.logger,
+	}
+	return d
+}
+
+// Init the Daemon, opening the node-local database and bringing up the
+// API endpoint.
+func (d *Daemon) Init() error {
+	level.Info(d.logger).Log("msg", "starting daemon", "version", d.version)
+
+	if err := d.initDatabase(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := d.initConfig(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := d.initCertificates(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := d.initEndpoints(); err != nil {
+		return errors.WithStack(err)
+	}
+	go d.pruneOperations()
+	d.patchesGroup.Add(1)
+	go d.ensureBackgroundPatches()
+
+	close(d.setupChan)
+
+	d.dispatchLifecycle("daemon-started")
+	return nil
+}
+
+// Stop the Daemon, closing the API endpoint and the node-local database. It
+// is safe to call Stop even if Init failed part way through.
+func (d *Daemon) Stop() error {
+	level.Info(d.logger).Log("msg", "stopping daemon")
+
+	d.cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.shutdownTimeout)
+	defer cancel()
+
+	// Event listeners are closed first, as they're long lived connections
+	// that the API endpoint won't wait for.
+	d.dispatchLifecycle("daemon-stopping")
+	if err := d.events.Close(ctx); err != nil {
+		level.Warn(d.logger).Log("msg", "timed out closing event listeners", "err", err)
+	}
+
+	var result error
+	if d.server != nil {
+		if err := d.server.Shutdown(ctx); err != nil {
+			result = errors.Wrap(err, "failed to shutdown API endpoint")
+		}
+		d.server = nil
+	}
+	if d.unixServer != nil {
+		if err := d.unixServer.Shutdown(ctx); err != nil && result == nil {
+			result = errors.Wrap(err, "failed to shutdown local API endpoint")
+		}
+		d.unixServer = nil
+	}
+	if err := d.updateDebugAddress(""); err != nil && result == nil {
+		result = errors.Wrap(err, "failed to shutdown debug endpoint")
+	}
+	// The background patches are cancelled along with the context, wait for
+	// them to give up before closing the database under them.
+	d.patchesGroup.Wait()
+	if d.db.DB() != nil {
+		if err := d.db.Close(); err != nil && result == nil {
+			result = errors.Wrap(err, "failed to close database")
+		}
+	}
+	return result
+}
+
+// Kill signals the Daemon to shutdown. It is safe to call Kill multiple times.
+func (d *Daemon) Kill() {
+	d.shutdownOnce.Do(func() {
+		close(d.shutdownChan)
+	})
+}
+
+// SetupChan returns a channel that blocks until setup has happened from
+// the Daemon
+func (d *Daemon) SetupChan() <-chan struct{} {
+	return d.setupChan
+}
+
+// ShutdownChan returns a channel that blocks until shutdown has happened from
+// the Daemon.
+func (d *Daemon) ShutdownChan() <-chan struct{} {
+	return d.shutdownChan
+}
+
+// Node returns the underlying Node associated with the daemon
+func (d *Daemon) Node() api.Node {
+	return d.db
+}
+
+// NodeConfigSchema returns the daemon schema for the local Node
+func (d *Daemon) NodeConfigSchema() config.Schema {
+	return d.configSchema
+}
+
+// Operations return the underlying operational tasks associated with the
+// current daemon
+func (d *Daemon) Operations() api.Operations {
+	return d.operations
+}
+
+// Events returns the event stream associated with the current daemon
+func (d *Daemon) Events() api.Events {
+	return d.events
+}
+
+// Version returns the current version of the daemon
+func (d *Daemon) Version() string {
+	return d.version
+}
+
+// NetworkAddress returns the address the daemon is serving the API on.
+func (d *Daemon) NetworkAddress() string {
+	if d.listener != nil {
+		return d.listener.Addr().String()
+	}
+	return d.networkAddress
+}
+
+// UnixSocket returns the path of the unix socket the daemon is serving the
+// API on for local clients.
+func (d *Daemon) UnixSocket() string {
+	return filepath.Join(d.dataDir, unixSocket)
+}
+
+func (d *Daemon) initDatabase() error {
+	level.Info(d.logger).Log("msg", "initializing local database")
+
+	dir := filepath.Join(d.dataDir, "database")
+	if err := d.fileSystem.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "error creating database directory")
+	}
+	if err := d.db.Open(dir, d.connectionInfo, nil); err != nil {
+		return errors.Wrap(err, "error creating database")
+	}
+	return nil
+}
+
+// initConfig applies the current node config, by triggering the change
+// callbacks with all the config values.
+func (d *Daemon) initConfig() error {
+	level.Info(d.logger).Log("msg", "loading node config")
+
+	schema, err := d.nodeConfigSchema()
+	if err != nil {
+		return errors.Wrap(err, "error building node config schema")
+	}
+	d.configSchema = schema
+
+	values := make(map[string]string)
+	if err := d.db.Transaction(d.ctx, func(tx *db.NodeTx) error {
+		nodeConfig, err := node.ConfigLoad(tx, d.configSchema)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, name := range d.configSchema.Keys() {
+			if values[name], err = nodeConfig.Raw(name); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error loading node config")
+	}
+	return d.configSchema.Trigger(values)
+}
+
+// nodeConfigSchema returns the node config schema, with the change callbacks
+// that apply the config to the daemon.
+func (d *Daemon) nodeConfigSchema() (config.Schema, error) {
+	schema := node.ConfigSchema()
+	for name, fn := range map[string]func(string) error{
+		"core.debug_address":   d.updateDebugAddress,
+		"operations.retention": d.updateOperationsRetention,
+	} {
+		// The keys are known to be in the schema, so this can only fail
+		// if the schema and the daemon are out of step.
+		if err := schema.Watch(name, fn); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return schema, nil
+}
+
+func (d *Daemon) updateOperationsRetention(value string) error {
+	retention, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	atomic.StoreInt64(&d.operationsRetention, int64(retention))
+	return nil
+}
+
+func (d *Daemon) initCertificates() error {
+	level.Info(d.logger).Log("msg", "loading server certificate")
+
+	info, err := cert.KeyPairAndCA(d.dataDir, "server", cert.Server, cert.WithFileSystem(d.fileSystem))
+	if err != nil {
+		return errors.Wrap(err, "error loading server certificate")
+	}
+	d.serverCert = info
+
+	level.Info(d.logger).Log("msg", "loaded server certificate", "fingerprint", info.Fingerprint(), "ca", info.CA() != nil)
+	return nil
+}
+
+func (d *Daemon) initEndpoints() error {
+	handler := api.RestServer(
+		d,
+		d.apiServices,
+		api.WithLogger(log.WithPrefix(d.logger, "component", "api")),
+	)
+
+	// Local clients are trusted by the file permissions of the unix socket,
+	// so the client certificates are only checked on the network endpoint.
+	path := d.UnixSocket()
+
+	level.Info(d.logger).Log("msg", "starting local REST API", "socket", path)
+
+	unixListener, err := inet.UnixListener(path, unixSocketMode)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	d.unixListener = unixListener
+	d.unixServer = &http.Server{
+		Handler: handler,
+	}
+	go d.serve(d.unixServer, d.unixListener)
+
+	address := inet.CanonicalNetworkAddress(d.networkAddress)
+
+	level.Info(d.logger).Log("msg", "starting REST API", "address", address)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "cannot listen on network address")
+	}
+	d.listener = tls.NewListener(listener, cert.ServerTLSConfig(d.serverCert))
+	d.server = &http.Server{
+		Handler: d.trusted(handler),
+	}
+	go d.serve(d.server, d.listener)
+	return nil
+}
+
+func (d *Daemon) serve(server *http.Server, listener net.Listener) {
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		level.Error(d.logger).Log("msg", "REST API failed", "address", listener.Addr(), "err", err)
+		d.Kill()
+	}
+}
+
+func (d *Daemon) pruneOperations() {
+	ticker := time.NewTicker(operationsPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.operations.Prune(time.Duration(atomic.LoadInt64(&d.operationsRetention)))
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// ensureBackgroundPatches runs the pending patches of the node-local database
+// that are meant to run in background, until the daemon is stopped.
+func (d *Daemon) ensureBackgroundPatches() {
+	defer d.patchesGroup.Done()
+
+	applied, err := d.db.EnsureBackgroundPatches(d.ctx)
+	for _, name := range applied {
+		level.Info(d.logger).Log("msg", "applied database patch", "name", name)
+	}
+	if err != nil && d.ctx.Err() == nil {
+		level.Error(d.logger).Log("msg", "failed to apply database patches", "err", err)
+	}
+}
+
+func (d *Daemon) dispatchLifecycle(action string) {
+	if err := d.events.Dispatch(events.TypeLifecycle, events.Lifecycle{
+		Action: action,
+		Source: "/1.0",
+	}); err != nil {
+		level.Error(d.logger).Log("msg", "failed to dispatch lifecycle event", "action", action, "err", err)
+	}
+}
+
+// operationsChanger dispatches operational changes as events.
+type operationsChanger struct {
+	events events.Dispatcher
+}
+
+func (c operationsChanger) Change(op operations.Op) {
+	c.events.Dispatch(events.TypeOperation, api.RenderOp(op))
+}
+
+// trusted only allows requests through to the handler if the request was
+// made with a trusted client certificate. Client certificates are trusted if
+// they're signed by the server CA, or they are found in the trusted
+// directory within the data directory.
+func (d *Daemon) trusted(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trustedCerts, err := cert.ReadCerts(
+			filepath.Join(d.dataDir, trustedDir),
+			cert.WithFileSystem(d.fileSystem),
+		)
+		if err != nil {
+			level.Error(d.logger).Log("msg", "failed to read trusted certificates", "err", err)
+
+			w.Header().Set("Content-Type", "application/json")
+			api.InternalError(err).Render(w)
+			return
+		}
+
+		if !cert.CheckTrustedRequest(r, trustedCerts) {
+			level.Debug(d.logger).Log("msg", "rejecting untrusted client", "remote", r.RemoteAddr)
+
+			w.Header().Set("Content-Type", "application/json")
+			api.Forbidden(nil).Render(w)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
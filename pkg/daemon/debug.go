@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	inet "github.com/bicycolet/bicycolet/internal/net"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// updateDebugAddress stops any running debug endpoint and then starts a new
+// one serving the pprof endpoints and the published variables, which include
+// the database metrics, on the given address. An empty address disables the
+// debug endpoint.
+func (d *Daemon) updateDebugAddress(address string) error {
+	d.debugMutex.Lock()
+	defer d.debugMutex.Unlock()
+
+	if d.debugServer != nil {
+		level.Info(d.logger).Log("msg", "stopping debug endpoint")
+
+		if err := d.debugServer.Close(); err != nil {
+			return errors.Wrap(err, "failed to stop debug endpoint")
+		}
+		d.debugServer = nil
+	}
+	if address == "" {
+		return nil
+	}
+
+	address = inet.CanonicalNetworkAddress(address)
+
+	level.Info(d.logger).Log("msg", "starting debug endpoint", "address", address)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "cannot listen on debug address")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	server := &http.Server{
+		Handler: mux,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			level.Error(d.logger).Log("msg", "debug endpoint failed", "err", err)
+		}
+	}()
+	d.debugServer = server
+	return nil
+}
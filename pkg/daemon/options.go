@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/bicycolet/bicycolet/internal/db/database"
+	"github.com/bicycolet/bicycolet/internal/events"
+	"github.com/bicycolet/bicycolet/internal/fsys"
+	"github.com/go-kit/kit/log"
+)
+
+// Option to be passed to New to customize the resulting instance.
+type Option func(*options)
+
+type options struct {
+	fileSystem      fsys.FileSystem
+	events          *events.Broadcaster
+	shutdownTimeout time.Duration
+	logger          log.Logger
+
+	databaseMetrics       database.Metrics
+	slowQueryThreshold    time.Duration
+	acceptSchemaChecksums bool
+}
+
+// WithFileSystem sets the fileSystem on the options
+func WithFileSystem(fileSystem fsys.FileSystem) Option {
+	return func(options *options) {
+		options.fileSystem = fileSystem
+	}
+}
+
+// WithEvents sets the events broadcaster on the options
+func WithEvents(events *events.Broadcaster) Option {
+	return func(options *options) {
+		options.events = events
+	}
+}
+
+// WithShutdownTimeout sets how long to wait for in-flight requests to finish
+// when stopping the daemon.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(options *options) {
+		options.shutdownTimeout = timeout
+	}
+}
+
+// WithLogger sets the logger on the option
+func WithLogger(logger log.Logger) Option {
+	return func(options *options) {
+		options.logger = logger
+	}
+}
+
+// WithDatabaseMetrics sets the metrics recording the activity of the node
+// database on the options
+func WithDatabaseMetrics(metrics database.Metrics) Option {
+	return func(options *options) {
+		options.databaseMetrics = metrics
+	}
+}
+
+// WithSlowQueryThreshold sets how long a statement against the node database
+// can take before it's logged as a slow query. Zero disables the slow query
+// log.
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(options *options) {
+		options.slowQueryThreshold = threshold
+	}
+}
+
+// WithAcceptSchemaChecksums sets whether the schema updates of the node
+// database that have changed since they were applied are accepted, instead
+// of failing to open the database.
+func WithAcceptSchemaChecksums(accept bool) Option {
+	return func(options *options) {
+		options.acceptSchemaChecksums = accept
+	}
+}
+
+// Create a options instance with default values.
+func newOptions() *options {
+	return &options{
+		fileSystem:      fsys.NewLocalFileSystem(false),
+		events:          events.New(),
+		shutdownTimeout: 10 * time.Second,
+		logger:          log.NewNopLogger(),
+		databaseMetrics: database.NewDiscardMetrics(),
+	}
+}